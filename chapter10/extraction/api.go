@@ -2,57 +2,73 @@ package extraction
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
 )
 
 type Neighbor struct {
-	SystemID  string
-	SNPA      string
+	// SystemID is the IS-IS system ID, empty when the device reports N/A
+	SystemID string
+	Hostname string
+	SNPA     string
+	// AdjNumber is the level usage of the adjacency, 1 for L1, 2 for L2 and 3 for L1-2
 	AdjNumber int64
 	AdjState  string
-	IntAuth   string
-	Interface string
-	Area      string
-	VRF       string
-	Instance  string
+	HoldTime  time.Duration
+	// IntAuth, CircuitType, Transitions and LastTransition are only set from detail output
+	IntAuth        string
+	CircuitType    string
+	Transitions    int64
+	LastTransition time.Duration
+	Interface      string
+	Area           string
+	VRF            string
+	Instance       string
 }
 
 type InfoISIS struct {
-	Neighbors           []Neighbor
-	NeighborsByID       map[string]Neighbor
-	SystemID            string
-	PassiveInterface    string
-	GenerateWideMetrics string
-	AcceptWideMetrics   string
-	SupportedRoutes     string
-	AreaIds             []string
-	Instance            string
+	Neighbors []Neighbor
+	// NeighborsByID is keyed by system ID, or by hostname when the system ID is not reported,
+	// and holds every adjacency to that system, such as over parallel links, levels or VRFs
+	NeighborsByID map[string][]Neighbor
+	Instance      string
 }
 type deviceISIS struct {
 	TABLEProcessTag struct {
-		ROWProcessTag struct {
+		ROWProcessTag Rows[struct {
 			ProcessTagOut string `json:"process-tag-out"`
 			TABLEVrf      struct {
-				ROWVrf struct {
+				ROWVrf Rows[struct {
 					VrfNameOut      string `json:"vrf-name-out"`
 					AdjSummaryOut   string `json:"adj-summary-out"`
 					AdjInterfaceOut string `json:"adj-interface-out"`
 					TABLEProcessAdj struct {
-						ROWProcessAdj []struct {
-							AdjSysNameOut   string `json:"adj-sys-name-out"`
-							AdjSysIDOut     string `json:"adj-sys-id-out"`
-							AdjUsageOut     string `json:"adj-usage-out"`
-							AdjStateOut     string `json:"adj-state-out"`
-							AdjHoldTimeOut  string `json:"adj-hold-time-out"`
-							AdjIntfNameOut  string `json:"adj-intf-name-out"`
-							AdjDetailSetOut string `json:"adj-detail-set-out"`
-						} `json:"ROW_process_adj"`
+						ROWProcessAdj Rows[struct {
+							AdjSysNameOut        string `json:"adj-sys-name-out"`
+							AdjSysIDOut          string `json:"adj-sys-id-out"`
+							AdjUsageOut          string `json:"adj-usage-out"`
+							AdjStateOut          string `json:"adj-state-out"`
+							AdjHoldTimeOut       string `json:"adj-hold-time-out"`
+							AdjIntfNameOut       string `json:"adj-intf-name-out"`
+							AdjDetailSetOut      string `json:"adj-detail-set-out"`
+							AdjSNPAOut           string `json:"adj-snpa-out"`
+							AdjTransitionOut     string `json:"adj-transition-out"`
+							AdjLastTransitionOut string `json:"adj-last-transition-out"`
+							AdjCktTypeOut        string `json:"adj-ckt-type-out"`
+							AdjAuthOut           string `json:"adj-auth-out"`
+							AdjAreaAddrOut       string `json:"adj-area-addr-out"`
+						}] `json:"ROW_process_adj"`
 					} `json:"TABLE_process_adj"`
-				} `json:"ROW_vrf"`
+				}] `json:"ROW_vrf"`
 			} `json:"TABLE_vrf"`
-		} `json:"ROW_process_tag"`
+		}] `json:"ROW_process_tag"`
 	} `json:"TABLE_process_tag"`
 }
 
+// ParseISIS returns the adjacencies of every process tag and VRF from
+// "show isis adjacency" or "show isis adjacency detail", Instance is the
+// first process tag
 func ParseISIS(js []byte) (*InfoISIS, error) {
 	var isis deviceISIS
 	neighbors := []Neighbor{}
@@ -60,16 +76,65 @@ func ParseISIS(js []byte) (*InfoISIS, error) {
 	if err != nil {
 		return nil, err
 	}
-	instance := isis.TABLEProcessTag.ROWProcessTag.ProcessTagOut
-	for _, peer := range isis.TABLEProcessTag.ROWProcessTag.TABLEVrf.ROWVrf.TABLEProcessAdj.ROWProcessAdj {
-		nei := peer.AdjSysNameOut
-		inter := peer.AdjIntfNameOut
-		neighbors = append(neighbors, Neighbor{
-			SystemID:  nei,
-			Interface: inter})
+	instance := ""
+	byID := map[string][]Neighbor{}
+	for _, process := range isis.TABLEProcessTag.ROWProcessTag {
+		if instance == "" {
+			instance = process.ProcessTagOut
+		}
+		for _, vrf := range process.TABLEVrf.ROWVrf {
+			for _, peer := range vrf.TABLEProcessAdj.ROWProcessAdj {
+				nei := Neighbor{
+					SystemID:    notAvailable(peer.AdjSysIDOut),
+					Hostname:    peer.AdjSysNameOut,
+					SNPA:        notAvailable(peer.AdjSNPAOut),
+					AdjState:    peer.AdjStateOut,
+					IntAuth:     peer.AdjAuthOut,
+					CircuitType: peer.AdjCktTypeOut,
+					Interface:   peer.AdjIntfNameOut,
+					Area:        peer.AdjAreaAddrOut,
+					VRF:         vrf.VrfNameOut,
+					Instance:    process.ProcessTagOut,
+				}
+				if nei.AdjNumber, err = parseCount(peer.AdjUsageOut); err != nil {
+					return nil, fmt.Errorf("neighbor %s: invalid usage: %w", peer.AdjSysNameOut, err)
+				}
+				if nei.Transitions, err = parseCount(peer.AdjTransitionOut); err != nil {
+					return nil, fmt.Errorf("neighbor %s: invalid transitions: %w", peer.AdjSysNameOut, err)
+				}
+				if nei.HoldTime, err = ParseTimer(peer.AdjHoldTimeOut); err != nil {
+					return nil, fmt.Errorf("neighbor %s: %w", peer.AdjSysNameOut, err)
+				}
+				if nei.LastTransition, err = ParseTimer(peer.AdjLastTransitionOut); err != nil {
+					return nil, fmt.Errorf("neighbor %s: %w", peer.AdjSysNameOut, err)
+				}
+				neighbors = append(neighbors, nei)
+
+				id := nei.SystemID
+				if id == "" {
+					id = nei.Hostname
+				}
+				byID[id] = append(byID[id], nei)
+			}
+		}
 	}
 	return &InfoISIS{
-		Neighbors: neighbors,
-		Instance:  instance,
+		Neighbors:     neighbors,
+		NeighborsByID: byID,
+		Instance:      instance,
 	}, nil
 }
+
+func notAvailable(s string) string {
+	if s == "N/A" {
+		return ""
+	}
+	return s
+}
+
+func parseCount(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
@@ -5,36 +5,75 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestParseISIS(t *testing.T) {
+	sw1 := Neighbor{Hostname: "sw1", AdjNumber: 2, AdjState: "UP", HoldTime: 24 * time.Second,
+		Interface: "Ethernet1/49", VRF: "default", Instance: "internal"}
+	sw2 := Neighbor{Hostname: "sw2", AdjNumber: 2, AdjState: "UP", HoldTime: 26 * time.Second,
+		Interface: "Ethernet1/51", VRF: "default", Instance: "internal"}
+	oob1 := Neighbor{Hostname: "oob1", AdjNumber: 2, AdjState: "INIT", HoldTime: 29 * time.Second,
+		Interface: "mgmt0", VRF: "mgmt", Instance: "internal"}
+	pe1 := Neighbor{Hostname: "pe1", AdjNumber: 1, AdjState: "UP", HoldTime: 8 * time.Second,
+		Interface: "Ethernet1/1.100", VRF: "customer-a", Instance: "external"}
+
+	spine1 := Neighbor{SystemID: "0100.0000.0001", Hostname: "spine1", SNPA: "5254.0012.3401", AdjNumber: 2,
+		AdjState: "UP", HoldTime: 25 * time.Second, IntAuth: "md5", CircuitType: "L2", Transitions: 1,
+		LastTransition: 23 * 24 * time.Hour, Interface: "Ethernet1/49", Area: "49.0001", VRF: "default", Instance: "underlay"}
+	spine1Parallel := spine1
+	spine1Parallel.SNPA, spine1Parallel.HoldTime, spine1Parallel.Interface = "5254.0012.3451", 21*time.Second, "Ethernet1/51"
+	spine2 := Neighbor{SystemID: "0100.0000.0002", Hostname: "spine2", SNPA: "5254.0012.3402", AdjNumber: 2,
+		AdjState: "UP", HoldTime: 7 * time.Second, IntAuth: "md5", CircuitType: "L2", Transitions: 5,
+		LastTransition: time.Hour + 12*time.Minute + 9*time.Second, Interface: "Ethernet1/50", Area: "49.0001",
+		VRF: "default", Instance: "underlay"}
+	leaf3 := Neighbor{SystemID: "0100.0000.0013", Hostname: "leaf3", SNPA: "5254.0012.3413", AdjNumber: 3,
+		AdjState: "INIT", HoldTime: 29 * time.Second, IntAuth: "none", CircuitType: "L1-2",
+		Interface: "Ethernet1/3", Area: "49.0002", VRF: "default", Instance: "underlay"}
+
 	tests := []struct {
 		name string
+		file string
 		want *InfoISIS
 	}{
 		{
 			name: "Parsing device API output",
+			file: "isis.json",
 			want: &InfoISIS{
-				Instance: "internal",
-				Neighbors: []Neighbor{
-					Neighbor{
-						SystemID:  "sw1",
-						Interface: "Ethernet1/49",
-					},
-					Neighbor{
-						SystemID:  "sw2",
-						Interface: "Ethernet1/51",
-					},
+				Instance:      "internal",
+				Neighbors:     []Neighbor{sw1, sw2},
+				NeighborsByID: map[string][]Neighbor{"sw1": {sw1}, "sw2": {sw2}},
+			},
+		},
+		{
+			name: "Parsing several process tags and VRFs",
+			file: "isis_multi.json",
+			want: &InfoISIS{
+				Instance:      "internal",
+				Neighbors:     []Neighbor{sw1, sw2, oob1, pe1},
+				NeighborsByID: map[string][]Neighbor{"sw1": {sw1}, "sw2": {sw2}, "oob1": {oob1}, "pe1": {pe1}},
+			},
+		},
+		{
+			name: "Parsing adjacency detail output",
+			file: "isis_detail.json",
+			want: &InfoISIS{
+				Instance:  "underlay",
+				Neighbors: []Neighbor{spine1, spine1Parallel, spine2, leaf3},
+				NeighborsByID: map[string][]Neighbor{
+					"0100.0000.0001": {spine1, spine1Parallel},
+					"0100.0000.0002": {spine2},
+					"0100.0000.0013": {leaf3},
 				},
 			},
 		},
 	}
-	data, err := os.ReadFile(filepath.Join("testdata", "isis.json"))
-	if err != nil {
-		t.Fatalf("failed to read test data: %v", err)
-	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tc.file))
+			if err != nil {
+				t.Fatalf("failed to read test data: %v", err)
+			}
 			got, err := ParseISIS(data)
 			if err != nil {
 				t.Errorf("ParseISIS got error: %v, want nil", err)
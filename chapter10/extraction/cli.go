@@ -2,27 +2,38 @@ package extraction
 
 import (
 	"encoding/json"
+	"fmt"
+	"time"
 )
 
 type DeviceInterface struct {
 	TABLEInterface struct {
-		ROWInterface struct {
-			Interface  string `json:"interface"`
-			State      string `json:"state"`
-			AdminState string `json:"admin_state"`
-			Desc       string `json:"desc"`
-			EthMtu     string `json:"eth_mtu"`
-			EthBw      string `json:"eth_bw"`
-		} `json:"ROW_interface"`
+		ROWInterface Rows[struct {
+			Interface      string `json:"interface"`
+			State          string `json:"state"`
+			AdminState     string `json:"admin_state"`
+			Desc           string `json:"desc"`
+			EthMtu         string `json:"eth_mtu"`
+			EthBw          string `json:"eth_bw"`
+			EthLinkFlapped string `json:"eth_link_flapped"`
+			// SVIs report their own keys instead of the eth_ ones
+			SviAdminState string `json:"svi_admin_state"`
+			SviLineProto  string `json:"svi_line_proto"`
+			SviMtu        string `json:"svi_mtu"`
+			SviBw         string `json:"svi_bw"`
+		}] `json:"ROW_interface"`
 	} `json:"TABLE_interface"`
 }
 
 type InfoInterface struct {
 	Description string
-	Speed       string
-	MTU         string
-	OperStatus  string
-	AdminStatus string
+	// Bandwidth is in bits per second
+	Bandwidth   uint64
+	MTU         int
+	OperStatus  LinkState
+	AdminStatus LinkState
+	// LinkFlapped is the time since the last link state change, zero when it never changed
+	LinkFlapped time.Duration
 }
 
 type InfoInterfaces struct {
@@ -36,12 +47,33 @@ func ParseDeviceInterface(js []byte) (*InfoInterfaces, error) {
 	if err != nil {
 		return nil, err
 	}
-	intfs[devint.TABLEInterface.ROWInterface.Interface] = InfoInterface{
-		Description: devint.TABLEInterface.ROWInterface.Desc,
-		Speed:       devint.TABLEInterface.ROWInterface.EthBw,
-		MTU:         devint.TABLEInterface.ROWInterface.EthMtu,
-		OperStatus:  devint.TABLEInterface.ROWInterface.State,
-		AdminStatus: devint.TABLEInterface.ROWInterface.AdminState,
+	for _, row := range devint.TABLEInterface.ROWInterface {
+		intf := InfoInterface{Description: row.Desc}
+		if intf.Bandwidth, err = ParseBandwidth(firstSet(row.EthBw, row.SviBw)); err != nil {
+			return nil, fmt.Errorf("interface %s: %w", row.Interface, err)
+		}
+		if intf.MTU, err = ParseMTU(firstSet(row.EthMtu, row.SviMtu)); err != nil {
+			return nil, fmt.Errorf("interface %s: %w", row.Interface, err)
+		}
+		if intf.OperStatus, err = ParseLinkState(firstSet(row.State, row.SviLineProto)); err != nil {
+			return nil, fmt.Errorf("interface %s: %w", row.Interface, err)
+		}
+		if intf.AdminStatus, err = ParseLinkState(firstSet(row.AdminState, row.SviAdminState)); err != nil {
+			return nil, fmt.Errorf("interface %s: %w", row.Interface, err)
+		}
+		if intf.LinkFlapped, err = ParseTimer(row.EthLinkFlapped); err != nil {
+			return nil, fmt.Errorf("interface %s: invalid link flapped: %w", row.Interface, err)
+		}
+		intfs[row.Interface] = intf
 	}
 	return &InfoInterfaces{Interfaces: intfs}, nil
 }
+
+func firstSet(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -5,34 +5,53 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestParseInterfaces(t *testing.T) {
 	tests := []struct {
 		name string
+		file string
 		want *InfoInterfaces
 	}{
 		{
 			name: "Parsing device interfaces output",
+			file: "interface.json",
 			want: &InfoInterfaces{
 				Interfaces: map[string]InfoInterface{
 					"Ethernet1/3": {
 						Description: "CustomLab",
-						Speed:       "1000000",
-						MTU:         "1500",
-						OperStatus:  "up",
-						AdminStatus: "up",
+						Bandwidth:   1000000000,
+						MTU:         1500,
+						OperStatus:  LinkUp,
+						AdminStatus: LinkUp,
+						LinkFlapped: 114 * 24 * time.Hour,
 					},
 				},
 			},
 		},
-	}
-	data, err := os.ReadFile(filepath.Join("testdata", "interface.json"))
-	if err != nil {
-		t.Fatalf("failed to read test data: %v", err)
+		{
+			name: "Parsing several interfaces",
+			file: "interfaces.json",
+			want: &InfoInterfaces{
+				Interfaces: map[string]InfoInterface{
+					"mgmt0": {Description: "OOB", Bandwidth: 1000000000, MTU: 1500, OperStatus: LinkUp, AdminStatus: LinkUp,
+						LinkFlapped: 12*time.Minute + 34*time.Second},
+					"Ethernet1/1": {Description: "uplink-core1", Bandwidth: 100000000000, MTU: 9216, OperStatus: LinkUp, AdminStatus: LinkUp,
+						LinkFlapped: 51 * time.Hour},
+					"Ethernet1/2": {Bandwidth: 10000000000, MTU: 1500, OperStatus: LinkDown, AdminStatus: LinkDown},
+					"Vlan10":      {Description: "servers", Bandwidth: 1000000000, MTU: 9216, OperStatus: LinkUp, AdminStatus: LinkUp},
+					"nve1":        {Description: "vxlan", OperStatus: LinkUp, AdminStatus: LinkUp},
+				},
+			},
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tc.file))
+			if err != nil {
+				t.Fatalf("failed to read test data: %v", err)
+			}
 			got, err := ParseDeviceInterface(data)
 			if err != nil {
 				t.Errorf("ParseDeviceInterface got error: %v, want nil", err)
@@ -43,3 +62,35 @@ func TestParseInterfaces(t *testing.T) {
 		})
 	}
 }
+
+func TestParseInterfacesMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		js   string
+		want string
+	}{
+		{
+			name: "MTU",
+			js:   `{"TABLE_interface": {"ROW_interface": {"interface": "Ethernet1/1", "eth_mtu": "jumbo", "eth_bw": "1000000"}}}`,
+			want: `interface Ethernet1/1: invalid MTU "jumbo"`,
+		},
+		{
+			name: "Bandwidth",
+			js:   `{"TABLE_interface": {"ROW_interface": {"interface": "Ethernet1/1", "eth_mtu": "1500", "eth_bw": "fast"}}}`,
+			want: `interface Ethernet1/1: invalid bandwidth "fast"`,
+		},
+		{
+			name: "State",
+			js:   `{"TABLE_interface": {"ROW_interface": {"interface": "Ethernet1/1", "state": "sideways", "eth_mtu": "1500", "eth_bw": "1000000"}}}`,
+			want: `interface Ethernet1/1: invalid link state "sideways"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseDeviceInterface([]byte(tc.js))
+			if err == nil || err.Error() != tc.want {
+				t.Errorf("ParseDeviceInterface got error: %v, want %s", err, tc.want)
+			}
+		})
+	}
+}
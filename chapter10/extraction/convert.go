@@ -0,0 +1,210 @@
+package extraction
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LinkState is the administrative or operational state of an interface
+type LinkState int
+
+const (
+	LinkUnknown LinkState = iota
+	LinkUp
+	LinkDown
+)
+
+func (s LinkState) String() string {
+	switch s {
+	case LinkUp:
+		return "up"
+	case LinkDown:
+		return "down"
+	default:
+		return "unknown"
+	}
+}
+
+func (s LinkState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ParseLinkState accepts the state strings NX-OS reports, reasons such as
+// "sfp-missing" or "err-disabled" are down, an empty value is unknown
+func ParseLinkState(s string) (LinkState, error) {
+	switch state := strings.ToLower(strings.TrimSpace(s)); state {
+	case "":
+		return LinkUnknown, nil
+	case "up", "connected", "link-up":
+		return LinkUp, nil
+	case "down", "administratively down", "admin-down", "disabled", "err-disabled",
+		"link-not-connected", "notconnect", "sfp-missing", "xcvr-absent", "suspended":
+		return LinkDown, nil
+	default:
+		if strings.HasPrefix(state, "down") {
+			return LinkDown, nil
+		}
+		return LinkUnknown, fmt.Errorf("invalid link state %q", s)
+	}
+}
+
+// ParseBandwidth returns the bandwidth in bits per second. NX-OS reports eth_bw
+// in Kbit without a unit and eth_bw_str or eth_speed with one, so a bare number
+// is read in Kbit. An empty value is zero.
+func ParseBandwidth(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	number, unit := s, ""
+	if i >= 0 {
+		number, unit = s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	}
+	unit = strings.TrimSuffix(strings.TrimSuffix(unit, "/s"), "ps")
+
+	multipliers := map[string]float64{
+		"":     1e3,
+		"k":    1e3,
+		"kb":   1e3,
+		"kbit": 1e3,
+		"b":    1,
+		"bit":  1,
+		"m":    1e6,
+		"mb":   1e6,
+		"mbit": 1e6,
+		"g":    1e9,
+		"gb":   1e9,
+		"gbit": 1e9,
+	}
+	multiplier, ok := multipliers[unit]
+	if number == "" || !ok {
+		return 0, fmt.Errorf("invalid bandwidth %q", s)
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth %q", s)
+	}
+	// 1<<64 is the first float64 above math.MaxUint64, which it rounds up to
+	bps := n * multiplier
+	if math.IsNaN(bps) || bps < 0 || bps >= 1<<64 {
+		return 0, fmt.Errorf("invalid bandwidth %q: value out of range", s)
+	}
+	return uint64(bps), nil
+}
+
+// ParseMTU parses an MTU in bytes, an empty value is zero
+func ParseMTU(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	mtu, err := strconv.Atoi(s)
+	if err != nil || mtu <= 0 {
+		return 0, fmt.Errorf("invalid MTU %q", s)
+	}
+	return mtu, nil
+}
+
+// ParseCounter parses a packet or byte counter, an empty value is zero
+func ParseCounter(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid counter %q", s)
+	}
+	return n, nil
+}
+
+var timerUnits = map[string]time.Duration{
+	"y":      365 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+	"w":      7 * 24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"d":      24 * time.Hour,
+	"day":    24 * time.Hour,
+	"h":      time.Hour,
+	"hour":   time.Hour,
+	"m":      time.Minute,
+	"min":    time.Minute,
+	"minute": time.Minute,
+	"s":      time.Second,
+	"sec":    time.Second,
+	"second": time.Second,
+}
+
+// ParseTimer parses NX-OS timers such as hold times and link flapped values:
+// "00:00:24", "1d02h", "3w2d" or "16week(s) 2day(s)". An empty value, "never"
+// or "N/A" is zero.
+func ParseTimer(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "never") || strings.EqualFold(s, "N/A") {
+		return 0, nil
+	}
+
+	if strings.Contains(s, ":") {
+		parts := strings.Split(s, ":")
+		if len(parts) != 3 {
+			return 0, fmt.Errorf("invalid timer %q", s)
+		}
+		var d time.Duration
+		for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+			n, err := strconv.ParseInt(parts[i], 10, 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid timer %q", s)
+			}
+			if d, err = addTimer(d, n, unit); err != nil {
+				return 0, fmt.Errorf("invalid timer %q: %w", s, err)
+			}
+		}
+		return d, nil
+	}
+
+	rest := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(s, "(s)", ""), " ", ""))
+	var d time.Duration
+	for rest != "" {
+		digits := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+		if digits <= 0 {
+			return 0, fmt.Errorf("invalid timer %q", s)
+		}
+		n, err := strconv.ParseInt(rest[:digits], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timer %q: %w", s, err)
+		}
+		rest = rest[digits:]
+
+		letters := strings.IndexFunc(rest, func(r rune) bool { return r >= '0' && r <= '9' })
+		if letters < 0 {
+			letters = len(rest)
+		}
+		// Only words take a plural "s", "ms" is not minutes
+		word := rest[:letters]
+		unit, ok := timerUnits[word]
+		if !ok && len(word) > 2 {
+			unit, ok = timerUnits[strings.TrimSuffix(word, "s")]
+		}
+		if !ok {
+			return 0, fmt.Errorf("invalid timer %q", s)
+		}
+		if d, err = addTimer(d, n, unit); err != nil {
+			return 0, fmt.Errorf("invalid timer %q: %w", s, err)
+		}
+		rest = rest[letters:]
+	}
+	return d, nil
+}
+
+// addTimer adds n units to d, failing instead of overflowing
+func addTimer(d time.Duration, n int64, unit time.Duration) (time.Duration, error) {
+	if n > int64(math.MaxInt64-d)/int64(unit) {
+		return 0, errors.New("value out of range")
+	}
+	return d + time.Duration(n)*unit, nil
+}
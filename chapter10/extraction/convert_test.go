@@ -0,0 +1,177 @@
+package extraction
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint64
+		wantErr bool
+	}{
+		{in: "1000000", want: 1000000000},
+		{in: "100000000Kbit", want: 100000000000},
+		{in: "1000000 Kbit", want: 1000000000},
+		{in: "1000 Mb/s", want: 1000000000},
+		{in: "100Gb/s", want: 100000000000},
+		{in: "2.5 Gbps", want: 2500000000},
+		{in: "", want: 0},
+		{in: "Kbit", wantErr: true},
+		{in: "10 furlongs", wantErr: true},
+		{in: "18446744073709551615 Gbps", wantErr: true},
+		{in: "18446744073709551616 bit", wantErr: true},
+		{in: "10000000000 Gbps", want: 10000000000000000000},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseBandwidth(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseBandwidth(%q) got error: %v, want error %v", tc.in, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseBandwidth(%q) = %d, want %d", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseMTU(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{in: "9216", want: 9216},
+		{in: " 1500 ", want: 1500},
+		{in: "", want: 0},
+		{in: "0", wantErr: true},
+		{in: "jumbo", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseMTU(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseMTU(%q) got error: %v, want error %v", tc.in, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseMTU(%q) = %d, want %d", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseLinkState(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    LinkState
+		wantErr bool
+	}{
+		{in: "up", want: LinkUp},
+		{in: "UP", want: LinkUp},
+		{in: "down", want: LinkDown},
+		{in: "Administratively down", want: LinkDown},
+		{in: "sfp-missing", want: LinkDown},
+		{in: "down (Link not connected)", want: LinkDown},
+		{in: "", want: LinkUnknown},
+		{in: "sideways", want: LinkUnknown, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseLinkState(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseLinkState(%q) got error: %v, want error %v", tc.in, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseLinkState(%q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseCounter(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint64
+		wantErr bool
+	}{
+		{in: "79167802986755", want: 79167802986755},
+		{in: "18446744073709551615", want: 18446744073709551615},
+		{in: "", want: 0},
+		{in: "-1", wantErr: true},
+		{in: "12k", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseCounter(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseCounter(%q) got error: %v, want error %v", tc.in, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseCounter(%q) = %d, want %d", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseTimer(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "00:00:24", want: 24 * time.Second},
+		{in: "01:02:03", want: time.Hour + 2*time.Minute + 3*time.Second},
+		{in: "1d02h", want: 26 * time.Hour},
+		{in: "3w2d", want: 23 * 24 * time.Hour},
+		{in: "never", want: 0},
+		{in: "", want: 0},
+		{in: "00:24", wantErr: true},
+		{in: "2x", wantErr: true},
+		{in: "1d02", wantErr: true},
+		{in: "16week(s) 2day(s)", want: 114 * 24 * time.Hour},
+		{in: "3week(s)3day(s)", want: 24 * 24 * time.Hour},
+		{in: "1year(s)2week(s)", want: 379 * 24 * time.Hour},
+		{in: "5 minutes", want: 5 * time.Minute},
+		{in: "2mins 30secs", want: 2*time.Minute + 30*time.Second},
+		{in: "3hours", want: 3 * time.Hour},
+		{in: "5ms", wantErr: true},
+		{in: "5hs", wantErr: true},
+		{in: "99999999999999999999d", wantErr: true},
+		{in: "9999999999999w", wantErr: true},
+		{in: "99999999999:00:00", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseTimer(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseTimer(%q) got error: %v, want error %v", tc.in, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseTimer(%q) = %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+// The chapters are self-contained modules, so chapter6/unmarshalling keeps its own
+// copy of convert.go, which must stay identical apart from the package clause
+func TestConvertMatchesUnmarshalling(t *testing.T) {
+	ours, err := os.ReadFile("convert.go")
+	if err != nil {
+		t.Fatalf("failed to read convert.go: %v", err)
+	}
+	theirs, err := os.ReadFile(filepath.Join("..", "..", "chapter6", "unmarshalling", "convert.go"))
+	if err != nil {
+		t.Skipf("chapter6 is not available: %v", err)
+	}
+	ours = bytes.TrimPrefix(ours, []byte("package extraction\n"))
+	theirs = bytes.TrimPrefix(theirs, []byte("package unmarshalling\n"))
+	if !bytes.Equal(ours, theirs) {
+		t.Errorf("chapter6/unmarshalling/convert.go differs from convert.go, keep both copies identical")
+	}
+}
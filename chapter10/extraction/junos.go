@@ -0,0 +1,369 @@
+package extraction
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterParser(VendorJunos, "show interfaces", parseJunosInterfaces)
+	RegisterParser(VendorJunos, "show lldp neighbors", parseJunosLLDP)
+	RegisterParser(VendorJunos, "show isis adjacency", parseJunosISIS)
+	RegisterParser(VendorJunos, "show ospf neighbor", parseJunosOSPF)
+	RegisterParser(VendorJunos, "show bgp summary", parseJunosBGP)
+	RegisterParser(VendorJunos, "show arp", parseJunosARP)
+	RegisterParser(VendorJunos, "show arp no-resolve", parseJunosARP)
+	RegisterParser(VendorJunos, "show ethernet-switching table", parseJunosMACTable)
+}
+
+// junosValue is a leaf of "| display json" output, where every element is an
+// array of objects holding the text in "data"
+type junosValue []struct {
+	Data       string            `json:"data"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+func (v junosValue) String() string {
+	if len(v) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(v[0].Data)
+}
+
+// Method Seconds returns the junos:seconds attribute Junos adds to times
+func (v junosValue) Seconds() (time.Duration, error) {
+	if len(v) == 0 || v[0].Attributes["junos:seconds"] == "" {
+		return 0, nil
+	}
+	s := v[0].Attributes["junos:seconds"]
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid seconds %q", s)
+	}
+	return time.Duration(n) * time.Second, nil
+}
+
+type junosInterfaces struct {
+	InterfaceInformation []struct {
+		PhysicalInterface []struct {
+			Name             junosValue `json:"name"`
+			AdminStatus      junosValue `json:"admin-status"`
+			OperStatus       junosValue `json:"oper-status"`
+			Description      junosValue `json:"description"`
+			MTU              junosValue `json:"mtu"`
+			Speed            junosValue `json:"speed"`
+			InterfaceFlapped junosValue `json:"interface-flapped"`
+		} `json:"physical-interface"`
+	} `json:"interface-information"`
+}
+
+// parseJunosInterfaces reports an Unlimited MTU or speed, as on loopbacks, as zero
+func parseJunosInterfaces(output []byte, state *OperationalState) error {
+	var info junosInterfaces
+	if err := json.Unmarshal(output, &info); err != nil {
+		return err
+	}
+	for _, information := range info.InterfaceInformation {
+		for _, phy := range information.PhysicalInterface {
+			name := phy.Name.String()
+			intf := InterfaceState{Name: name, Description: phy.Description.String()}
+			var err error
+			if intf.AdminStatus, err = ParseLinkState(phy.AdminStatus.String()); err != nil {
+				return fmt.Errorf("interface %s: %w", name, err)
+			}
+			if intf.OperStatus, err = ParseLinkState(phy.OperStatus.String()); err != nil {
+				return fmt.Errorf("interface %s: %w", name, err)
+			}
+			if mtu := phy.MTU.String(); !junosUnspecified(mtu) {
+				if intf.MTU, err = ParseMTU(mtu); err != nil {
+					return fmt.Errorf("interface %s: %w", name, err)
+				}
+			}
+			if speed := phy.Speed.String(); !junosUnspecified(speed) {
+				if intf.Bandwidth, err = ParseBandwidth(speed); err != nil {
+					return fmt.Errorf("interface %s: %w", name, err)
+				}
+			}
+			if intf.LinkFlapped, err = phy.InterfaceFlapped.Seconds(); err != nil {
+				return fmt.Errorf("interface %s: invalid interface flapped: %w", name, err)
+			}
+			state.Interfaces = append(state.Interfaces, intf)
+		}
+	}
+	return nil
+}
+
+func junosUnspecified(s string) bool {
+	switch strings.ToLower(s) {
+	case "", "unlimited", "unspecified", "auto":
+		return true
+	}
+	return false
+}
+
+type junosLLDP struct {
+	LLDPNeighborsInformation []struct {
+		LLDPNeighborInformation []struct {
+			LocalPortID           junosValue `json:"lldp-local-port-id"`
+			LocalInterface        junosValue `json:"lldp-local-interface"`
+			RemoteChassisID       junosValue `json:"lldp-remote-chassis-id"`
+			RemotePortID          junosValue `json:"lldp-remote-port-id"`
+			RemotePortDescription junosValue `json:"lldp-remote-port-description"`
+			RemoteSystemName      junosValue `json:"lldp-remote-system-name"`
+		} `json:"lldp-neighbor-information"`
+	} `json:"lldp-neighbors-information"`
+}
+
+func parseJunosLLDP(output []byte, state *OperationalState) error {
+	var lldp junosLLDP
+	if err := json.Unmarshal(output, &lldp); err != nil {
+		return err
+	}
+	for _, information := range lldp.LLDPNeighborsInformation {
+		for _, nei := range information.LLDPNeighborInformation {
+			local := nei.LocalPortID.String()
+			if local == "" {
+				local = nei.LocalInterface.String()
+			}
+			chassis := nei.RemoteChassisID.String()
+			if mac, err := NormalizeMAC(chassis); err == nil {
+				chassis = mac
+			}
+			state.LLDPNeighbors = append(state.LLDPNeighbors, LLDPNeighbor{
+				LocalInterface:  local,
+				ChassisID:       chassis,
+				SystemName:      nei.RemoteSystemName.String(),
+				PortID:          nei.RemotePortID.String(),
+				PortDescription: nei.RemotePortDescription.String(),
+			})
+		}
+	}
+	return nil
+}
+
+type junosISIS struct {
+	ISISAdjacencyInformation []struct {
+		ISISAdjacency []struct {
+			InterfaceName  junosValue `json:"interface-name"`
+			SystemName     junosValue `json:"system-name"`
+			AdjacencyState junosValue `json:"adjacency-state"`
+			Holdtime       junosValue `json:"holdtime"`
+		} `json:"isis-adjacency"`
+	} `json:"isis-adjacency-information"`
+}
+
+// parseJunosISIS reports the system name as the neighbor ID when Junos could
+// not resolve it to a hostname
+func parseJunosISIS(output []byte, state *OperationalState) error {
+	var isis junosISIS
+	if err := json.Unmarshal(output, &isis); err != nil {
+		return err
+	}
+	for _, information := range isis.ISISAdjacencyInformation {
+		for _, adj := range information.ISISAdjacency {
+			name := adj.SystemName.String()
+			holdTime, err := junosSeconds(adj.Holdtime.String())
+			if err != nil {
+				return fmt.Errorf("neighbor %s: invalid hold time: %w", name, err)
+			}
+			adjacency := RoutingAdjacency{
+				Protocol:  "isis",
+				Hostname:  name,
+				Interface: adj.InterfaceName.String(),
+				State:     strings.ToLower(adj.AdjacencyState.String()),
+				HoldTime:  holdTime,
+			}
+			if isSystemID(name) {
+				adjacency.NeighborID, adjacency.Hostname = name, ""
+			}
+			state.Adjacencies = append(state.Adjacencies, adjacency)
+		}
+	}
+	return nil
+}
+
+// isSystemID reports whether s has the xxxx.xxxx.xxxx form of an ISIS system ID
+func isSystemID(s string) bool {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	for _, part := range parts {
+		if len(part) != 4 || strings.Trim(strings.ToLower(part), "0123456789abcdef") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+type junosOSPF struct {
+	OSPFNeighborInformation []struct {
+		OSPFNeighbor []struct {
+			NeighborAddress   junosValue `json:"neighbor-address"`
+			InterfaceName     junosValue `json:"interface-name"`
+			OSPFNeighborState junosValue `json:"ospf-neighbor-state"`
+			NeighborID        junosValue `json:"neighbor-id"`
+			ActivityTimer     junosValue `json:"activity-timer"`
+		} `json:"ospf-neighbor"`
+	} `json:"ospf-neighbor-information"`
+}
+
+func parseJunosOSPF(output []byte, state *OperationalState) error {
+	var ospf junosOSPF
+	if err := json.Unmarshal(output, &ospf); err != nil {
+		return err
+	}
+	for _, information := range ospf.OSPFNeighborInformation {
+		for _, nei := range information.OSPFNeighbor {
+			id := nei.NeighborID.String()
+			holdTime, err := junosSeconds(nei.ActivityTimer.String())
+			if err != nil {
+				return fmt.Errorf("neighbor %s: invalid activity timer: %w", id, err)
+			}
+			state.Adjacencies = append(state.Adjacencies, RoutingAdjacency{
+				Protocol:   "ospf",
+				NeighborID: id,
+				Address:    nei.NeighborAddress.String(),
+				Interface:  nei.InterfaceName.String(),
+				State:      strings.ToLower(nei.OSPFNeighborState.String()),
+				HoldTime:   holdTime,
+			})
+		}
+	}
+	return nil
+}
+
+func junosSeconds(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid seconds %q", s)
+	}
+	return time.Duration(n) * time.Second, nil
+}
+
+type junosBGP struct {
+	BGPInformation []struct {
+		BGPPeer []struct {
+			PeerAddress junosValue `json:"peer-address"`
+			PeerAS      junosValue `json:"peer-as"`
+			PeerState   junosValue `json:"peer-state"`
+			ElapsedTime junosValue `json:"elapsed-time"`
+			BGPRib      []struct {
+				Name                junosValue `json:"name"`
+				ReceivedPrefixCount junosValue `json:"received-prefix-count"`
+			} `json:"bgp-rib"`
+		} `json:"bgp-peer"`
+	} `json:"bgp-information"`
+}
+
+// parseJunosBGP takes the VRF from the routing table of the peer, inet.0 and
+// inet6.0 are the default VRF. Junos does not report the local AS in the summary.
+func parseJunosBGP(output []byte, state *OperationalState) error {
+	var bgp junosBGP
+	if err := json.Unmarshal(output, &bgp); err != nil {
+		return err
+	}
+	for _, information := range bgp.BGPInformation {
+		for _, peer := range information.BGPPeer {
+			address := peer.PeerAddress.String()
+			session := BGPSession{
+				Neighbor: address,
+				State:    strings.ToLower(peer.PeerState.String()),
+				VRF:      "default",
+			}
+			var err error
+			if session.RemoteAS, err = parseASN(peer.PeerAS.String()); err != nil {
+				return fmt.Errorf("neighbor %s: %w", address, err)
+			}
+			if session.Uptime, err = peer.ElapsedTime.Seconds(); err != nil {
+				return fmt.Errorf("neighbor %s: invalid elapsed time: %w", address, err)
+			}
+			for _, rib := range peer.BGPRib {
+				prefixes, err := ParseCounter(rib.ReceivedPrefixCount.String())
+				if err != nil {
+					return fmt.Errorf("neighbor %s: %w", address, err)
+				}
+				session.PrefixesReceived += prefixes
+				if instance, _, ok := strings.Cut(rib.Name.String(), ".inet"); ok {
+					session.VRF = instance
+				}
+			}
+			state.BGPSessions = append(state.BGPSessions, session)
+		}
+	}
+	return nil
+}
+
+type junosARP struct {
+	ARPTableInformation []struct {
+		ARPTableEntry []struct {
+			MACAddress    junosValue `json:"mac-address"`
+			IPAddress     junosValue `json:"ip-address"`
+			InterfaceName junosValue `json:"interface-name"`
+		} `json:"arp-table-entry"`
+	} `json:"arp-table-information"`
+}
+
+// parseJunosARP leaves the age and VRF empty, Junos does not report them here
+func parseJunosARP(output []byte, state *OperationalState) error {
+	var arp junosARP
+	if err := json.Unmarshal(output, &arp); err != nil {
+		return err
+	}
+	for _, information := range arp.ARPTableInformation {
+		for _, entry := range information.ARPTableEntry {
+			ip := entry.IPAddress.String()
+			mac, err := NormalizeMAC(entry.MACAddress.String())
+			if err != nil {
+				return fmt.Errorf("arp %s: %w", ip, err)
+			}
+			state.ARP = append(state.ARP, ARPEntry{IP: ip, MAC: mac, Interface: entry.InterfaceName.String()})
+		}
+	}
+	return nil
+}
+
+type junosMACTable struct {
+	L2ngL2aldRtbMacdb []struct {
+		L2ngL2aldMacEntryVlan []struct {
+			L2ngMacEntry []struct {
+				VlanName         junosValue `json:"l2ng-l2-mac-vlan-name"`
+				MACAddress       junosValue `json:"l2ng-l2-mac-address"`
+				Flags            junosValue `json:"l2ng-l2-mac-flags"`
+				LogicalInterface junosValue `json:"l2ng-l2-mac-logical-interface"`
+			} `json:"l2ng-mac-entry"`
+		} `json:"l2ng-l2ald-mac-entry-vlan"`
+	} `json:"l2ng-l2ald-rtb-macdb"`
+}
+
+// parseJunosMACTable reads the ELS ethernet-switching table, where the flag S
+// marks a static entry and the VLAN is only known by name
+func parseJunosMACTable(output []byte, state *OperationalState) error {
+	var table junosMACTable
+	if err := json.Unmarshal(output, &table); err != nil {
+		return err
+	}
+	for _, macdb := range table.L2ngL2aldRtbMacdb {
+		for _, vlan := range macdb.L2ngL2aldMacEntryVlan {
+			for _, entry := range vlan.L2ngMacEntry {
+				mac, err := NormalizeMAC(entry.MACAddress.String())
+				if err != nil {
+					return err
+				}
+				state.MACTable = append(state.MACTable, MACEntry{
+					MAC:       mac,
+					VLAN:      entry.VlanName.String(),
+					Interface: entry.LogicalInterface.String(),
+					Static:    strings.Contains(entry.Flags.String(), "S"),
+				})
+			}
+		}
+	}
+	return nil
+}
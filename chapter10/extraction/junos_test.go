@@ -0,0 +1,85 @@
+package extraction
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJunosParsers(t *testing.T) {
+	tests := []struct {
+		command string
+		want    *OperationalState
+	}{
+		{
+			command: "show interfaces",
+			want: &OperationalState{Interfaces: []InterfaceState{
+				{Name: "ge-0/0/0", Description: "to-spine1", AdminStatus: LinkUp, OperStatus: LinkUp, MTU: 9192,
+					Bandwidth: 1000000000, LinkFlapped: 3121860 * time.Second},
+				{Name: "ge-0/0/1", AdminStatus: LinkDown, OperStatus: LinkDown, MTU: 1514, Bandwidth: 10000000000},
+				{Name: "lo0", AdminStatus: LinkUp, OperStatus: LinkUp},
+			}},
+		},
+		{
+			command: "show lldp neighbors",
+			want: &OperationalState{LLDPNeighbors: []LLDPNeighbor{
+				{LocalInterface: "ge-0/0/0", ChassisID: "2c:6b:f5:38:46:c0", SystemName: "spine1", PortID: "Ethernet1/1"},
+			}},
+		},
+		{
+			command: "show isis adjacency",
+			want: &OperationalState{Adjacencies: []RoutingAdjacency{
+				{Protocol: "isis", Hostname: "spine1", Interface: "ge-0/0/0.0", State: "up", HoldTime: 22 * time.Second},
+				{Protocol: "isis", NeighborID: "0100.0000.0013", Interface: "ge-0/0/2.0", State: "initializing",
+					HoldTime: 8 * time.Second},
+			}},
+		},
+		{
+			command: "show ospf neighbor",
+			want: &OperationalState{Adjacencies: []RoutingAdjacency{
+				{Protocol: "ospf", NeighborID: "192.168.0.2", Address: "10.0.1.2", Interface: "ge-0/0/0.0", State: "full",
+					HoldTime: 34 * time.Second},
+			}},
+		},
+		{
+			command: "show bgp summary",
+			want: &OperationalState{BGPSessions: []BGPSession{
+				{Neighbor: "10.0.0.1", RemoteAS: 65001, State: "established", Uptime: 788645 * time.Second,
+					PrefixesReceived: 12, VRF: "default"},
+				{Neighbor: "192.0.2.9", RemoteAS: 64512, State: "active", Uptime: 190 * time.Second, VRF: "CUST-B"},
+			}},
+		},
+		{
+			command: "show arp no-resolve",
+			want: &OperationalState{ARP: []ARPEntry{
+				{IP: "10.0.0.1", MAC: "2c:6b:f5:38:46:c0", Interface: "ge-0/0/0.0"},
+			}},
+		},
+		{
+			command: "show ethernet-switching table",
+			want: &OperationalState{MACTable: []MACEntry{
+				{MAC: "00:50:56:a1:b2:c3", VLAN: "v10", Interface: "ge-0/0/3.0"},
+				{MAC: "00:50:56:a1:00:01", VLAN: "v10", Interface: "ge-0/0/4.0", Static: true},
+			}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.command, func(t *testing.T) {
+			file := strings.ReplaceAll(tc.command, " ", "_") + ".json"
+			data, err := os.ReadFile(filepath.Join("testdata", "junos", file))
+			if err != nil {
+				t.Fatalf("failed to read test data: %v", err)
+			}
+			got := &OperationalState{}
+			if err := got.Parse(VendorJunos, tc.command+" | display json", data); err != nil {
+				t.Fatalf("Parse got error: %v, want nil", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Parse returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package nxapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"extraction"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBatchSize is the number of commands sent in one request,
+// NX-API rejects batches much larger than this
+const DefaultBatchSize = 10
+
+// authCookie is set by the switch after a successful login and accepted
+// instead of the credentials until it expires
+const authCookie = "nxapi_auth"
+
+// Client sends show commands to the NX-API JSON-RPC endpoint of a switch
+type Client struct {
+	// URL is the endpoint, such as https://switch1/ins
+	URL      string
+	Username string
+	Password string
+	// BatchSize is the number of commands per request, zero uses DefaultBatchSize
+	BatchSize  int
+	HTTPClient *http.Client
+
+	mu     sync.Mutex
+	cookie *http.Cookie
+}
+
+// NewClient creates a client for the switch at host, verifyTLS false accepts self-signed certificates
+func NewClient(host, username, password string, verifyTLS bool) *Client {
+	return &Client{
+		URL:      "https://" + host + "/ins",
+		Username: username,
+		Password: password,
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: !verifyTLS},
+			},
+		},
+	}
+}
+
+// CommandError is the error object returned for a command the switch rejected
+type CommandError struct {
+	Command string
+	Code    int
+	Message string
+	// Detail is the CLI message, such as "% Invalid command at '^' marker."
+	Detail string
+}
+
+func (e *CommandError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("%q: %s (%d): %s", e.Command, e.Message, e.Code, e.Detail)
+	}
+	return fmt.Sprintf("%q: %s (%d)", e.Command, e.Message, e.Code)
+}
+
+type request struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  params `json:"params"`
+	ID      int    `json:"id"`
+}
+
+type params struct {
+	Cmd     string `json:"cmd"`
+	Version int    `json:"version"`
+}
+
+type response struct {
+	ID     int `json:"id"`
+	Result *struct {
+		Body json.RawMessage `json:"body"`
+	} `json:"result"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    *struct {
+			Msg string `json:"msg"`
+		} `json:"data"`
+	} `json:"error"`
+}
+
+// Show runs the commands in batches and returns the JSON body of each
+// command in order, ready for the extraction parsers
+// A rejected command stops the run with a *CommandError
+func (c *Client) Show(ctx context.Context, commands ...string) ([][]byte, error) {
+	size := c.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+
+	bodies := make([][]byte, 0, len(commands))
+	for start := 0; start < len(commands); start += size {
+		end := min(start+size, len(commands))
+		batch, err := c.send(ctx, commands[start:end])
+		if err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, batch...)
+	}
+	return bodies, nil
+}
+
+// send posts one batch, logging in again once when the session cookie has expired
+func (c *Client) send(ctx context.Context, commands []string) ([][]byte, error) {
+	reqs := make([]request, len(commands))
+	for i, command := range commands {
+		reqs[i] = request{JSONRPC: "2.0", Method: "cli", Params: params{Cmd: command, Version: 1}, ID: i + 1}
+	}
+	payload, err := json.Marshal(reqs)
+	if err != nil {
+		return nil, err
+	}
+
+	data, status, err := c.post(ctx, payload, true)
+	if err == nil && status == http.StatusUnauthorized && c.clearCookie() {
+		data, status, err = c.post(ctx, payload, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Command errors are reported with status 500 and a JSON-RPC body
+	if status != http.StatusOK && !looksLikeJSON(data) {
+		return nil, fmt.Errorf("NX-API returned status %d", status)
+	}
+
+	resps, err := decodeResponses(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NX-API response: %w", err)
+	}
+
+	bodies := make([][]byte, len(commands))
+	seen := make([]bool, len(commands))
+	for _, resp := range resps {
+		i := resp.ID - 1
+		if i < 0 || i >= len(commands) {
+			return nil, fmt.Errorf("invalid NX-API response: unexpected id %d", resp.ID)
+		}
+		if resp.Error != nil {
+			cmdErr := &CommandError{Command: commands[i], Code: resp.Error.Code, Message: resp.Error.Message}
+			if resp.Error.Data != nil {
+				cmdErr.Detail = strings.TrimSpace(resp.Error.Data.Msg)
+			}
+			return nil, cmdErr
+		}
+		// Commands without output, such as an empty table, have a null result
+		if resp.Result != nil && len(resp.Result.Body) > 0 {
+			bodies[i] = resp.Result.Body
+		} else {
+			bodies[i] = []byte("{}")
+		}
+		seen[i] = true
+	}
+	for i, ok := range seen {
+		if !ok {
+			return nil, fmt.Errorf("invalid NX-API response: no result for %q", commands[i])
+		}
+	}
+	return bodies, nil
+}
+
+// post sends the payload with the session cookie when there is one, otherwise with the credentials
+// The body is read whole, so chunked transfer encoding is handled by the transport
+func (c *Client) post(ctx context.Context, payload []byte, useCookie bool) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json-rpc")
+
+	c.mu.Lock()
+	cookie := c.cookie
+	c.mu.Unlock()
+	if useCookie && cookie != nil {
+		req.AddCookie(cookie)
+	} else {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("NX-API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == authCookie {
+			c.mu.Lock()
+			c.cookie = &http.Cookie{Name: cookie.Name, Value: cookie.Value}
+			c.mu.Unlock()
+		}
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read NX-API response: %w", err)
+	}
+	return data, resp.StatusCode, nil
+}
+
+// clearCookie drops the session cookie, reporting whether there was one to retry without
+func (c *Client) clearCookie() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	had := c.cookie != nil
+	c.cookie = nil
+	return had
+}
+
+func looksLikeJSON(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && (data[0] == '[' || data[0] == '{')
+}
+
+// decodeResponses accepts the array returned for a batch and the single
+// object some releases return for a batch of one
+func decodeResponses(data []byte) ([]response, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var resp response
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, err
+		}
+		return []response{resp}, nil
+	}
+	var resps []response
+	if err := json.Unmarshal(data, &resps); err != nil {
+		return nil, err
+	}
+	return resps, nil
+}
+
+// Interfaces runs "show interface" and parses it with extraction.ParseDeviceInterface
+func (c *Client) Interfaces(ctx context.Context) (*extraction.InfoInterfaces, error) {
+	bodies, err := c.Show(ctx, "show interface")
+	if err != nil {
+		return nil, err
+	}
+	return extraction.ParseDeviceInterface(bodies[0])
+}
+
+// ISIS runs "show isis adjacency detail" and parses it with extraction.ParseISIS
+func (c *Client) ISIS(ctx context.Context) (*extraction.InfoISIS, error) {
+	bodies, err := c.Show(ctx, "show isis adjacency detail")
+	if err != nil {
+		return nil, err
+	}
+	return extraction.ParseISIS(bodies[0])
+}
+
+// StateCommands are the commands collected by State when none are given
+var StateCommands = []string{
+	"show interface",
+	"show isis adjacency detail",
+	"show lldp neighbors detail",
+	"show ip bgp summary vrf all",
+	"show ip arp vrf all",
+	"show mac address-table",
+}
+
+// State runs the commands and parses them into the vendor neutral state,
+// StateCommands when none are given
+func (c *Client) State(ctx context.Context, commands ...string) (*extraction.OperationalState, error) {
+	if len(commands) == 0 {
+		commands = StateCommands
+	}
+	bodies, err := c.Show(ctx, commands...)
+	if err != nil {
+		return nil, err
+	}
+	state := &extraction.OperationalState{}
+	for i, command := range commands {
+		if err := state.Parse(extraction.VendorNXOS, command, bodies[i]); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
@@ -0,0 +1,267 @@
+package nxapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// fakeSwitch is an NX-API stand-in answering with the extraction test data
+type fakeSwitch struct {
+	mu       sync.Mutex
+	outputs  map[string]string
+	batches  [][]string
+	logins   int
+	sessions map[string]bool
+}
+
+func newFakeSwitch(t *testing.T) (*fakeSwitch, *httptest.Server) {
+	t.Helper()
+	files := map[string]string{
+		"show interface":              "../testdata/interfaces.json",
+		"show isis adjacency detail":  "../testdata/isis_detail.json",
+		"show lldp neighbors detail":  "../testdata/nxos/show_lldp_neighbors_detail.json",
+		"show ip bgp summary vrf all": "../testdata/nxos/show_ip_bgp_summary_vrf_all.json",
+		"show ip arp vrf all":         "../testdata/nxos/show_ip_arp.json",
+		"show mac address-table":      "../testdata/nxos/show_mac_address-table.json",
+	}
+	s := &fakeSwitch{outputs: map[string]string{"show vlan id 4000": ""}, sessions: map[string]bool{}}
+	for command, file := range files {
+		data, err := os.ReadFile(filepath.FromSlash(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.outputs[command] = string(data)
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func (s *fakeSwitch) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path != "/ins" || r.Header.Get("Content-Type") != "application/json-rpc" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if cookie, err := r.Cookie(authCookie); err != nil || !s.sessions[cookie.Value] {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		s.logins++
+		session := "session-" + strconv.Itoa(s.logins)
+		s.sessions[session] = true
+		http.SetCookie(w, &http.Cookie{Name: authCookie, Value: session})
+	}
+
+	var reqs []request
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var commands []string
+	var resps []json.RawMessage
+	status := http.StatusOK
+	for _, req := range reqs {
+		commands = append(commands, req.Params.Cmd)
+		output, ok := s.outputs[req.Params.Cmd]
+		switch {
+		case !ok:
+			status = http.StatusInternalServerError
+			resps = append(resps, json.RawMessage(`{"jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid params",
+				"data": {"msg": "% Invalid command at '^' marker.\n"}}, "id": `+strconv.Itoa(req.ID)+`}`))
+		case output == "":
+			resps = append(resps, json.RawMessage(`{"jsonrpc": "2.0", "result": null, "id": `+strconv.Itoa(req.ID)+`}`))
+		default:
+			resps = append(resps, json.RawMessage(`{"jsonrpc": "2.0", "result": {"body": `+output+`}, "id": `+strconv.Itoa(req.ID)+`}`))
+		}
+	}
+	s.batches = append(s.batches, commands)
+
+	data, _ := json.Marshal(resps)
+	if len(resps) == 1 {
+		data = resps[0]
+	}
+	w.Header().Set("Content-Type", "application/json-rpc")
+	w.WriteHeader(status)
+	// Write in pieces so the response is sent with chunked transfer encoding
+	for len(data) > 0 {
+		n := min(512, len(data))
+		w.Write(data[:n])
+		w.(http.Flusher).Flush()
+		data = data[n:]
+	}
+}
+
+func newTestClient(server *httptest.Server) *Client {
+	return &Client{
+		URL:        server.URL + "/ins",
+		Username:   "admin",
+		Password:   "secret",
+		HTTPClient: server.Client(),
+	}
+}
+
+func TestShowBatches(t *testing.T) {
+	s, server := newFakeSwitch(t)
+	client := newTestClient(server)
+	client.BatchSize = 4
+
+	bodies, err := client.Show(context.Background(), StateCommands...)
+	if err != nil {
+		t.Fatalf("Show got error: %v, want nil", err)
+	}
+	if len(bodies) != len(StateCommands) {
+		t.Fatalf("Show returned %d bodies, want %d", len(bodies), len(StateCommands))
+	}
+	for i, command := range StateCommands {
+		var got, want any
+		if err := json.Unmarshal(bodies[i], &got); err != nil {
+			t.Fatalf("body of %q is not JSON: %v", command, err)
+		}
+		json.Unmarshal([]byte(s.outputs[command]), &want)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("body of %q returned diff (-want +got):\n%s", command, diff)
+		}
+	}
+
+	wantBatches := [][]string{StateCommands[:4], StateCommands[4:]}
+	if diff := cmp.Diff(wantBatches, s.batches); diff != "" {
+		t.Errorf("batches returned diff (-want +got):\n%s", diff)
+	}
+	if s.logins != 1 {
+		t.Errorf("logins = %d, want 1, the session cookie should be reused", s.logins)
+	}
+}
+
+func TestShowErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		commands []string
+		want     *CommandError
+	}{
+		{
+			name:     "invalid command",
+			username: "admin",
+			commands: []string{"show interface", "show bogus"},
+			want: &CommandError{Command: "show bogus", Code: -32602, Message: "Invalid params",
+				Detail: "% Invalid command at '^' marker."},
+		},
+		{
+			name:     "single invalid command",
+			username: "admin",
+			commands: []string{"show bogus"},
+			want: &CommandError{Command: "show bogus", Code: -32602, Message: "Invalid params",
+				Detail: "% Invalid command at '^' marker."},
+		},
+		{
+			name:     "wrong credentials",
+			username: "operator",
+			commands: []string{"show interface"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, server := newFakeSwitch(t)
+			client := newTestClient(server)
+			client.Username = tt.username
+
+			_, err := client.Show(context.Background(), tt.commands...)
+			if err == nil {
+				t.Fatal("Show got nil error, want error")
+			}
+			var cmdErr *CommandError
+			if tt.want == nil {
+				if errors.As(err, &cmdErr) {
+					t.Errorf("Show got command error %v, want a transport error", err)
+				}
+				return
+			}
+			if !errors.As(err, &cmdErr) {
+				t.Fatalf("Show got error %v, want *CommandError", err)
+			}
+			if diff := cmp.Diff(tt.want, cmdErr); diff != "" {
+				t.Errorf("Show returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestShowNullResult(t *testing.T) {
+	_, server := newFakeSwitch(t)
+
+	bodies, err := newTestClient(server).Show(context.Background(), "show vlan id 4000")
+	if err != nil {
+		t.Fatalf("Show got error: %v, want nil", err)
+	}
+	if got := string(bodies[0]); got != "{}" {
+		t.Errorf("Show returned %s, want {}", got)
+	}
+}
+
+func TestExpiredCookie(t *testing.T) {
+	s, server := newFakeSwitch(t)
+	client := newTestClient(server)
+
+	if _, err := client.Show(context.Background(), "show interface"); err != nil {
+		t.Fatalf("Show got error: %v, want nil", err)
+	}
+	s.mu.Lock()
+	s.sessions = map[string]bool{}
+	s.mu.Unlock()
+	if _, err := client.Show(context.Background(), "show interface"); err != nil {
+		t.Fatalf("Show after expiry got error: %v, want nil", err)
+	}
+	if s.logins != 2 {
+		t.Errorf("logins = %d, want 2", s.logins)
+	}
+}
+
+func TestParsedResults(t *testing.T) {
+	_, server := newFakeSwitch(t)
+	client := newTestClient(server)
+	ctx := context.Background()
+
+	intfs, err := client.Interfaces(ctx)
+	if err != nil {
+		t.Fatalf("Interfaces got error: %v, want nil", err)
+	}
+	if len(intfs.Interfaces) != 5 {
+		t.Errorf("Interfaces returned %d interfaces, want 5", len(intfs.Interfaces))
+	}
+
+	isis, err := client.ISIS(ctx)
+	if err != nil {
+		t.Fatalf("ISIS got error: %v, want nil", err)
+	}
+	if len(isis.Neighbors) != 4 {
+		t.Errorf("ISIS returned %d neighbors, want 4", len(isis.Neighbors))
+	}
+
+	state, err := client.State(ctx)
+	if err != nil {
+		t.Fatalf("State got error: %v, want nil", err)
+	}
+	if len(state.Interfaces) != 5 || len(state.Adjacencies) != 4 {
+		t.Errorf("State returned %d interfaces and %d adjacencies, want 5 and 4",
+			len(state.Interfaces), len(state.Adjacencies))
+	}
+	if len(state.LLDPNeighbors) == 0 || len(state.BGPSessions) == 0 || len(state.ARP) == 0 || len(state.MACTable) == 0 {
+		t.Errorf("State returned %+v, want LLDP, BGP, ARP and MAC entries", state)
+	}
+}
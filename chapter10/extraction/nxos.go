@@ -0,0 +1,271 @@
+package extraction
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterParser(VendorNXOS, "show interface", parseNXOSInterfaces)
+	RegisterParser(VendorNXOS, "show isis adjacency", parseNXOSISIS)
+	RegisterParser(VendorNXOS, "show isis adjacency detail", parseNXOSISIS)
+	RegisterParser(VendorNXOS, "show lldp neighbors detail", parseNXOSLLDP)
+	RegisterParser(VendorNXOS, "show ip bgp summary", parseNXOSBGP)
+	RegisterParser(VendorNXOS, "show ip bgp summary vrf all", parseNXOSBGP)
+	RegisterParser(VendorNXOS, "show ip arp", parseNXOSARP)
+	RegisterParser(VendorNXOS, "show ip arp vrf all", parseNXOSARP)
+	RegisterParser(VendorNXOS, "show mac address-table", parseNXOSMACTable)
+}
+
+func parseNXOSInterfaces(output []byte, state *OperationalState) error {
+	info, err := ParseDeviceInterface(output)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(info.Interfaces))
+	for name := range info.Interfaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		intf := info.Interfaces[name]
+		state.Interfaces = append(state.Interfaces, InterfaceState{
+			Name:        name,
+			Description: intf.Description,
+			AdminStatus: intf.AdminStatus,
+			OperStatus:  intf.OperStatus,
+			MTU:         intf.MTU,
+			Bandwidth:   intf.Bandwidth,
+			LinkFlapped: intf.LinkFlapped,
+		})
+	}
+	return nil
+}
+
+func parseNXOSISIS(output []byte, state *OperationalState) error {
+	info, err := ParseISIS(output)
+	if err != nil {
+		return err
+	}
+	for _, nei := range info.Neighbors {
+		state.Adjacencies = append(state.Adjacencies, RoutingAdjacency{
+			Protocol:   "isis",
+			NeighborID: nei.SystemID,
+			Hostname:   nei.Hostname,
+			Interface:  nei.Interface,
+			State:      strings.ToLower(nei.AdjState),
+			HoldTime:   nei.HoldTime,
+			VRF:        nei.VRF,
+			Instance:   nei.Instance,
+		})
+	}
+	return nil
+}
+
+type nxosLLDP struct {
+	TABLENborDetail struct {
+		ROWNborDetail Rows[struct {
+			ChassisID string `json:"chassis_id"`
+			PortID    string `json:"port_id"`
+			LPortID   string `json:"l_port_id"`
+			PortDesc  string `json:"port_desc"`
+			SysName   string `json:"sys_name"`
+		}] `json:"ROW_nbor_detail"`
+	} `json:"TABLE_nbor_detail"`
+}
+
+func parseNXOSLLDP(output []byte, state *OperationalState) error {
+	var lldp nxosLLDP
+	if err := json.Unmarshal(output, &lldp); err != nil {
+		return err
+	}
+	for _, row := range lldp.TABLENborDetail.ROWNborDetail {
+		chassis := row.ChassisID
+		if mac, err := NormalizeMAC(chassis); err == nil {
+			chassis = mac
+		}
+		state.LLDPNeighbors = append(state.LLDPNeighbors, LLDPNeighbor{
+			LocalInterface:  expandNXOSInterface(row.LPortID),
+			ChassisID:       chassis,
+			SystemName:      row.SysName,
+			PortID:          row.PortID,
+			PortDescription: row.PortDesc,
+		})
+	}
+	return nil
+}
+
+// expandNXOSInterface expands the short Eth1/1 form LLDP uses to Ethernet1/1
+func expandNXOSInterface(name string) string {
+	if rest, ok := strings.CutPrefix(name, "Eth"); ok && rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+		return "Ethernet" + rest
+	}
+	return name
+}
+
+type nxosBGPSummary struct {
+	TABLEVrf struct {
+		ROWVrf Rows[struct {
+			VrfNameOut string `json:"vrf-name-out"`
+			VrfLocalAS string `json:"vrf-local-as"`
+			TABLEAf    struct {
+				ROWAf Rows[struct {
+					TABLESaf struct {
+						ROWSaf Rows[struct {
+							TABLENeighbor struct {
+								ROWNeighbor Rows[struct {
+									NeighborID     string `json:"neighborid"`
+									NeighborAS     string `json:"neighboras"`
+									Time           string `json:"time"`
+									State          string `json:"state"`
+									PrefixReceived string `json:"prefixreceived"`
+								}] `json:"ROW_neighbor"`
+							} `json:"TABLE_neighbor"`
+						}] `json:"ROW_saf"`
+					} `json:"TABLE_saf"`
+				}] `json:"ROW_af"`
+			} `json:"TABLE_af"`
+		}] `json:"ROW_vrf"`
+	} `json:"TABLE_vrf"`
+}
+
+// parseNXOSBGP reports one session per neighbor and VRF, with the prefixes
+// received in every address family added up
+func parseNXOSBGP(output []byte, state *OperationalState) error {
+	var summary nxosBGPSummary
+	if err := json.Unmarshal(output, &summary); err != nil {
+		return err
+	}
+	for _, vrf := range summary.TABLEVrf.ROWVrf {
+		localAS, err := parseASN(vrf.VrfLocalAS)
+		if err != nil {
+			return fmt.Errorf("vrf %s: %w", vrf.VrfNameOut, err)
+		}
+		sessions := map[string]int{}
+		for _, af := range vrf.TABLEAf.ROWAf {
+			for _, saf := range af.TABLESaf.ROWSaf {
+				for _, row := range saf.TABLENeighbor.ROWNeighbor {
+					prefixes, err := ParseCounter(row.PrefixReceived)
+					if err != nil {
+						return fmt.Errorf("neighbor %s: %w", row.NeighborID, err)
+					}
+					if i, ok := sessions[row.NeighborID]; ok {
+						state.BGPSessions[i].PrefixesReceived += prefixes
+						continue
+					}
+
+					session := BGPSession{
+						Neighbor:         row.NeighborID,
+						LocalAS:          localAS,
+						State:            strings.ToLower(row.State),
+						PrefixesReceived: prefixes,
+						VRF:              vrf.VrfNameOut,
+					}
+					if session.RemoteAS, err = parseASN(row.NeighborAS); err != nil {
+						return fmt.Errorf("neighbor %s: %w", row.NeighborID, err)
+					}
+					if session.Uptime, err = ParseTimer(row.Time); err != nil {
+						return fmt.Errorf("neighbor %s: %w", row.NeighborID, err)
+					}
+					sessions[row.NeighborID] = len(state.BGPSessions)
+					state.BGPSessions = append(state.BGPSessions, session)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parseASN accepts plain and asdot AS numbers such as 65001 or 1.10
+func parseASN(s string) (uint32, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if high, low, ok := strings.Cut(s, "."); ok {
+		h, err1 := strconv.ParseUint(high, 10, 16)
+		l, err2 := strconv.ParseUint(low, 10, 16)
+		if err1 != nil || err2 != nil {
+			return 0, fmt.Errorf("invalid AS number %q", s)
+		}
+		return uint32(h<<16 | l), nil
+	}
+	asn, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid AS number %q", s)
+	}
+	return uint32(asn), nil
+}
+
+type nxosARP struct {
+	TABLEVrf struct {
+		ROWVrf Rows[struct {
+			VrfNameOut string `json:"vrf-name-out"`
+			TABLEAdj   struct {
+				ROWAdj Rows[struct {
+					IntfOut   string `json:"intf-out"`
+					IPAddrOut string `json:"ip-addr-out"`
+					TimeStamp string `json:"time-stamp"`
+					MAC       string `json:"mac"`
+				}] `json:"ROW_adj"`
+			} `json:"TABLE_adj"`
+		}] `json:"ROW_vrf"`
+	} `json:"TABLE_vrf"`
+}
+
+// parseNXOSARP reports incomplete entries with an empty MAC address
+func parseNXOSARP(output []byte, state *OperationalState) error {
+	var arp nxosARP
+	if err := json.Unmarshal(output, &arp); err != nil {
+		return err
+	}
+	for _, vrf := range arp.TABLEVrf.ROWVrf {
+		for _, row := range vrf.TABLEAdj.ROWAdj {
+			entry := ARPEntry{IP: row.IPAddrOut, Interface: row.IntfOut, VRF: vrf.VrfNameOut}
+			var err error
+			if !strings.EqualFold(row.MAC, "incomplete") {
+				if entry.MAC, err = NormalizeMAC(row.MAC); err != nil {
+					return fmt.Errorf("arp %s: %w", row.IPAddrOut, err)
+				}
+			}
+			if entry.Age, err = ParseTimer(row.TimeStamp); err != nil {
+				return fmt.Errorf("arp %s: %w", row.IPAddrOut, err)
+			}
+			state.ARP = append(state.ARP, entry)
+		}
+	}
+	return nil
+}
+
+type nxosMACTable struct {
+	TABLEMacAddress struct {
+		ROWMacAddress Rows[struct {
+			DispMacAddr  string `json:"disp_mac_addr"`
+			DispVlan     string `json:"disp_vlan"`
+			DispIsStatic string `json:"disp_is_static"`
+			DispPort     string `json:"disp_port"`
+		}] `json:"ROW_mac_address"`
+	} `json:"TABLE_mac_address"`
+}
+
+func parseNXOSMACTable(output []byte, state *OperationalState) error {
+	var table nxosMACTable
+	if err := json.Unmarshal(output, &table); err != nil {
+		return err
+	}
+	for _, row := range table.TABLEMacAddress.ROWMacAddress {
+		mac, err := NormalizeMAC(row.DispMacAddr)
+		if err != nil {
+			return err
+		}
+		state.MACTable = append(state.MACTable, MACEntry{
+			MAC:       mac,
+			VLAN:      row.DispVlan,
+			Interface: row.DispPort,
+			Static:    row.DispIsStatic == "enabled",
+		})
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package extraction
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNXOSParsers(t *testing.T) {
+	tests := []struct {
+		command string
+		file    string
+		want    *OperationalState
+	}{
+		{
+			command: "show interface | json",
+			file:    "interfaces.json",
+			want: &OperationalState{Interfaces: []InterfaceState{
+				{Name: "Ethernet1/1", Description: "uplink-core1", AdminStatus: LinkUp, OperStatus: LinkUp, MTU: 9216,
+					Bandwidth: 100000000000, LinkFlapped: 51 * time.Hour},
+				{Name: "Ethernet1/2", AdminStatus: LinkDown, OperStatus: LinkDown, MTU: 1500, Bandwidth: 10000000000},
+				{Name: "Vlan10", Description: "servers", AdminStatus: LinkUp, OperStatus: LinkUp, MTU: 9216, Bandwidth: 1000000000},
+				{Name: "mgmt0", Description: "OOB", AdminStatus: LinkUp, OperStatus: LinkUp, MTU: 1500,
+					Bandwidth: 1000000000, LinkFlapped: 12*time.Minute + 34*time.Second},
+				{Name: "nve1", Description: "vxlan", AdminStatus: LinkUp, OperStatus: LinkUp},
+			}},
+		},
+		{
+			command: "show isis adjacency",
+			file:    "isis_multi.json",
+			want: &OperationalState{Adjacencies: []RoutingAdjacency{
+				{Protocol: "isis", Hostname: "sw1", Interface: "Ethernet1/49", State: "up", HoldTime: 24 * time.Second,
+					VRF: "default", Instance: "internal"},
+				{Protocol: "isis", Hostname: "sw2", Interface: "Ethernet1/51", State: "up", HoldTime: 26 * time.Second,
+					VRF: "default", Instance: "internal"},
+				{Protocol: "isis", Hostname: "oob1", Interface: "mgmt0", State: "init", HoldTime: 29 * time.Second,
+					VRF: "mgmt", Instance: "internal"},
+				{Protocol: "isis", Hostname: "pe1", Interface: "Ethernet1/1.100", State: "up", HoldTime: 8 * time.Second,
+					VRF: "customer-a", Instance: "external"},
+			}},
+		},
+		{
+			command: "show lldp neighbors detail",
+			file:    filepath.Join("nxos", "show_lldp_neighbors_detail.json"),
+			want: &OperationalState{LLDPNeighbors: []LLDPNeighbor{
+				{LocalInterface: "Ethernet1/49", ChassisID: "52:54:00:12:34:01", SystemName: "spine1", PortID: "Ethernet1/1",
+					PortDescription: "to-leaf1"},
+				{LocalInterface: "mgmt0", ChassisID: "pe1-chassis", SystemName: "pe1", PortID: "ge-0/0/3"},
+			}},
+		},
+		{
+			command: "show ip bgp summary vrf all",
+			file:    filepath.Join("nxos", "show_ip_bgp_summary_vrf_all.json"),
+			want: &OperationalState{BGPSessions: []BGPSession{
+				{Neighbor: "10.0.0.2", RemoteAS: 65002, LocalAS: 65001, State: "established", Uptime: 9 * 24 * time.Hour,
+					PrefixesReceived: 15, VRF: "default"},
+				{Neighbor: "10.0.0.6", RemoteAS: 65003, LocalAS: 65001, State: "idle", Uptime: 4*time.Minute + 10*time.Second,
+					VRF: "default"},
+				{Neighbor: "192.0.2.1", RemoteAS: 64512, LocalAS: 65546, State: "established", Uptime: 51 * time.Hour,
+					PrefixesReceived: 1, VRF: "customer-a"},
+			}},
+		},
+		{
+			command: "show ip arp",
+			file:    filepath.Join("nxos", "show_ip_arp.json"),
+			want: &OperationalState{ARP: []ARPEntry{
+				{IP: "10.0.0.2", MAC: "52:54:00:12:34:01", Interface: "Ethernet1/49", Age: 5*time.Minute + 12*time.Second,
+					VRF: "default"},
+				{IP: "192.168.10.20", MAC: "00:50:56:a1:b2:c3", Interface: "Vlan10", Age: 41 * time.Second, VRF: "default"},
+				{IP: "192.168.10.21", Interface: "Vlan10", Age: 3 * time.Second, VRF: "default"},
+			}},
+		},
+		{
+			command: "show mac address-table",
+			file:    filepath.Join("nxos", "show_mac_address-table.json"),
+			want: &OperationalState{MACTable: []MACEntry{
+				{MAC: "00:50:56:a1:b2:c3", VLAN: "10", Interface: "Ethernet1/3"},
+				{MAC: "52:54:00:12:00:ff", VLAN: "-", Interface: "sup-eth1(R)", Static: true},
+			}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.command, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tc.file))
+			if err != nil {
+				t.Fatalf("failed to read test data: %v", err)
+			}
+			got := &OperationalState{}
+			if err := got.Parse(VendorNXOS, tc.command, data); err != nil {
+				t.Fatalf("Parse got error: %v, want nil", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Parse returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package extraction
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Rows holds the rows of an NX-OS TABLE_* element. NX-OS encodes a table with
+// a single row as an object and a table with several rows as an array, Rows
+// accepts both.
+type Rows[T any] []T
+
+func (r *Rows[T]) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*r = nil
+		return nil
+	case len(data) > 0 && data[0] == '[':
+		var rows []T
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return err
+		}
+		*r = rows
+		return nil
+	default:
+		var row T
+		if err := json.Unmarshal(data, &row); err != nil {
+			return err
+		}
+		*r = Rows[T]{row}
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package extraction
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRows(t *testing.T) {
+	type row struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name string
+		js   string
+		want Rows[row]
+	}{
+		{
+			name: "Single row object",
+			js:   `{"name": "a"}`,
+			want: Rows[row]{{Name: "a"}},
+		},
+		{
+			name: "Row array",
+			js:   `[{"name": "a"}, {"name": "b"}]`,
+			want: Rows[row]{{Name: "a"}, {Name: "b"}},
+		},
+		{
+			name: "Empty table",
+			js:   `null`,
+			want: nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got Rows[row]
+			if err := json.Unmarshal([]byte(tc.js), &got); err != nil {
+				t.Fatalf("Unmarshal got error: %v, want nil", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Unmarshal returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	var got Rows[row]
+	if err := json.Unmarshal([]byte(`"a"`), &got); err == nil {
+		t.Errorf("Unmarshal of a string got nil error, want error")
+	}
+}
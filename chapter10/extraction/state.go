@@ -0,0 +1,203 @@
+package extraction
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Vendors with parsers registered by this package
+const (
+	VendorNXOS  = "nxos"
+	VendorJunos = "junos"
+)
+
+// OperationalState is the vendor neutral state of one device, assembled from
+// the output of several show commands
+type OperationalState struct {
+	Interfaces    []InterfaceState
+	LLDPNeighbors []LLDPNeighbor
+	Adjacencies   []RoutingAdjacency
+	BGPSessions   []BGPSession
+	ARP           []ARPEntry
+	MACTable      []MACEntry
+}
+
+type InterfaceState struct {
+	Name        string
+	Description string
+	AdminStatus LinkState
+	OperStatus  LinkState
+	MTU         int
+	// Bandwidth is in bits per second
+	Bandwidth   uint64
+	LinkFlapped time.Duration
+}
+
+type LLDPNeighbor struct {
+	LocalInterface  string
+	ChassisID       string
+	SystemName      string
+	PortID          string
+	PortDescription string
+}
+
+// RoutingAdjacency is an IGP neighbor such as an ISIS adjacency or an OSPF neighbor
+type RoutingAdjacency struct {
+	// Protocol is "isis" or "ospf"
+	Protocol string
+	// NeighborID is the ISIS system ID or the OSPF router ID
+	NeighborID string
+	Hostname   string
+	Address    string
+	Interface  string
+	// State is lower case, such as "up" for ISIS or "full" for OSPF
+	State    string
+	HoldTime time.Duration
+	VRF      string
+	Instance string
+}
+
+type BGPSession struct {
+	Neighbor string
+	RemoteAS uint32
+	LocalAS  uint32
+	// State is lower case, such as "established" or "idle"
+	State            string
+	Uptime           time.Duration
+	PrefixesReceived uint64
+	VRF              string
+}
+
+// Method Established reports whether the session is up
+func (s BGPSession) Established() bool {
+	return s.State == "established"
+}
+
+type ARPEntry struct {
+	IP string
+	// MAC is lower case and colon separated, as are all MAC addresses in the model
+	MAC       string
+	Interface string
+	Age       time.Duration
+	VRF       string
+}
+
+type MACEntry struct {
+	MAC string
+	// VLAN is the VLAN ID, or the VLAN name when the device only reports that
+	VLAN      string
+	Interface string
+	Static    bool
+}
+
+// Parser adds what it finds in the output of one command to state
+type Parser func(output []byte, state *OperationalState) error
+
+type parserKey struct {
+	vendor  string
+	command string
+}
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = map[parserKey]Parser{}
+)
+
+// RegisterParser registers the parser of a command, replacing any previous one
+func RegisterParser(vendor, command string, parser Parser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	parsers[parserKey{strings.ToLower(vendor), NormalizeCommand(command)}] = parser
+}
+
+// LookupParser returns the parser of a command
+func LookupParser(vendor, command string) (Parser, bool) {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	parser, ok := parsers[parserKey{strings.ToLower(vendor), NormalizeCommand(command)}]
+	return parser, ok
+}
+
+// RegisteredCommands returns the commands with a parser for a vendor, sorted
+func RegisteredCommands(vendor string) []string {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	var commands []string
+	for key := range parsers {
+		if key.vendor == strings.ToLower(vendor) {
+			commands = append(commands, key.command)
+		}
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// formatPipes are the output format pipes removed by NormalizeCommand
+var formatPipes = []string{"json", "json-pretty", "json native", "display json"}
+
+// NormalizeCommand lower cases a command, collapses white space and removes
+// the output format pipe, so "show interface | json" and "show  interface"
+// find the same parser. Other pipes, such as "| include up", are kept since
+// they filter the output a parser expects
+func NormalizeCommand(command string) string {
+	command = strings.Join(strings.Fields(strings.ToLower(command)), " ")
+	if i := strings.LastIndex(command, "|"); i >= 0 {
+		pipe := strings.Join(strings.Fields(command[i+1:]), " ")
+		for _, format := range formatPipes {
+			if pipe == format {
+				return strings.TrimSpace(command[:i])
+			}
+		}
+	}
+	return command
+}
+
+// Method Parse adds the output of a command to the state using the registered parser
+func (s *OperationalState) Parse(vendor, command string, output []byte) error {
+	normalized := NormalizeCommand(command)
+	if strings.Contains(normalized, "|") {
+		return fmt.Errorf("output filter in %q is not supported, parsers need the full output", command)
+	}
+	parser, ok := LookupParser(vendor, command)
+	if !ok {
+		return fmt.Errorf("no %s parser for %q", vendor, command)
+	}
+	if err := parser(output, s); err != nil {
+		return fmt.Errorf("%s %q: %w", vendor, normalized, err)
+	}
+	return nil
+}
+
+// ParseState builds the state of a device from the output of each command
+func ParseState(vendor string, outputs map[string][]byte) (*OperationalState, error) {
+	commands := make([]string, 0, len(outputs))
+	for command := range outputs {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	state := &OperationalState{}
+	for _, command := range commands {
+		if err := state.Parse(vendor, command, outputs[command]); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// NormalizeMAC converts the dotted Cisco and the colon or dash separated forms
+// of a MAC address to lower case colon separated form
+func NormalizeMAC(mac string) (string, error) {
+	hex := strings.NewReplacer(".", "", ":", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(mac)))
+	if len(hex) != 12 || strings.Trim(hex, "0123456789abcdef") != "" {
+		return "", fmt.Errorf("invalid MAC address %q", mac)
+	}
+	pairs := make([]string, 6)
+	for i := range pairs {
+		pairs[i] = hex[2*i : 2*i+2]
+	}
+	return strings.Join(pairs, ":"), nil
+}
@@ -0,0 +1,115 @@
+package extraction
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeCommand(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "show interface", want: "show interface"},
+		{in: "  Show   Interface | json", want: "show interface"},
+		{in: "show bgp summary|display json", want: "show bgp summary"},
+		{in: "show interface | JSON-PRETTY", want: "show interface"},
+		{in: "show interface | json native", want: "show interface"},
+		{in: "show interface | include Ethernet", want: "show interface | include ethernet"},
+		{in: "show ip arp | exclude Vlan | json", want: "show ip arp | exclude vlan"},
+	}
+	for _, tc := range tests {
+		if got := NormalizeCommand(tc.in); got != tc.want {
+			t.Errorf("NormalizeCommand(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeMAC(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "5254.0012.3401", want: "52:54:00:12:34:01"},
+		{in: "2C:6B:F5:38:46:C0", want: "2c:6b:f5:38:46:c0"},
+		{in: "00-50-56-a1-b2-c3", want: "00:50:56:a1:b2:c3"},
+		{in: "5254.0012", wantErr: true},
+		{in: "zzzz.0012.3401", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := NormalizeMAC(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("NormalizeMAC(%q) got error: %v, want error %v", tc.in, err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Errorf("NormalizeMAC(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParserRegistry(t *testing.T) {
+	if _, ok := LookupParser("NXOS", "show interface | json"); !ok {
+		t.Errorf("LookupParser found no NX-OS interface parser")
+	}
+	if _, ok := LookupParser(VendorJunos, "show interface"); ok {
+		t.Errorf("LookupParser found a Junos parser for the NX-OS command")
+	}
+
+	var got []byte
+	RegisterParser("eos", "show version", func(output []byte, state *OperationalState) error {
+		got = output
+		return nil
+	})
+	defer func() {
+		parsersMu.Lock()
+		delete(parsers, parserKey{"eos", "show version"})
+		parsersMu.Unlock()
+	}()
+	if err := (&OperationalState{}).Parse("eos", "show version | json", []byte("{}")); err != nil || string(got) != "{}" {
+		t.Errorf("Parse with a registered parser got error: %v, output %q", err, got)
+	}
+	if diff := cmp.Diff([]string{"show version"}, RegisteredCommands("eos")); diff != "" {
+		t.Errorf("RegisteredCommands returned diff (-want +got):\n%s", diff)
+	}
+
+	err := (&OperationalState{}).Parse(VendorNXOS, "show running-config", nil)
+	if err == nil || !strings.Contains(err.Error(), `no nxos parser for "show running-config"`) {
+		t.Errorf("Parse of an unknown command got error: %v", err)
+	}
+	err = (&OperationalState{}).Parse(VendorNXOS, "show interface | include Ethernet | json", []byte("{}"))
+	if err == nil || !strings.Contains(err.Error(), "output filter") {
+		t.Errorf("Parse of filtered output got error: %v", err)
+	}
+	err = (&OperationalState{}).Parse(VendorNXOS, "show interface", []byte(`{"TABLE_interface": []`))
+	if err == nil || !strings.HasPrefix(err.Error(), `nxos "show interface": `) {
+		t.Errorf("Parse of malformed output got error: %v", err)
+	}
+}
+
+func TestParseState(t *testing.T) {
+	outputs := map[string][]byte{}
+	for command, file := range map[string]string{
+		"show interface":      "interface.json",
+		"show isis adjacency": "isis.json",
+		"show ip arp":         filepath.Join("nxos", "show_ip_arp.json"),
+	} {
+		data, err := os.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatalf("failed to read test data: %v", err)
+		}
+		outputs[command] = data
+	}
+
+	state, err := ParseState(VendorNXOS, outputs)
+	if err != nil {
+		t.Fatalf("ParseState got error: %v, want nil", err)
+	}
+	if len(state.Interfaces) != 1 || len(state.Adjacencies) != 2 || len(state.ARP) != 3 {
+		t.Errorf("ParseState got %d interfaces, %d adjacencies and %d ARP entries, want 1, 2 and 3",
+			len(state.Interfaces), len(state.Adjacencies), len(state.ARP))
+	}
+}
@@ -0,0 +1,71 @@
+package textfsm
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed templates/*.textfsm
+var templateFS embed.FS
+
+var (
+	builtinMu sync.Mutex
+	builtin   = map[string]*Template{}
+)
+
+// templateName maps a platform and command to the bundled file name,
+// "cisco_ios" and "show ip interface brief" to cisco_ios_show_ip_interface_brief.textfsm
+func templateName(platform, command string) string {
+	command, _, _ = strings.Cut(command, "|")
+	words := append([]string{strings.ToLower(platform)}, strings.Fields(strings.ToLower(command))...)
+	return strings.Join(words, "_") + ".textfsm"
+}
+
+// Builtin returns the bundled template for a command, compiled once
+func Builtin(platform, command string) (*Template, error) {
+	name := templateName(platform, command)
+
+	builtinMu.Lock()
+	defer builtinMu.Unlock()
+	if t, ok := builtin[name]; ok {
+		return t, nil
+	}
+
+	f, err := templateFS.Open("templates/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("no %s template for %q", platform, command)
+	}
+	defer f.Close()
+	t, err := ParseTemplate(f)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+	builtin[name] = t
+	return t, nil
+}
+
+// BuiltinCommands returns the commands with a bundled template for a platform
+func BuiltinCommands(platform string) []string {
+	entries, _ := templateFS.ReadDir("templates")
+	prefix := strings.ToLower(platform) + "_"
+	var commands []string
+	for _, entry := range entries {
+		if name, ok := strings.CutPrefix(entry.Name(), prefix); ok {
+			commands = append(commands, strings.ReplaceAll(strings.TrimSuffix(name, ".textfsm"), "_", " "))
+		}
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// ParseCommand parses the output of a command with its bundled template
+func ParseCommand(platform, command, output string) ([]map[string]string, error) {
+	t, err := Builtin(platform, command)
+	if err != nil {
+		return nil, err
+	}
+	return t.ParseText(output)
+}
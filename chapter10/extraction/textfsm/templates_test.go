@@ -0,0 +1,107 @@
+package textfsm
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinTemplates(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []map[string]string
+	}{
+		{
+			command: "show ip interface brief",
+			want: []map[string]string{
+				{"INTERFACE": "GigabitEthernet0/0", "IP_ADDRESS": "10.0.0.1", "STATUS": "up", "PROTOCOL": "up"},
+				{"INTERFACE": "GigabitEthernet0/1", "IP_ADDRESS": "unassigned", "STATUS": "administratively down", "PROTOCOL": "down"},
+				{"INTERFACE": "GigabitEthernet0/2", "IP_ADDRESS": "10.0.1.1", "STATUS": "up", "PROTOCOL": "down"},
+				{"INTERFACE": "Loopback0", "IP_ADDRESS": "192.168.0.1", "STATUS": "up", "PROTOCOL": "up"},
+			},
+		},
+		{
+			command: "show version",
+			want: []map[string]string{
+				{
+					"VERSION":         "15.9(3)M4",
+					"ROMMON":          "IOSv",
+					"HOSTNAME":        "r1",
+					"UPTIME":          "2 weeks, 3 days, 4 hours, 5 minutes",
+					"RELOAD_REASON":   "reload",
+					"RUNNING_IMAGE":   "/vios-adventerprisek9-m",
+					"HARDWARE":        "IOSv",
+					"SERIAL":          "9RWKXGPVOY6JY4YV4MS6P",
+					"CONFIG_REGISTER": "0x0",
+				},
+			},
+		},
+		{
+			command: "show cdp neighbors detail",
+			want: []map[string]string{
+				{
+					"DESTINATION_HOST": "r2.example.com",
+					"MANAGEMENT_IP":    "10.0.0.2",
+					"PLATFORM":         "Cisco",
+					"CAPABILITIES":     "Router Source-Route-Bridge",
+					"LOCAL_PORT":       "GigabitEthernet0/0",
+					"REMOTE_PORT":      "GigabitEthernet0/1",
+					"SOFTWARE_VERSION": "Cisco IOS Software, IOSv Software (VIOS-ADVENTERPRISEK9-M), Version 15.9(3)M4, RELEASE SOFTWARE (fc3)",
+				},
+				{
+					"DESTINATION_HOST": "sw1",
+					"MANAGEMENT_IP":    "10.0.2.11",
+					"PLATFORM":         "cisco WS-C3850-24T",
+					"CAPABILITIES":     "Switch IGMP",
+					"LOCAL_PORT":       "GigabitEthernet0/2",
+					"REMOTE_PORT":      "GigabitEthernet1/0/24",
+					"SOFTWARE_VERSION": "Cisco IOS Software [Gibraltar], Catalyst L3 Switch Software (CAT3K_CAA-UNIVERSALK9-M), Version 16.12.4, RELEASE SOFTWARE (fc5)",
+				},
+			},
+		},
+		{
+			command: "show ip bgp summary",
+			want: []map[string]string{
+				{"ROUTER_ID": "192.168.0.1", "LOCAL_AS": "65001", "BGP_NEIGH": "10.0.0.2", "NEIGH_AS": "65002",
+					"UP_DOWN": "21:37:02", "STATE_PFXRCD": "4"},
+				{"ROUTER_ID": "192.168.0.1", "LOCAL_AS": "65001", "BGP_NEIGH": "10.0.0.6", "NEIGH_AS": "65003",
+					"UP_DOWN": "never", "STATE_PFXRCD": "Idle"},
+				{"ROUTER_ID": "192.168.0.1", "LOCAL_AS": "65001", "BGP_NEIGH": "10.0.0.10", "NEIGH_AS": "65004",
+					"UP_DOWN": "00:01:12", "STATE_PFXRCD": "Idle (Admin)"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.command, func(t *testing.T) {
+			file := "cisco_ios_" + strings.ReplaceAll(tc.command, " ", "_") + ".txt"
+			data, err := os.ReadFile(filepath.Join("testdata", file))
+			if err != nil {
+				t.Fatalf("failed to read test data: %v", err)
+			}
+			got, err := ParseCommand("cisco_ios", tc.command+" | include .", string(data))
+			if err != nil {
+				t.Fatalf("ParseCommand got error: %v, want nil", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ParseCommand returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBuiltinCommands(t *testing.T) {
+	want := []string{"show cdp neighbors detail", "show ip bgp summary", "show ip interface brief", "show version"}
+	if diff := cmp.Diff(want, BuiltinCommands("cisco_ios")); diff != "" {
+		t.Errorf("BuiltinCommands returned diff (-want +got):\n%s", diff)
+	}
+	for _, command := range want {
+		if _, err := Builtin("cisco_ios", command); err != nil {
+			t.Errorf("Builtin(%q) got error: %v", command, err)
+		}
+	}
+	if _, err := Builtin("cisco_ios", "show running-config"); err == nil {
+		t.Errorf("Builtin of a command without template got nil error")
+	}
+}
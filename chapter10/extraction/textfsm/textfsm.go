@@ -0,0 +1,382 @@
+// Package textfsm parses unstructured CLI output with templates in the format
+// of Google TextFSM: Value definitions followed by states of regex rules.
+package textfsm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Value is a column of the parsed table
+type Value struct {
+	Name  string
+	Regex string
+	// Filldown keeps the value for the following records until it matches again
+	Filldown bool
+	// Fillup copies the value back into earlier records where it is empty
+	Fillup bool
+	// Required drops records where the value is empty
+	Required bool
+	// Key marks the value as identifying a record, it does not change parsing
+	Key bool
+}
+
+type lineAction int
+
+const (
+	actionNext lineAction = iota
+	actionContinue
+	actionError
+)
+
+type recordAction int
+
+const (
+	recordNone recordAction = iota
+	recordRecord
+	recordClear
+	recordClearAll
+)
+
+type rule struct {
+	regex     *regexp.Regexp
+	line      lineAction
+	record    recordAction
+	nextState string
+	message   string
+	lineNo    int
+}
+
+// Template is a compiled template, safe for concurrent use
+type Template struct {
+	Values []Value
+	states map[string][]rule
+}
+
+var (
+	valueName       = regexp.MustCompile(`^\w+$`)
+	valueDefinition = regexp.MustCompile(`^Value\s+(?:(\S+)\s+)?(\w+)\s+(\(.*\))\s*$`)
+	valueRef        = regexp.MustCompile(`\$\{(\w+)\}|\$(\w+)`)
+	actionRegex     = regexp.MustCompile(`^\s+\^(.*?)(?:\s+->\s*(.*))?$`)
+)
+
+// ParseTemplate reads and compiles a template
+func ParseTemplate(r io.Reader) (*Template, error) {
+	t := &Template{states: map[string][]rule{}}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	// Value definitions come first and end at the first blank line
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		value, err := parseValue(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		for _, v := range t.Values {
+			if v.Name == value.Name {
+				return nil, fmt.Errorf("line %d: duplicate value %s", lineNo, value.Name)
+			}
+		}
+		t.Values = append(t.Values, value)
+	}
+	if len(t.Values) == 0 {
+		return nil, fmt.Errorf("template defines no values")
+	}
+
+	state := ""
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case line[0] != ' ' && line[0] != '\t':
+			if !valueName.MatchString(trimmed) {
+				return nil, fmt.Errorf("line %d: invalid state name %q", lineNo, trimmed)
+			}
+			if _, ok := t.states[trimmed]; ok {
+				return nil, fmt.Errorf("line %d: duplicate state %s", lineNo, trimmed)
+			}
+			state = trimmed
+			t.states[state] = nil
+		case state == "":
+			return nil, fmt.Errorf("line %d: rule outside of a state", lineNo)
+		default:
+			r, err := t.parseRule(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			r.lineNo = lineNo
+			t.states[state] = append(t.states[state], r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, ok := t.states["Start"]; !ok {
+		return nil, fmt.Errorf("template has no Start state")
+	}
+	// End and EOF are only markers, like TextFSM their rules would never run
+	for _, name := range []string{"End", "EOF"} {
+		if rules := t.states[name]; len(rules) > 0 {
+			return nil, fmt.Errorf("line %d: state %s must be empty", rules[0].lineNo, name)
+		}
+	}
+	for name, rules := range t.states {
+		for _, r := range rules {
+			if r.nextState == "" || r.nextState == "End" || r.nextState == "EOF" {
+				continue
+			}
+			if _, ok := t.states[r.nextState]; !ok {
+				return nil, fmt.Errorf("line %d: state %s moves to undefined state %s", r.lineNo, name, r.nextState)
+			}
+		}
+	}
+	return t, nil
+}
+
+// MustParseTemplate is like ParseTemplate but panics on error, for templates built into programs
+func MustParseTemplate(text string) *Template {
+	t, err := ParseTemplate(strings.NewReader(text))
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// parseValue parses "Value [Option[,Option...]] Name (regex)"
+func parseValue(line string) (Value, error) {
+	m := valueDefinition.FindStringSubmatch(line)
+	if m == nil {
+		return Value{}, fmt.Errorf("invalid value definition %q, expected Value [Options] Name (regex)", line)
+	}
+
+	value := Value{Name: m[2], Regex: m[3]}
+	if m[1] != "" {
+		for _, option := range strings.Split(m[1], ",") {
+			switch option {
+			case "Filldown":
+				value.Filldown = true
+			case "Fillup":
+				value.Fillup = true
+			case "Required":
+				value.Required = true
+			case "Key":
+				value.Key = true
+			case "List":
+				return Value{}, fmt.Errorf("option List is not supported, values are strings")
+			default:
+				return Value{}, fmt.Errorf("unknown option %s", option)
+			}
+		}
+	}
+	if _, err := regexp.Compile(value.Regex); err != nil {
+		return Value{}, fmt.Errorf("value %s: %v", value.Name, err)
+	}
+	return value, nil
+}
+
+// parseRule parses "  ^regex [-> [LineAction][.RecordAction] [NewState]]"
+func (t *Template) parseRule(line string) (rule, error) {
+	m := actionRegex.FindStringSubmatch(line)
+	if m == nil {
+		return rule{}, fmt.Errorf("rule must start with ^: %q", strings.TrimSpace(line))
+	}
+
+	var unknown []string
+	pattern := valueRef.ReplaceAllStringFunc(m[1], func(ref string) string {
+		name := strings.Trim(ref, "${}")
+		for _, v := range t.Values {
+			if v.Name == name {
+				return "(?P<" + name + ">" + v.Regex + ")"
+			}
+		}
+		unknown = append(unknown, name)
+		return ref
+	})
+	// "$$" anchors the end of the line, the "$$" left after substitution is "$"
+	pattern = strings.ReplaceAll(pattern, "$$", "$")
+	if len(unknown) > 0 {
+		return rule{}, fmt.Errorf("undefined value %s", strings.Join(unknown, ", "))
+	}
+
+	regex, err := regexp.Compile("^" + pattern)
+	if err != nil {
+		return rule{}, err
+	}
+	r := rule{regex: regex}
+
+	fields := strings.Fields(m[2])
+	if len(fields) == 0 {
+		return r, nil
+	}
+
+	action := fields[0]
+	if strings.HasPrefix(action, "Error") {
+		r.line = actionError
+		r.message = strings.Trim(strings.TrimSpace(strings.TrimPrefix(m[2], "Error")), `"`)
+		return r, nil
+	}
+
+	lineAction, recordAction, dotted := strings.Cut(action, ".")
+	if !dotted {
+		switch action {
+		case "Next", "Continue":
+			lineAction, recordAction = action, ""
+		case "Record", "NoRecord", "Clear", "Clearall":
+			lineAction, recordAction = "", action
+		default:
+			lineAction, recordAction = "", ""
+			fields = append([]string{""}, fields...)
+		}
+	}
+	switch lineAction {
+	case "", "Next":
+	case "Continue":
+		r.line = actionContinue
+	default:
+		return rule{}, fmt.Errorf("unknown line action %s", lineAction)
+	}
+	switch recordAction {
+	case "", "NoRecord":
+	case "Record":
+		r.record = recordRecord
+	case "Clear":
+		r.record = recordClear
+	case "Clearall":
+		r.record = recordClearAll
+	default:
+		return rule{}, fmt.Errorf("unknown record action %s", recordAction)
+	}
+
+	switch len(fields) {
+	case 1:
+	case 2:
+		r.nextState = fields[1]
+		if r.line == actionContinue {
+			return rule{}, fmt.Errorf("Continue cannot change state")
+		}
+	default:
+		return rule{}, fmt.Errorf("invalid action %q", m[2])
+	}
+	return r, nil
+}
+
+// parser holds the state of one ParseText call
+type parser struct {
+	t       *Template
+	current map[string]string
+	records []map[string]string
+}
+
+// ParseText runs the template over the text and returns one map per record
+func (t *Template) ParseText(text string) ([]map[string]string, error) {
+	p := &parser{t: t, current: map[string]string{}}
+	state := "Start"
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+lines:
+	for lineNo, line := range lines {
+		for _, r := range t.states[state] {
+			m := r.regex.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			for i, name := range r.regex.SubexpNames() {
+				if name != "" {
+					p.assign(name, m[i])
+				}
+			}
+
+			switch r.line {
+			case actionError:
+				if r.message != "" {
+					return nil, fmt.Errorf("line %d: %s: %q", lineNo+1, r.message, line)
+				}
+				return nil, fmt.Errorf("line %d: error rule matched: %q", lineNo+1, line)
+			}
+
+			switch r.record {
+			case recordRecord:
+				p.record()
+			case recordClear:
+				p.clear(false)
+			case recordClearAll:
+				p.clear(true)
+			}
+
+			if r.line == actionContinue {
+				continue
+			}
+			if r.nextState != "" {
+				state = r.nextState
+			}
+			break
+		}
+		if state == "End" || state == "EOF" {
+			break lines
+		}
+	}
+
+	// An explicit EOF state replaces the implicit record at the end of the input
+	if _, ok := t.states["EOF"]; !ok && state != "End" {
+		p.record()
+	}
+	return p.records, nil
+}
+
+func (p *parser) assign(name, value string) {
+	p.current[name] = value
+	for _, v := range p.t.Values {
+		if v.Name != name || !v.Fillup {
+			continue
+		}
+		for i := len(p.records) - 1; i >= 0 && p.records[i][name] == ""; i-- {
+			p.records[i][name] = value
+		}
+	}
+}
+
+// record saves the current values unless a required value is missing or only
+// filldown values are set, then clears the values that do not fill down
+func (p *parser) record() {
+	save := false
+	for _, v := range p.t.Values {
+		if v.Required && p.current[v.Name] == "" {
+			save = false
+			break
+		}
+		if !v.Filldown && p.current[v.Name] != "" {
+			save = true
+		}
+	}
+	if save {
+		record := make(map[string]string, len(p.t.Values))
+		for _, v := range p.t.Values {
+			record[v.Name] = p.current[v.Name]
+		}
+		p.records = append(p.records, record)
+	}
+	p.clear(false)
+}
+
+func (p *parser) clear(all bool) {
+	for _, v := range p.t.Values {
+		if all || !v.Filldown {
+			delete(p.current, v.Name)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package textfsm
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"strings"
+	"testing"
+)
+
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		text     string
+		want     []map[string]string
+	}{
+		{
+			name: "Record per line with implicit record at EOF",
+			template: `Value NAME (\S+)
+Value ADDR (\S+)
+
+Start
+  ^host ${NAME}
+  ^addr ${ADDR} -> Record
+`,
+			text: "host a\naddr 1\nhost b\n",
+			want: []map[string]string{
+				{"NAME": "a", "ADDR": "1"},
+				{"NAME": "b", "ADDR": ""},
+			},
+		},
+		{
+			name: "Filldown keeps the value and alone does not make a record",
+			template: `Value Filldown VRF (\S+)
+Value ROUTE (\S+)
+
+Start
+  ^VRF ${VRF}
+  ^  ${ROUTE} -> Record
+`,
+			text: "VRF red\n  10.0.0.0/8\n  10.1.0.0/16\nVRF blue\n  10.2.0.0/16\n",
+			want: []map[string]string{
+				{"VRF": "red", "ROUTE": "10.0.0.0/8"},
+				{"VRF": "red", "ROUTE": "10.1.0.0/16"},
+				{"VRF": "blue", "ROUTE": "10.2.0.0/16"},
+			},
+		},
+		{
+			name: "Fillup copies the value into earlier records",
+			template: `Value PORT (\S+)
+Value Fillup VLAN (\d+)
+
+Start
+  ^port ${PORT} -> Record
+  ^vlan ${VLAN} -> Record
+`,
+			text: "port 1\nport 2\nvlan 10\nport 3\n",
+			want: []map[string]string{
+				{"PORT": "1", "VLAN": "10"},
+				{"PORT": "2", "VLAN": "10"},
+				{"PORT": "", "VLAN": "10"},
+				{"PORT": "3", "VLAN": ""},
+			},
+		},
+		{
+			name: "Required drops incomplete records",
+			template: `Value Required NAME (\S+)
+Value MTU (\d+)
+
+Start
+  ^name ${NAME}
+  ^mtu ${MTU} -> Record
+`,
+			text: "name a\nmtu 1500\nmtu 9000\n",
+			want: []map[string]string{
+				{"NAME": "a", "MTU": "1500"},
+			},
+		},
+		{
+			name: "Continue matches further rules on the same line",
+			template: `Value A (\d+)
+Value B (\d+)
+
+Start
+  ^${A} -> Continue
+  ^\d+ ${B} -> Record
+`,
+			text: "1 2\n3 4\n",
+			want: []map[string]string{
+				{"A": "1", "B": "2"},
+				{"A": "3", "B": "4"},
+			},
+		},
+		{
+			name: "Clear discards partial values",
+			template: `Value NAME (\S+)
+Value DESC (.+)
+
+Start
+  ^interface ${NAME}
+  ^ description ${DESC} -> Record
+  ^! -> Clear
+`,
+			text: "interface a\n!\ninterface b\n description uplink\n",
+			want: []map[string]string{
+				{"NAME": "b", "DESC": "uplink"},
+			},
+		},
+		{
+			name: "States and End",
+			template: `Value NAME (\S+)
+
+Start
+  ^--- -> Body
+
+Body
+  ^=== -> End
+  ^${NAME}$$ -> Record
+`,
+			text: "ignored\n---\nx\ny\n===\nz\n",
+			want: []map[string]string{
+				{"NAME": "x"},
+				{"NAME": "y"},
+			},
+		},
+		{
+			name:     "Explicit EOF state disables the implicit record",
+			template: "Value NAME (\\S+)\n\nStart\n  ^${NAME}\n\nEOF\n",
+			text:     "x\n",
+			want:     nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := ParseTemplate(strings.NewReader(tc.template))
+			if err != nil {
+				t.Fatalf("ParseTemplate got error: %v, want nil", err)
+			}
+			got, err := tmpl.ParseText(tc.text)
+			if err != nil {
+				t.Fatalf("ParseText got error: %v, want nil", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ParseText returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseTextError(t *testing.T) {
+	tmpl := MustParseTemplate(`Value NAME (\S+)
+
+Start
+  ^ok ${NAME} -> Record
+  ^. -> Error "unexpected line"
+`)
+	_, err := tmpl.ParseText("ok a\nbad b\n")
+	if err == nil || err.Error() != `line 2: unexpected line: "bad b"` {
+		t.Errorf("ParseText got error: %v", err)
+	}
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name:     "No values",
+			template: "\nStart\n  ^x\n",
+			want:     "template defines no values",
+		},
+		{
+			name:     "Unknown option",
+			template: "Value Sometimes X (\\S+)\n\nStart\n  ^${X}\n",
+			want:     "line 1: unknown option Sometimes",
+		},
+		{
+			name:     "List option",
+			template: "Value List X (\\S+)\n\nStart\n  ^${X}\n",
+			want:     "line 1: option List is not supported, values are strings",
+		},
+		{
+			name:     "Regex without parentheses",
+			template: "Value X \\S+\n\nStart\n  ^${X}\n",
+			want:     "line 1: invalid value definition",
+		},
+		{
+			name:     "Missing Start",
+			template: "Value X (\\S+)\n\nBody\n  ^${X}\n",
+			want:     "template has no Start state",
+		},
+		{
+			name:     "Undefined value",
+			template: "Value X (\\S+)\n\nStart\n  ^${Y}\n",
+			want:     "line 4: undefined value Y",
+		},
+		{
+			name:     "Undefined state",
+			template: "Value X (\\S+)\n\nStart\n  ^${X} -> Record Body\n",
+			want:     "line 4: state Start moves to undefined state Body",
+		},
+		{
+			name:     "Rules in EOF",
+			template: "Value X (\\S+)\n\nStart\n  ^${X}\n\nEOF\n  ^.* -> Record\n",
+			want:     "line 7: state EOF must be empty",
+		},
+		{
+			name:     "Rules in End",
+			template: "Value X (\\S+)\n\nStart\n  ^${X} -> End\n\nEnd\n  ^.* -> Record\n",
+			want:     "line 7: state End must be empty",
+		},
+		{
+			name:     "Continue with state change",
+			template: "Value X (\\S+)\n\nStart\n  ^${X} -> Continue Start\n",
+			want:     "line 4: Continue cannot change state",
+		},
+		{
+			name:     "Rule without caret",
+			template: "Value X (\\S+)\n\nStart\n  ${X}\n",
+			want:     "line 4: rule must start with ^",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseTemplate(strings.NewReader(tc.template))
+			if err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+				t.Errorf("ParseTemplate got error: %v, want %s", err, tc.want)
+			}
+		})
+	}
+}
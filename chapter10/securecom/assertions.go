@@ -0,0 +1,332 @@
+package securecom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusRange is an inclusive range of accepted HTTP status codes
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// Method Contains reports whether the code falls within the range
+func (r StatusRange) Contains(code int) bool {
+	return code >= r.Min && code <= r.Max
+}
+
+func (r StatusRange) String() string {
+	if r.Min == r.Max {
+		return strconv.Itoa(r.Min)
+	}
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// ParseStatusRanges parses a list such as "200,204,300-399,4xx"
+func ParseStatusRanges(spec string) ([]StatusRange, error) {
+	var ranges []StatusRange
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+
+		var r StatusRange
+		var err error
+		switch {
+		case len(part) == 3 && strings.HasSuffix(part, "xx"):
+			var class int
+			class, err = strconv.Atoi(part[:1])
+			r = StatusRange{Min: class * 100, Max: class*100 + 99}
+		case strings.Contains(part, "-"):
+			low, high, _ := strings.Cut(part, "-")
+			r.Min, err = strconv.Atoi(low)
+			if err == nil {
+				r.Max, err = strconv.Atoi(high)
+			}
+		default:
+			r.Min, err = strconv.Atoi(part)
+			r.Max = r.Min
+		}
+
+		if err != nil || r.Min < 100 || r.Max > 599 || r.Min > r.Max {
+			return nil, fmt.Errorf("invalid status code range: %s", part)
+		}
+		ranges = append(ranges, r)
+	}
+
+	return ranges, nil
+}
+
+// JSONAssertion expects the value at a dotted path such as "data.items[0].state"
+type JSONAssertion struct {
+	Path  string
+	Value string
+}
+
+// Assertions describes what a response must satisfy for the check to pass
+// Empty fields are not asserted
+type Assertions struct {
+	StatusCodes     []StatusRange
+	BodyContains    []string
+	BodyRegex       []*regexp.Regexp
+	JSONPaths       []JSONAssertion
+	Headers         map[string]string // An empty value only requires the header to be present
+	MaxResponseTime time.Duration
+}
+
+// Method Evaluate checks the response against every assertion and returns one finding each
+func (a *Assertions) Evaluate(resp *http.Response, body []byte, responseTime time.Duration) []Finding {
+	var findings []Finding
+
+	if len(a.StatusCodes) > 0 {
+		findings = append(findings, checkStatusRanges(resp.StatusCode, a.StatusCodes))
+	}
+
+	for _, substr := range a.BodyContains {
+		if bytes.Contains(body, []byte(substr)) {
+			findings = append(findings, Finding{Name: "body-contains", Severity: SeverityOK,
+				Message: fmt.Sprintf("body contains %q", substr)})
+		} else {
+			findings = append(findings, Finding{Name: "body-contains", Severity: SeverityCritical,
+				Message: fmt.Sprintf("body does not contain %q", substr)})
+		}
+	}
+
+	for _, re := range a.BodyRegex {
+		if re.Match(body) {
+			findings = append(findings, Finding{Name: "body-regex", Severity: SeverityOK,
+				Message: fmt.Sprintf("body matches %s", re)})
+		} else {
+			findings = append(findings, Finding{Name: "body-regex", Severity: SeverityCritical,
+				Message: fmt.Sprintf("body does not match %s", re)})
+		}
+	}
+
+	if len(a.JSONPaths) > 0 {
+		findings = append(findings, checkJSONPaths(body, a.JSONPaths)...)
+	}
+
+	names := make([]string, 0, len(a.Headers))
+	for name := range a.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		findings = append(findings, checkHeader(resp.Header, name, a.Headers[name]))
+	}
+
+	if a.MaxResponseTime > 0 {
+		if responseTime > a.MaxResponseTime {
+			findings = append(findings, Finding{Name: "response-time", Severity: SeverityCritical,
+				Message: fmt.Sprintf("response took %v, limit is %v", responseTime, a.MaxResponseTime)})
+		} else {
+			findings = append(findings, Finding{Name: "response-time", Severity: SeverityOK,
+				Message: fmt.Sprintf("response took %v", responseTime)})
+		}
+	}
+
+	return findings
+}
+
+func checkStatusRanges(code int, ranges []StatusRange) Finding {
+	var accepted []string
+	for _, r := range ranges {
+		if r.Contains(code) {
+			return Finding{Name: "status", Severity: SeverityOK, Message: fmt.Sprintf("status %d", code)}
+		}
+		accepted = append(accepted, r.String())
+	}
+	return Finding{Name: "status", Severity: SeverityCritical,
+		Message: fmt.Sprintf("status %d, expected %s", code, strings.Join(accepted, ","))}
+}
+
+func checkHeader(header http.Header, name, expected string) Finding {
+	values, ok := header[http.CanonicalHeaderKey(name)]
+	switch {
+	case !ok:
+		return Finding{Name: "header", Severity: SeverityCritical, Message: fmt.Sprintf("missing header %s", name)}
+	case expected != "" && !containsString(values, expected):
+		return Finding{Name: "header", Severity: SeverityCritical,
+			Message: fmt.Sprintf("header %s is %q, expected %q", name, strings.Join(values, ", "), expected)}
+	default:
+		return Finding{Name: "header", Severity: SeverityOK, Message: fmt.Sprintf("header %s present", name)}
+	}
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+func checkJSONPaths(body []byte, assertions []JSONAssertion) []Finding {
+	var findings []Finding
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		for _, a := range assertions {
+			findings = append(findings, Finding{Name: "json-path", Severity: SeverityCritical,
+				Message: fmt.Sprintf("%s: body is not valid JSON: %v", a.Path, err)})
+		}
+		return findings
+	}
+
+	for _, a := range assertions {
+		value, err := lookupJSONPath(doc, a.Path)
+		if err != nil {
+			findings = append(findings, Finding{Name: "json-path", Severity: SeverityCritical,
+				Message: fmt.Sprintf("%s: %v", a.Path, err)})
+			continue
+		}
+
+		actual := jsonValueString(value)
+		if actual != a.Value {
+			findings = append(findings, Finding{Name: "json-path", Severity: SeverityCritical,
+				Message: fmt.Sprintf("%s is %s, expected %s", a.Path, actual, a.Value)})
+		} else {
+			findings = append(findings, Finding{Name: "json-path", Severity: SeverityOK,
+				Message: fmt.Sprintf("%s is %s", a.Path, actual)})
+		}
+	}
+
+	return findings
+}
+
+// lookupJSONPath walks a decoded document along a path like "$.data.items[0].name"
+func lookupJSONPath(doc interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	current := doc
+
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			continue
+		}
+
+		key := segment
+		var indexes []string
+		if i := strings.Index(segment, "["); i >= 0 {
+			key = segment[:i]
+			for _, idx := range strings.Split(segment[i+1:], "[") {
+				indexes = append(indexes, strings.TrimSuffix(idx, "]"))
+			}
+		}
+
+		if key != "" {
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is not an object", key)
+			}
+			if current, ok = object[key]; !ok {
+				return nil, fmt.Errorf("key %s not found", key)
+			}
+		}
+
+		for _, idx := range indexes {
+			n, err := strconv.Atoi(idx)
+			if err != nil {
+				return nil, fmt.Errorf("invalid index [%s]", idx)
+			}
+			array, ok := current.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is not an array", segment)
+			}
+			if n < 0 || n >= len(array) {
+				return nil, fmt.Errorf("index %d out of range", n)
+			}
+			current = array[n]
+		}
+	}
+
+	return current, nil
+}
+
+// jsonValueString renders a decoded value for comparison, strings without quotes
+func jsonValueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// ParseJSONAssertion parses "path=value"
+func ParseJSONAssertion(spec string) (JSONAssertion, error) {
+	path, value, found := strings.Cut(spec, "=")
+	if !found || strings.TrimSpace(path) == "" {
+		return JSONAssertion{}, fmt.Errorf("invalid JSON assertion %q, expected path=value", spec)
+	}
+	return JSONAssertion{Path: strings.TrimSpace(path), Value: value}, nil
+}
+
+// NewAssertions builds assertions from command line style values, returning nil when none are set
+// Headers are given as "Name" or "Name: value", JSON paths as "path=value"
+func NewAssertions(statusCodes string, bodyContains, bodyRegex, jsonPaths, headers []string, maxResponseTime time.Duration) (*Assertions, error) {
+	a := &Assertions{
+		BodyContains:    bodyContains,
+		MaxResponseTime: maxResponseTime,
+	}
+
+	ranges, err := ParseStatusRanges(statusCodes)
+	if err != nil {
+		return nil, err
+	}
+	a.StatusCodes = ranges
+
+	for _, expr := range bodyRegex {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body regex %q: %v", expr, err)
+		}
+		a.BodyRegex = append(a.BodyRegex, re)
+	}
+
+	for _, spec := range jsonPaths {
+		assertion, err := ParseJSONAssertion(spec)
+		if err != nil {
+			return nil, err
+		}
+		a.JSONPaths = append(a.JSONPaths, assertion)
+	}
+
+	for _, spec := range headers {
+		name, value, _ := strings.Cut(spec, ":")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("invalid header assertion %q", spec)
+		}
+		if a.Headers == nil {
+			a.Headers = make(map[string]string)
+		}
+		a.Headers[name] = strings.TrimSpace(value)
+	}
+
+	if len(a.StatusCodes) == 0 && len(a.BodyContains) == 0 && len(a.BodyRegex) == 0 &&
+		len(a.JSONPaths) == 0 && len(a.Headers) == 0 && a.MaxResponseTime == 0 {
+		return nil, nil
+	}
+	return a, nil
+}
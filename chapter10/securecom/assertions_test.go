@@ -0,0 +1,240 @@
+package securecom
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// TestParseStatusRanges tests parsing of status code lists and ranges
+func TestParseStatusRanges(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected []StatusRange
+		wantErr  bool
+	}{
+		{spec: "200", expected: []StatusRange{{200, 200}}},
+		{spec: "200, 204", expected: []StatusRange{{200, 200}, {204, 204}}},
+		{spec: "300-399", expected: []StatusRange{{300, 399}}},
+		{spec: "2xx,404", expected: []StatusRange{{200, 299}, {404, 404}}},
+		{spec: "", expected: nil},
+		{spec: "abc", wantErr: true},
+		{spec: "399-300", wantErr: true},
+		{spec: "700", wantErr: true},
+		{spec: "9xx", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			ranges, err := ParseStatusRanges(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %v", tt.spec, ranges)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(ranges) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, ranges)
+			}
+			for i := range ranges {
+				if ranges[i] != tt.expected[i] {
+					t.Errorf("Range %d: expected %v, got %v", i, tt.expected[i], ranges[i])
+				}
+			}
+		})
+	}
+}
+
+// TestLookupJSONPath tests walking decoded JSON documents
+func TestLookupJSONPath(t *testing.T) {
+	body := []byte(`{"status":"ok","data":{"count":3,"healthy":true,"items":[{"name":"r1"},{"name":"r2"}],"tags":null}}`)
+
+	tests := []struct {
+		path     string
+		expected string
+		fails    bool
+	}{
+		{path: "status", expected: "ok"},
+		{path: "$.data.count", expected: "3"},
+		{path: "data.healthy", expected: "true"},
+		{path: "data.items[1].name", expected: "r2"},
+		{path: "data.tags", expected: "null"},
+		{path: "data.items[0]", expected: `{"name":"r1"}`},
+		{path: "data.missing", fails: true},
+		{path: "data.items[5].name", fails: true},
+		{path: "status.code", fails: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			findings := checkJSONPaths(body, []JSONAssertion{{Path: tt.path, Value: tt.expected}})
+			if len(findings) != 1 {
+				t.Fatalf("Expected 1 finding, got %d", len(findings))
+			}
+
+			failed := findings[0].Severity == SeverityCritical
+			if failed != tt.fails {
+				t.Errorf("Expected failure %v, got %+v", tt.fails, findings[0])
+			}
+		})
+	}
+}
+
+// TestAssertionsEvaluate tests each assertion type against a response
+func TestAssertionsEvaluate(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 200,
+		Header: http.Header{
+			"Content-Type":  []string{"application/json"},
+			"Cache-Control": []string{"no-store"},
+		},
+	}
+	body := []byte(`{"status":"ok","version":"1.4.2"}`)
+
+	tests := []struct {
+		name       string
+		assertions Assertions
+		failed     []string
+	}{
+		{
+			name: "All pass",
+			assertions: Assertions{
+				StatusCodes:     []StatusRange{{200, 299}},
+				BodyContains:    []string{`"status":"ok"`},
+				BodyRegex:       []*regexp.Regexp{regexp.MustCompile(`"version":"1\.\d+\.\d+"`)},
+				JSONPaths:       []JSONAssertion{{Path: "status", Value: "ok"}},
+				Headers:         map[string]string{"content-type": "application/json", "Cache-Control": ""},
+				MaxResponseTime: time.Second,
+			},
+		},
+		{
+			name:       "Wrong status",
+			assertions: Assertions{StatusCodes: []StatusRange{{204, 204}, {300, 399}}},
+			failed:     []string{"status"},
+		},
+		{
+			name:       "Missing body content",
+			assertions: Assertions{BodyContains: []string{"maintenance"}, BodyRegex: []*regexp.Regexp{regexp.MustCompile(`^<html`)}},
+			failed:     []string{"body-contains", "body-regex"},
+		},
+		{
+			name:       "JSON value differs",
+			assertions: Assertions{JSONPaths: []JSONAssertion{{Path: "version", Value: "2.0.0"}}},
+			failed:     []string{"json-path"},
+		},
+		{
+			name:       "Header missing or different",
+			assertions: Assertions{Headers: map[string]string{"X-Request-Id": "", "Content-Type": "text/html"}},
+			failed:     []string{"header", "header"},
+		},
+		{
+			name:       "Too slow",
+			assertions: Assertions{MaxResponseTime: 100 * time.Millisecond},
+			failed:     []string{"response-time"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := tt.assertions.Evaluate(resp, body, 250*time.Millisecond)
+
+			var failed []string
+			for _, f := range findings {
+				if f.Severity != SeverityOK {
+					failed = append(failed, f.Name)
+				}
+			}
+
+			if len(failed) != len(tt.failed) {
+				t.Fatalf("Expected failures %v, got %v (%+v)", tt.failed, failed, findings)
+			}
+			for i := range failed {
+				if failed[i] != tt.failed[i] {
+					t.Errorf("Failure %d: expected %s, got %s", i, tt.failed[i], failed[i])
+				}
+			}
+		})
+	}
+}
+
+// TestJSONPathInvalidBody tests JSON assertions against a non-JSON body
+func TestJSONPathInvalidBody(t *testing.T) {
+	findings := checkJSONPaths([]byte("<html></html>"), []JSONAssertion{{Path: "status", Value: "ok"}})
+
+	if len(findings) != 1 || findings[0].Severity != SeverityCritical {
+		t.Errorf("Expected critical finding for invalid JSON, got %+v", findings)
+	}
+}
+
+// TestNewAssertions tests building assertions from command line values
+func TestNewAssertions(t *testing.T) {
+	a, err := NewAssertions("", nil, nil, nil, nil, 0)
+	if err != nil || a != nil {
+		t.Errorf("Expected nil assertions when nothing is set, got %+v, %v", a, err)
+	}
+
+	a, err = NewAssertions("2xx", []string{"ok"}, []string{`v\d`}, []string{"data.state=up"},
+		[]string{"Content-Type: application/json", "X-Trace"}, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewAssertions failed: %v", err)
+	}
+
+	if len(a.StatusCodes) != 1 || len(a.BodyRegex) != 1 || a.JSONPaths[0] != (JSONAssertion{Path: "data.state", Value: "up"}) {
+		t.Errorf("Unexpected assertions: %+v", a)
+	}
+	if a.Headers["Content-Type"] != "application/json" || a.Headers["X-Trace"] != "" {
+		t.Errorf("Unexpected header assertions: %v", a.Headers)
+	}
+
+	invalid := []struct {
+		name string
+		fn   func() (*Assertions, error)
+	}{
+		{"Status", func() (*Assertions, error) { return NewAssertions("bad", nil, nil, nil, nil, 0) }},
+		{"Regex", func() (*Assertions, error) { return NewAssertions("", nil, []string{"("}, nil, nil, 0) }},
+		{"JSON path", func() (*Assertions, error) { return NewAssertions("", nil, nil, []string{"novalue"}, nil, 0) }},
+		{"Header", func() (*Assertions, error) { return NewAssertions("", nil, nil, nil, []string{": value"}, 0) }},
+	}
+	for _, tt := range invalid {
+		if _, err := tt.fn(); err == nil {
+			t.Errorf("%s: expected error, got nil", tt.name)
+		}
+	}
+}
+
+// TestCheckServerWithAssertions tests that failed assertions make a live server unhealthy
+func TestCheckServerWithAssertions(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"degraded"}`))
+	}))
+	defer server.Close()
+
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	checker := NewHTTPSChecker("127.0.0.1", port, 5*time.Second, false)
+	checker.Assertions = &Assertions{JSONPaths: []JSONAssertion{{Path: "status", Value: "ok"}}}
+
+	status := checker.CheckServer()
+	if !status.IsAlive {
+		t.Fatalf("Expected server to be alive, got %v", status.Error)
+	}
+	if status.Healthy() {
+		t.Errorf("Expected failed JSON assertion to make the check unhealthy, got %+v", status.Findings)
+	}
+
+	// The per-target expected status replaces the status assertion
+	checker.Assertions = &Assertions{StatusCodes: []StatusRange{{500, 599}}}
+	checker.ExpectedStatus = 200
+
+	status = checker.CheckServer()
+	if !status.Healthy() {
+		t.Errorf("Expected status 200 to satisfy the expected status, got %+v", status.Findings)
+	}
+}
@@ -0,0 +1,537 @@
+package securecom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration written as a string such as "30s" in YAML and JSON files
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %v", err)
+	}
+	return d.parse(text)
+}
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	return d.parse(value.Value)
+}
+
+func (d *Duration) parse(text string) error {
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// ChecksFile lists the checks to run, fields missing on a check are taken from Defaults
+type ChecksFile struct {
+	Defaults CheckConfig   `yaml:"defaults" json:"defaults"`
+	Checks   []CheckConfig `yaml:"checks" json:"checks"`
+}
+
+// CheckConfig describes one target in a checks file
+// Type selects the probe, fields that do not apply to it are ignored
+type CheckConfig struct {
+	Name           string            `yaml:"name" json:"name"`
+	Type           string            `yaml:"type" json:"type"`
+	Host           string            `yaml:"host" json:"host"`
+	Port           int               `yaml:"port" json:"port"`
+	Path           string            `yaml:"path" json:"path"`
+	Method         string            `yaml:"method" json:"method"`
+	Headers        map[string]string `yaml:"headers" json:"headers"`
+	Timeout        Duration          `yaml:"timeout" json:"timeout"`
+	Interval       Duration          `yaml:"interval" json:"interval"`
+	FollowRedirect *bool             `yaml:"follow_redirect" json:"follow_redirect"`
+	Proxy          string            `yaml:"proxy" json:"proxy"`
+	TLS            TLSConfig         `yaml:"tls" json:"tls"`
+	Assert         AssertConfig      `yaml:"assert" json:"assert"`
+
+	// DNS probes resolve Host, optionally through Resolver, and expect Expect in the answer
+	RecordType string   `yaml:"record_type" json:"record_type"`
+	Expect     []string `yaml:"expect" json:"expect"`
+	Resolver   string   `yaml:"resolver" json:"resolver"`
+	// Count is the number of ICMP echo requests
+	Count int `yaml:"count" json:"count"`
+	// Banner must be contained in the SSH identification string
+	Banner string `yaml:"banner" json:"banner"`
+	// Service is the gRPC health service name, Plaintext disables TLS for gRPC
+	Service   string `yaml:"service" json:"service"`
+	Plaintext bool   `yaml:"plaintext" json:"plaintext"`
+}
+
+// Probe types of a checks file
+const (
+	ProbeHTTPS = "https"
+	ProbeHTTP  = "http"
+	ProbeTCP   = "tcp"
+	ProbeDNS   = "dns"
+	ProbeICMP  = "icmp"
+	ProbeSSH   = "ssh"
+	ProbeGRPC  = "grpc"
+)
+
+// TLSConfig holds the TLS settings of a check, the policy is evaluated when Policy is true
+type TLSConfig struct {
+	Verify              *bool    `yaml:"verify" json:"verify"`
+	CAFile              string   `yaml:"ca_file" json:"ca_file"`
+	ClientCert          string   `yaml:"client_cert" json:"client_cert"`
+	ClientKey           string   `yaml:"client_key" json:"client_key"`
+	ServerName          string   `yaml:"server_name" json:"server_name"`
+	Policy              *bool    `yaml:"policy" json:"policy"`
+	MinVersion          string   `yaml:"min_version" json:"min_version"`
+	ExpiryWarningDays   int      `yaml:"expiry_warning_days" json:"expiry_warning_days"`
+	ExpiryCriticalDays  int      `yaml:"expiry_critical_days" json:"expiry_critical_days"`
+	ForbiddenCiphers    []string `yaml:"forbidden_ciphers" json:"forbidden_ciphers"`
+	RequireOCSPStapling *bool    `yaml:"require_ocsp_stapling" json:"require_ocsp_stapling"`
+}
+
+// AssertConfig holds the response assertions of a check
+type AssertConfig struct {
+	Status          string            `yaml:"status" json:"status"`
+	BodyContains    []string          `yaml:"body_contains" json:"body_contains"`
+	BodyRegex       []string          `yaml:"body_regex" json:"body_regex"`
+	JSONPath        map[string]string `yaml:"json_path" json:"json_path"`
+	Headers         map[string]string `yaml:"headers" json:"headers"`
+	MaxResponseTime Duration          `yaml:"max_response_time" json:"max_response_time"`
+}
+
+var checkMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodOptions: true,
+}
+
+// LoadChecksFile reads a YAML or JSON checks file, chosen by extension, and validates every check
+// Unknown keys are rejected so that typos do not silently disable a setting
+func LoadChecksFile(filename string) (*ChecksFile, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checks file: %v", err)
+	}
+
+	var file ChecksFile
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse checks file %s: %v", filename, err)
+	}
+
+	if err := file.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid checks file %s: %v", filename, err)
+	}
+	return &file, nil
+}
+
+// Method Validate reports every problem in the file at once
+func (f *ChecksFile) Validate() error {
+	var problems []string
+
+	if len(f.Checks) == 0 {
+		problems = append(problems, "no checks defined")
+	}
+
+	names := make(map[string]int)
+	for i, check := range f.Checks {
+		merged := check.withDefaults(f.Defaults)
+
+		if merged.Host == "" {
+			problems = append(problems, fmt.Sprintf("checks[%d]: host is required", i))
+		}
+		if previous, ok := names[merged.name()]; ok && merged.Host != "" {
+			problems = append(problems, fmt.Sprintf("checks[%d]: name %q already used by checks[%d]", i, merged.name(), previous))
+		}
+		names[merged.name()] = i
+
+		if _, err := merged.Probe(); err != nil {
+			problems = append(problems, fmt.Sprintf("checks[%d]: %v", i, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Method NamedChecks builds a checker for every check with the defaults applied
+func (f *ChecksFile) NamedChecks() ([]NamedCheck, error) {
+	checks := make([]NamedCheck, 0, len(f.Checks))
+
+	for _, check := range f.Checks {
+		merged := check.withDefaults(f.Defaults)
+		checker, err := merged.Probe()
+		if err != nil {
+			return nil, fmt.Errorf("check %s: %v", merged.name(), err)
+		}
+		checks = append(checks, NamedCheck{
+			Name:     merged.name(),
+			Interval: time.Duration(merged.Interval),
+			Checker:  checker,
+		})
+	}
+
+	return checks, nil
+}
+
+// name returns the configured name, or a URL describing the probe when none is set
+func (c CheckConfig) name() string {
+	switch {
+	case c.Name != "":
+		return c.Name
+	case c.Type == ProbeHTTPS || c.Type == ProbeHTTP:
+		checker := NewHTTPSChecker(c.Host, c.Port, 0, true)
+		checker.Scheme = c.Type
+		checker.CustomPath = c.Path
+		return checker.BuildURL()
+	case c.Type == ProbeDNS:
+		return fmt.Sprintf("dns://%s?type=%s", c.Host, strings.ToUpper(c.RecordType))
+	case c.Port != 0:
+		return fmt.Sprintf("%s://%s:%d", c.Type, c.Host, c.Port)
+	default:
+		return fmt.Sprintf("%s://%s", c.Type, c.Host)
+	}
+}
+
+// withDefaults fills unset fields from the defaults, headers are merged with the check winning
+func (c CheckConfig) withDefaults(d CheckConfig) CheckConfig {
+	if c.Type == "" {
+		c.Type = d.Type
+	}
+	if c.Port == 0 {
+		c.Port = d.Port
+	}
+	if c.Path == "" {
+		c.Path = d.Path
+	}
+	if c.Method == "" {
+		c.Method = d.Method
+	}
+	if c.Timeout == 0 {
+		c.Timeout = d.Timeout
+	}
+	if c.Interval == 0 {
+		c.Interval = d.Interval
+	}
+	if c.FollowRedirect == nil {
+		c.FollowRedirect = d.FollowRedirect
+	}
+	if c.Proxy == "" {
+		c.Proxy = d.Proxy
+	}
+	c.Headers = mergeHeaders(d.Headers, c.Headers)
+
+	if c.TLS.Verify == nil {
+		c.TLS.Verify = d.TLS.Verify
+	}
+	if c.TLS.CAFile == "" {
+		c.TLS.CAFile = d.TLS.CAFile
+	}
+	if c.TLS.ClientCert == "" && c.TLS.ClientKey == "" {
+		c.TLS.ClientCert = d.TLS.ClientCert
+		c.TLS.ClientKey = d.TLS.ClientKey
+	}
+	if c.TLS.ServerName == "" {
+		c.TLS.ServerName = d.TLS.ServerName
+	}
+	if c.TLS.Policy == nil {
+		c.TLS.Policy = d.TLS.Policy
+	}
+	if c.TLS.MinVersion == "" {
+		c.TLS.MinVersion = d.TLS.MinVersion
+	}
+	if c.TLS.ExpiryWarningDays == 0 {
+		c.TLS.ExpiryWarningDays = d.TLS.ExpiryWarningDays
+	}
+	if c.TLS.ExpiryCriticalDays == 0 {
+		c.TLS.ExpiryCriticalDays = d.TLS.ExpiryCriticalDays
+	}
+	if c.TLS.ForbiddenCiphers == nil {
+		c.TLS.ForbiddenCiphers = d.TLS.ForbiddenCiphers
+	}
+	if c.TLS.RequireOCSPStapling == nil {
+		c.TLS.RequireOCSPStapling = d.TLS.RequireOCSPStapling
+	}
+
+	if c.Assert.Status == "" {
+		c.Assert.Status = d.Assert.Status
+	}
+	if c.Assert.BodyContains == nil {
+		c.Assert.BodyContains = d.Assert.BodyContains
+	}
+	if c.Assert.BodyRegex == nil {
+		c.Assert.BodyRegex = d.Assert.BodyRegex
+	}
+	if c.Assert.JSONPath == nil {
+		c.Assert.JSONPath = d.Assert.JSONPath
+	}
+	if c.Assert.Headers == nil {
+		c.Assert.Headers = d.Assert.Headers
+	}
+	if c.Assert.MaxResponseTime == 0 {
+		c.Assert.MaxResponseTime = d.Assert.MaxResponseTime
+	}
+
+	if c.RecordType == "" {
+		c.RecordType = d.RecordType
+	}
+	if c.Resolver == "" {
+		c.Resolver = d.Resolver
+	}
+	if c.Count == 0 {
+		c.Count = d.Count
+	}
+
+	c.Type = strings.ToLower(c.Type)
+	if c.Type == "" {
+		c.Type = ProbeHTTPS
+	}
+	if c.Port == 0 {
+		switch c.Type {
+		case ProbeHTTPS:
+			c.Port = 443
+		case ProbeHTTP:
+			c.Port = 80
+		case ProbeSSH:
+			c.Port = 22
+		}
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.Method == "" {
+		c.Method = http.MethodGet
+	}
+	if c.RecordType == "" {
+		c.RecordType = "A"
+	}
+	if c.Count == 0 {
+		c.Count = 3
+	}
+	if c.Timeout == 0 {
+		c.Timeout = Duration(10 * time.Second)
+	}
+
+	return c
+}
+
+func mergeHeaders(defaults, overrides map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return overrides
+	}
+	merged := make(map[string]string)
+	for name, value := range defaults {
+		merged[name] = value
+	}
+	for name, value := range overrides {
+		merged[name] = value
+	}
+	return merged
+}
+
+// Method Probe builds the checker for the probe type, returning an error for invalid settings
+func (c CheckConfig) Probe() (StatusChecker, error) {
+	if c.Timeout < 0 || c.Interval < 0 {
+		return nil, fmt.Errorf("timeout and interval must not be negative")
+	}
+	if c.Proxy != "" && c.Type != ProbeHTTPS && c.Type != ProbeHTTP {
+		return nil, fmt.Errorf("proxy is only supported for http and https probes")
+	}
+	timeout := time.Duration(c.Timeout)
+
+	switch c.Type {
+	case ProbeHTTPS, ProbeHTTP:
+		return c.Checker()
+	case ProbeTCP:
+		if err := c.checkPort(); err != nil {
+			return nil, err
+		}
+		return NewTCPChecker(c.Host, c.Port, timeout), nil
+	case ProbeDNS:
+		checker := NewDNSChecker(c.Host, timeout)
+		checker.RecordType = strings.ToUpper(c.RecordType)
+		checker.Expected = c.Expect
+		checker.Resolver = c.Resolver
+		if !dnsRecordTypes[checker.RecordType] {
+			return nil, fmt.Errorf("unsupported record type %s", c.RecordType)
+		}
+		if checker.Resolver != "" {
+			if _, _, err := net.SplitHostPort(checker.Resolver); err != nil {
+				return nil, fmt.Errorf("resolver must be host:port: %v", err)
+			}
+		}
+		return checker, nil
+	case ProbeICMP:
+		checker := NewICMPChecker(c.Host, timeout)
+		checker.Count = c.Count
+		return checker, nil
+	case ProbeSSH:
+		if err := c.checkPort(); err != nil {
+			return nil, err
+		}
+		checker := NewSSHBannerChecker(c.Host, c.Port, timeout)
+		checker.Expected = c.Banner
+		return checker, nil
+	case ProbeGRPC:
+		if err := c.checkPort(); err != nil {
+			return nil, err
+		}
+		checker := NewGRPCHealthChecker(c.Host, c.Port, timeout)
+		checker.Service = c.Service
+		if !c.Plaintext {
+			tlsConfig, err := c.transportConfig().TLSConfig()
+			if err != nil {
+				return nil, err
+			}
+			checker.TLS = tlsConfig
+		}
+		return checker, nil
+	default:
+		return nil, fmt.Errorf("unknown probe type %s", c.Type)
+	}
+}
+
+func (c CheckConfig) checkPort() error {
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("port %d out of range", c.Port)
+	}
+	return nil
+}
+
+func (c CheckConfig) transportConfig() TransportConfig {
+	return TransportConfig{
+		VerifyTLS:  c.TLS.Verify == nil || *c.TLS.Verify,
+		CertFile:   c.TLS.ClientCert,
+		KeyFile:    c.TLS.ClientKey,
+		CAFile:     c.TLS.CAFile,
+		ServerName: c.TLS.ServerName,
+		Proxy:      c.Proxy,
+	}
+}
+
+// Method Checker builds the HTTP or HTTPS checker, returning an error for invalid settings
+func (c CheckConfig) Checker() (*HTTPSChecker, error) {
+	if err := c.checkPort(); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(c.Path, "/") {
+		return nil, fmt.Errorf("path %q must start with /", c.Path)
+	}
+	method := strings.ToUpper(c.Method)
+	if !checkMethods[method] {
+		return nil, fmt.Errorf("unsupported method %s", c.Method)
+	}
+	verify := c.TLS.Verify == nil || *c.TLS.Verify
+	checker := NewHTTPSChecker(c.Host, c.Port, time.Duration(c.Timeout), verify)
+	checker.Scheme = c.Type
+	checker.CustomPath = c.Path
+	checker.Method = method
+	checker.Headers = c.Headers
+	if c.FollowRedirect != nil {
+		checker.FollowRedirect = *c.FollowRedirect
+	}
+
+	// Each check gets its own transport since TLS and proxy settings differ between checks
+	transport, err := c.transportConfig().Transport()
+	if err != nil {
+		return nil, err
+	}
+	checker.Transport = transport
+
+	if c.TLS.Policy != nil && *c.TLS.Policy {
+		policy, err := c.TLS.policy()
+		if err != nil {
+			return nil, err
+		}
+		policy.Roots = checker.Transport.TLSClientConfig.RootCAs
+		checker.Policy = policy
+	}
+
+	var jsonPaths []string
+	for path, value := range c.Assert.JSONPath {
+		jsonPaths = append(jsonPaths, path+"="+value)
+	}
+	sort.Strings(jsonPaths)
+	var headers []string
+	for name, value := range c.Assert.Headers {
+		headers = append(headers, name+": "+value)
+	}
+	assertions, err := NewAssertions(c.Assert.Status, c.Assert.BodyContains, c.Assert.BodyRegex, jsonPaths, headers,
+		time.Duration(c.Assert.MaxResponseTime))
+	if err != nil {
+		return nil, err
+	}
+	checker.Assertions = assertions
+
+	return checker, nil
+}
+
+func (t TLSConfig) policy() (*TLSPolicy, error) {
+	policy := NewTLSPolicy()
+	if t.ExpiryWarningDays != 0 {
+		policy.ExpiryWarningDays = t.ExpiryWarningDays
+	}
+	if t.ExpiryCriticalDays != 0 {
+		policy.ExpiryCriticalDays = t.ExpiryCriticalDays
+	}
+	if t.RequireOCSPStapling != nil {
+		policy.RequireOCSPStapling = *t.RequireOCSPStapling
+	}
+
+	if t.MinVersion != "" {
+		version, err := ParseTLSVersion(t.MinVersion)
+		if err != nil {
+			return nil, err
+		}
+		policy.MinVersion = version
+	}
+
+	ciphers, err := ParseCipherSuites(strings.Join(t.ForbiddenCiphers, ","))
+	if err != nil {
+		return nil, err
+	}
+	policy.ForbiddenCiphers = append(policy.ForbiddenCiphers, ciphers...)
+
+	return policy, nil
+}
+
+// RunChecksFile runs the checks of a file once, or continuously with the monitor
+func RunChecksFile(filename *string, workers *int, continuous *bool, monitor *Monitor, output *Output) {
+	file, err := LoadChecksFile(*filename)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(ExitUnknown)
+	}
+
+	checks, err := file.NamedChecks()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(ExitUnknown)
+	}
+
+	fmt.Fprintf(output.progress(), "Loaded %d check(s) from %s\n", len(checks), *filename)
+	RunChecks(checks, *workers, *continuous, monitor, output)
+}
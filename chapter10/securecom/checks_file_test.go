@@ -0,0 +1,236 @@
+package securecom
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeChecksFile(t *testing.T, name, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write checks file: %v", err)
+	}
+	return filename
+}
+
+// TestLoadChecksFileYAML tests loading a YAML file with defaults applied to each check
+func TestLoadChecksFileYAML(t *testing.T) {
+	filename := writeChecksFile(t, "checks.yaml", `
+defaults:
+  timeout: 5s
+  interval: 1m
+  headers:
+    User-Agent: securecom
+  tls:
+    verify: false
+checks:
+  - name: api
+    host: api.example.com
+    port: 8443
+    path: /health
+    method: head
+    interval: 15s
+    headers:
+      Authorization: Bearer token
+    tls:
+      policy: true
+      min_version: "1.3"
+    assert:
+      status: 2xx
+      json_path:
+        status: ok
+      max_response_time: 500ms
+  - host: www.example.com
+    follow_redirect: false
+`)
+
+	file, err := LoadChecksFile(filename)
+	if err != nil {
+		t.Fatalf("LoadChecksFile failed: %v", err)
+	}
+
+	checks, err := file.NamedChecks()
+	if err != nil {
+		t.Fatalf("NamedChecks failed: %v", err)
+	}
+	if len(checks) != 2 {
+		t.Fatalf("Expected 2 checks, got %d", len(checks))
+	}
+
+	if checks[0].Name != "api" || checks[0].Interval != 15*time.Second {
+		t.Errorf("Unexpected first check: %+v", checks[0])
+	}
+	api := checks[0].Checker.(*HTTPSChecker)
+	if api.BuildURL() != "https://api.example.com:8443/health" || api.Method != "HEAD" || api.Timeout != 5*time.Second || api.VerifyTLS {
+		t.Errorf("Unexpected api checker: %+v", api)
+	}
+	if api.Headers["User-Agent"] != "securecom" || api.Headers["Authorization"] != "Bearer token" {
+		t.Errorf("Expected merged headers, got %v", api.Headers)
+	}
+	if api.Policy == nil || api.Policy.MinVersion != 0x0304 {
+		t.Errorf("Expected TLS 1.3 policy, got %+v", api.Policy)
+	}
+	if api.Assertions == nil || len(api.Assertions.StatusCodes) != 1 || api.Assertions.MaxResponseTime != 500*time.Millisecond {
+		t.Errorf("Unexpected assertions: %+v", api.Assertions)
+	}
+
+	if checks[1].Name != "https://www.example.com/" || checks[1].Interval != time.Minute {
+		t.Errorf("Unexpected second check: %+v", checks[1])
+	}
+	www := checks[1].Checker.(*HTTPSChecker)
+	if www.FollowRedirect || www.Method != "GET" || www.Port != 443 || www.Assertions != nil {
+		t.Errorf("Unexpected www checker: %+v", www)
+	}
+}
+
+// TestLoadChecksFileJSON tests loading the same format from JSON
+func TestLoadChecksFileJSON(t *testing.T) {
+	filename := writeChecksFile(t, "checks.json", `{
+  "checks": [
+    {"host": "api.example.com", "timeout": "2s", "assert": {"body_contains": ["ok"]}}
+  ]
+}`)
+
+	file, err := LoadChecksFile(filename)
+	if err != nil {
+		t.Fatalf("LoadChecksFile failed: %v", err)
+	}
+
+	checks, _ := file.NamedChecks()
+	checker := checks[0].Checker.(*HTTPSChecker)
+	if checker.Timeout != 2*time.Second || checker.Assertions.BodyContains[0] != "ok" {
+		t.Errorf("Unexpected checker: %+v", checker)
+	}
+}
+
+// TestLoadChecksFileInvalid tests that parse errors and every validation problem are reported
+func TestLoadChecksFileInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "Unknown key",
+			filename: "checks.yaml",
+			content:  "checks:\n  - host: a.example.com\n    prot: 443\n",
+			expected: []string{"field prot not found"},
+		},
+		{
+			name:     "Unknown JSON key",
+			filename: "checks.json",
+			content:  `{"checks": [{"hostname": "a.example.com"}]}`,
+			expected: []string{`unknown field "hostname"`},
+		},
+		{
+			name:     "Invalid duration",
+			filename: "checks.yaml",
+			content:  "checks:\n  - host: a.example.com\n    timeout: soon\n",
+			expected: []string{"invalid duration"},
+		},
+		{
+			name:     "No checks",
+			filename: "checks.yaml",
+			content:  "defaults:\n  port: 443\n",
+			expected: []string{"no checks defined"},
+		},
+		{
+			name:     "Every problem reported",
+			filename: "checks.yaml",
+			content: `
+checks:
+  - port: 443
+  - host: a.example.com
+    port: 70000
+  - host: b.example.com
+    method: DELETE
+  - host: c.example.com
+    assert:
+      status: abc
+  - name: dup
+    host: d.example.com
+  - name: dup
+    host: e.example.com
+    tls:
+      policy: true
+      min_version: "2.0"
+`,
+			expected: []string{
+				"checks[0]: host is required",
+				"checks[1]: port 70000 out of range",
+				"checks[2]: unsupported method DELETE",
+				"checks[3]: invalid status code range: abc",
+				`checks[5]: name "dup" already used by checks[4]`,
+				"checks[5]: unknown TLS version: 2.0",
+			},
+		},
+		{
+			name:     "Transport settings",
+			filename: "checks.yaml",
+			content:  "checks:\n  - host: a.example.com\n    proxy: ftp://proxy\n  - host: b.example.com\n    tls:\n      client_cert: client.pem\n",
+			expected: []string{
+				`checks[0]: unsupported proxy scheme "ftp"`,
+				"checks[1]: client certificate and key must be set together",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadChecksFile(writeChecksFile(t, tt.filename, tt.content))
+			if err == nil {
+				t.Fatalf("Expected error")
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("Expected error to contain %q, got: %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+// TestChecksFileRequest tests that method and headers from the file reach the server
+func TestChecksFileRequest(t *testing.T) {
+	var method, auth string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+	file := &ChecksFile{Checks: []CheckConfig{{
+		Host:    "127.0.0.1",
+		Port:    port,
+		Method:  "post",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		TLS:     TLSConfig{Verify: new(bool)},
+		Assert:  AssertConfig{Status: "204"},
+	}}}
+
+	if err := file.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	checks, err := file.NamedChecks()
+	if err != nil {
+		t.Fatalf("NamedChecks failed: %v", err)
+	}
+
+	status := checks[0].Checker.CheckServer()
+	if !status.Healthy() {
+		t.Errorf("Expected healthy check, got %v %+v", status.Error, status.Findings)
+	}
+	if method != http.MethodPost || auth != "Bearer token" {
+		t.Errorf("Expected POST with Authorization header, got %s %q", method, auth)
+	}
+}
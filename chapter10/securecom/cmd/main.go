@@ -5,12 +5,27 @@ import (
 	"fmt"
 	"os"
 	"securecom"
+	"strings"
+	"time"
 )
 
+// stringList is a flag that can be repeated
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 func main() {
 	// Define command-line flags
 	hostname := flag.String("hostname", "", "Server hostname or IP address")
-	hostnames := flag.String("hostnames", "", "Comma-separated list of hostnames")
+	hostnames := flag.String("hostnames", "", "Comma-separated list of host[:port][/path][=status] targets")
+	config := flag.String("config", "", "YAML or JSON checks file describing each target")
 	port := flag.Int("port", 443, "HTTPS port (default: 443)")
 	timeout := flag.Int("timeout", 10, "Request timeout in seconds")
 	path := flag.String("path", "/", "URL path to check (default: /)")
@@ -18,15 +33,135 @@ func main() {
 	followRedirect := flag.Bool("follow-redirect", true, "Follow HTTP redirects (default: true)")
 	continuous := flag.Bool("continuous", false, "Continuous monitoring mode")
 	interval := flag.Int("interval", 60, "Check interval in seconds for continuous mode")
+	workers := flag.Int("workers", 10, "Number of servers checked concurrently")
+	tlsPolicy := flag.Bool("tls-policy", false, "Evaluate certificate expiry and TLS policy findings")
+	expiryWarning := flag.Int("expiry-warning-days", 30, "Warn when the certificate expires within this many days")
+	expiryCritical := flag.Int("expiry-critical-days", 7, "Fail when the certificate expires within this many days")
+	minTLS := flag.String("min-tls", "1.2", "Minimum accepted TLS version")
+	forbiddenCiphers := flag.String("forbidden-ciphers", "", "Comma-separated cipher suites to reject in addition to insecure ones")
+	caFile := flag.String("ca-file", "", "PEM CA bundle used to validate the certificate chain")
+	clientCert := flag.String("client-cert", "", "PEM client certificate presented for mutual TLS")
+	clientKey := flag.String("client-key", "", "PEM private key of the client certificate")
+	serverName := flag.String("server-name", "", "Override the TLS server name (SNI) sent and verified")
+	proxy := flag.String("proxy", "", "Proxy URL (http, https, socks5), or env to use HTTP(S)_PROXY")
+	requireOCSP := flag.Bool("require-ocsp-stapling", false, "Fail when no OCSP response is stapled")
+	expectStatus := flag.String("expect-status", "", "Accepted status codes, e.g. 200,204,300-399,4xx")
+	maxResponse := flag.Int("max-response-ms", 0, "Fail when the response takes longer than this many milliseconds")
+	failThreshold := flag.Int("fail-threshold", 3, "Consecutive failed checks before a host is DEGRADED or DOWN")
+	recoverThreshold := flag.Int("recover-threshold", 2, "Consecutive good checks before a host is UP again")
+	flapThreshold := flag.Int("flap-threshold", 4, "State changes within the flap window that mark a host as flapping, 0 disables")
+	flapWindow := flag.Int("flap-window", 600, "Flap detection window in seconds")
+	webhookURL := flag.String("webhook-url", "", "URL receiving state changes as JSON POST")
+	smtpServer := flag.String("smtp-server", "", "SMTP server as host:port for mail notifications")
+	smtpFrom := flag.String("smtp-from", "", "Sender address of mail notifications")
+	smtpTo := flag.String("smtp-to", "", "Comma-separated recipients of mail notifications")
+	smtpUser := flag.String("smtp-user", "", "SMTP username, the password is read from SMTP_PASSWORD")
+	notifyCommand := flag.String("notify-command", "", "Shell command run on state changes, the event is passed on stdin")
+	outputFormat := flag.String("output", "text", "Result format: text, json, junit or prometheus-textfile")
+	outputFile := flag.String("output-file", "", "Write machine-readable results atomically to this file instead of standard output")
+	statusListen := flag.String("status-listen", "", "Address serving the status page and /status.json, e.g. :8080")
+	var bodyContains, bodyRegex, jsonPaths, headers stringList
+	flag.Var(&bodyContains, "body-contains", "Substring the body must contain (repeatable)")
+	flag.Var(&bodyRegex, "body-regex", "Regular expression the body must match (repeatable)")
+	flag.Var(&jsonPaths, "json-path", "JSON path and expected value as path=value (repeatable)")
+	flag.Var(&headers, "header", "Required response header as Name or Name: value (repeatable)")
 
 	flag.Parse()
 
 	// Validate required flags
-	if *hostname == "" && *hostnames == "" {
-		fmt.Println("Error: either -hostname or -hostnames is required")
+	if *hostname == "" && *hostnames == "" && *config == "" {
+		fmt.Println("Error: one of -hostname, -hostnames or -config is required")
 		flag.Usage()
-		os.Exit(1)
+		os.Exit(securecom.ExitUnknown)
+	}
+
+	var policy *securecom.TLSPolicy
+	if *tlsPolicy {
+		policy = securecom.NewTLSPolicy()
+		policy.ExpiryWarningDays = *expiryWarning
+		policy.ExpiryCriticalDays = *expiryCritical
+		policy.RequireOCSPStapling = *requireOCSP
+
+		version, err := securecom.ParseTLSVersion(*minTLS)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(securecom.ExitUnknown)
+		}
+		policy.MinVersion = version
+
+		ciphers, err := securecom.ParseCipherSuites(*forbiddenCiphers)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(securecom.ExitUnknown)
+		}
+		policy.ForbiddenCiphers = append(policy.ForbiddenCiphers, ciphers...)
+
+		if *caFile != "" {
+			roots, err := securecom.LoadCABundle(*caFile)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(securecom.ExitUnknown)
+			}
+			policy.Roots = roots
+		}
+	}
+
+	assertions, err := securecom.NewAssertions(*expectStatus, bodyContains, bodyRegex, jsonPaths, headers,
+		time.Duration(*maxResponse)*time.Millisecond)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(securecom.ExitUnknown)
+	}
+
+	format, err := securecom.ParseOutputFormat(*outputFormat)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(securecom.ExitUnknown)
+	}
+	if *outputFile != "" && format == securecom.OutputText {
+		fmt.Println("Error: -output-file requires -output json, junit or prometheus-textfile")
+		os.Exit(securecom.ExitUnknown)
+	}
+	output := &securecom.Output{Format: format, File: *outputFile}
+
+	monitor := securecom.NewMonitor(time.Duration(*interval) * time.Second)
+	monitor.FailThreshold = *failThreshold
+	monitor.RecoverThreshold = *recoverThreshold
+	monitor.FlapThreshold = *flapThreshold
+	monitor.FlapWindow = time.Duration(*flapWindow) * time.Second
+	monitor.ListenAddr = *statusListen
+
+	notifyTimeout := time.Duration(*timeout) * time.Second
+	if *webhookURL != "" {
+		monitor.Notifiers = append(monitor.Notifiers, securecom.NewWebhookNotifier(*webhookURL, notifyTimeout))
+	}
+	if *smtpServer != "" {
+		if *smtpFrom == "" || *smtpTo == "" {
+			fmt.Println("Error: -smtp-from and -smtp-to are required with -smtp-server")
+			os.Exit(securecom.ExitUnknown)
+		}
+		mailer := securecom.NewSMTPNotifier(*smtpServer, *smtpFrom, strings.Split(*smtpTo, ","))
+		mailer.Username = *smtpUser
+		mailer.Password = os.Getenv("SMTP_PASSWORD")
+		monitor.Notifiers = append(monitor.Notifiers, mailer)
+	}
+	if *notifyCommand != "" {
+		monitor.Notifiers = append(monitor.Notifiers, securecom.NewCommandNotifier(*notifyCommand, notifyTimeout))
+	}
+
+	if *config != "" {
+		securecom.RunChecksFile(config, workers, continuous, monitor, output)
+		return
+	}
+
+	transportConfig := &securecom.TransportConfig{
+		VerifyTLS:  *verifyTLS,
+		CertFile:   *clientCert,
+		KeyFile:    *clientKey,
+		CAFile:     *caFile,
+		ServerName: *serverName,
+		Proxy:      *proxy,
 	}
 
-	securecom.ConnectAndCheck(hostname, hostnames, path, port, timeout, interval, verifyTLS, followRedirect, continuous)
+	securecom.ConnectAndCheck(hostname, hostnames, path, port, timeout, interval, workers, verifyTLS, followRedirect, continuous, policy, assertions, transportConfig, monitor, output)
 }
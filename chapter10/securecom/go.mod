@@ -2,6 +2,21 @@ module securecom
 
 go 1.24.9
 
-require golang.org/x/crypto v0.45.0
+replace discovery => ../discovery
 
-require golang.org/x/sys v0.38.0 // indirect
+require (
+	discovery v0.0.0-00010101000000-000000000000
+	github.com/pkg/sftp v1.13.9
+	golang.org/x/crypto v0.45.0
+	golang.org/x/net v0.47.0
+	google.golang.org/grpc v1.76.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/kr/fs v0.1.0 // indirect
+	golang.org/x/sys v0.38.0 // indirect
+	golang.org/x/text v0.31.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250804133106-a7a43d27e69b // indirect
+	google.golang.org/protobuf v1.36.6 // indirect
+)
@@ -0,0 +1,250 @@
+package securecom
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"securecom/sshtest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// TestParseProxyJump tests parsing of OpenSSH ProxyJump specifications
+func TestParseProxyJump(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected []JumpHost
+		wantErr  bool
+	}{
+		{
+			name:     "Single host",
+			spec:     "bastion.example.com",
+			expected: []JumpHost{{Hostname: "bastion.example.com"}},
+		},
+		{
+			name: "User and port",
+			spec: "admin@bastion1:2222",
+			expected: []JumpHost{
+				{Hostname: "bastion1", Port: 2222, Username: "admin"},
+			},
+		},
+		{
+			name: "Chain of hosts",
+			spec: "ops@10.0.0.1, admin@[2001:db8::1]:22",
+			expected: []JumpHost{
+				{Hostname: "10.0.0.1", Username: "ops"},
+				{Hostname: "2001:db8::1", Port: 22, Username: "admin"},
+			},
+		},
+		{
+			name:    "Invalid port",
+			spec:    "bastion:ssh",
+			wantErr: true,
+		},
+		{
+			name:    "Missing host",
+			spec:    "admin@",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts, err := ParseProxyJump(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got nil", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(hosts) != len(tt.expected) {
+				t.Fatalf("Expected %d hosts, got %d", len(tt.expected), len(hosts))
+			}
+			for i := range hosts {
+				if hosts[i] != tt.expected[i] {
+					t.Errorf("Host %d: expected %+v, got %+v", i, tt.expected[i], hosts[i])
+				}
+			}
+		})
+	}
+}
+
+// TestJumpHostAddress tests the default SSH port of a jump host
+func TestJumpHostAddress(t *testing.T) {
+	if addr := (JumpHost{Hostname: "bastion"}).Address(); addr != "bastion:22" {
+		t.Errorf("Expected bastion:22, got %s", addr)
+	}
+
+	if addr := (JumpHost{Hostname: "bastion", Port: 2222}).Address(); addr != "bastion:2222" {
+		t.Errorf("Expected bastion:2222, got %s", addr)
+	}
+}
+
+// TestJumpHostClientConfig tests the authentication methods built for a jump host
+func TestJumpHostClientConfig(t *testing.T) {
+	if _, err := (JumpHost{Hostname: "bastion", Username: "ops"}).ClientConfig(time.Second); err == nil {
+		t.Error("Expected error for jump host without credentials, got nil")
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+
+	jump := JumpHost{Hostname: "bastion", Username: "ops", Password: "pw", KeyFile: keyFile}
+	config, err := jump.ClientConfig(5 * time.Second)
+	if err != nil {
+		t.Fatalf("ClientConfig failed: %v", err)
+	}
+
+	if len(config.Auth) != 2 {
+		t.Errorf("Expected key and password auth, got %d methods", len(config.Auth))
+	}
+
+	if config.User != "ops" || config.Timeout != 5*time.Second {
+		t.Errorf("Unexpected config: user=%s timeout=%v", config.User, config.Timeout)
+	}
+
+	jump.KeyFile = filepath.Join(t.TempDir(), "missing")
+	if _, err := jump.ClientConfig(time.Second); err == nil {
+		t.Error("Expected error for missing key file, got nil")
+	}
+}
+
+// TestConnectThroughJumpHost tests running a command on a device behind one bastion
+func TestConnectThroughJumpHost(t *testing.T) {
+	device := startTestServer(t, sshtest.Config{
+		Username:  "admin",
+		Password:  "secret",
+		Responses: map[string]string{"show version": "device output\n"},
+	})
+	bastion := startTestServer(t, sshtest.Config{Username: "ops", Password: "bastion-pw"})
+
+	client := NewSSHClient("127.0.0.1", "admin", "secret", device.Port(), 5*time.Second)
+	client.JumpHosts = []JumpHost{
+		{Hostname: "127.0.0.1", Port: bastion.Port(), Username: "ops", Password: "bastion-pw"},
+	}
+
+	conn, err := client.Connect()
+	if err != nil {
+		t.Fatalf("Connect through jump host failed: %v", err)
+	}
+	defer conn.Close()
+
+	output, err := client.ExecuteCommand(conn, "show version")
+	if err != nil {
+		t.Fatalf("ExecuteCommand failed: %v", err)
+	}
+	if output != "device output\n" {
+		t.Errorf("Unexpected output: %q", output)
+	}
+
+	forwarded := bastion.Forwarded()
+	expected := fmt.Sprintf("127.0.0.1:%d", device.Port())
+	if len(forwarded) != 1 || forwarded[0] != expected {
+		t.Errorf("Expected bastion to forward to %s, got %v", expected, forwarded)
+	}
+}
+
+// TestConnectThroughJumpHostChain tests chaining through two bastions
+func TestConnectThroughJumpHostChain(t *testing.T) {
+	device := startTestServer(t, sshtest.Config{
+		Username:  "admin",
+		Password:  "secret",
+		Responses: map[string]string{"show clock": "12:00:00\n"},
+	})
+	inner := startTestServer(t, sshtest.Config{Username: "inner", Password: "inner-pw"})
+	outer := startTestServer(t, sshtest.Config{Username: "outer", Password: "outer-pw"})
+
+	jumps := []JumpHost{
+		{Hostname: "127.0.0.1", Port: outer.Port(), Username: "outer", Password: "outer-pw"},
+		{Hostname: "127.0.0.1", Port: inner.Port(), Username: "inner", Password: "inner-pw"},
+	}
+
+	config := &ssh.ClientConfig{
+		User:            "admin",
+		Auth:            []ssh.AuthMethod{ssh.Password("secret")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	}
+
+	conn, err := DialThroughJumpHosts(jumps, fmt.Sprintf("127.0.0.1:%d", device.Port()), config)
+	if err != nil {
+		t.Fatalf("DialThroughJumpHosts failed: %v", err)
+	}
+	defer conn.Close()
+
+	client := NewSSHClient("127.0.0.1", "admin", "secret", device.Port(), 5*time.Second)
+	output, err := client.ExecuteCommand(conn, "show clock")
+	if err != nil {
+		t.Fatalf("ExecuteCommand failed: %v", err)
+	}
+	if output != "12:00:00\n" {
+		t.Errorf("Unexpected output: %q", output)
+	}
+
+	if got := outer.Forwarded(); len(got) != 1 || !strings.HasSuffix(got[0], fmt.Sprint(inner.Port())) {
+		t.Errorf("Expected outer bastion to forward to inner bastion, got %v", got)
+	}
+	if got := inner.Forwarded(); len(got) != 1 || !strings.HasSuffix(got[0], fmt.Sprint(device.Port())) {
+		t.Errorf("Expected inner bastion to forward to device, got %v", got)
+	}
+}
+
+// TestConnectThroughJumpHost_BadCredentials tests that bastion authentication errors are reported
+func TestConnectThroughJumpHost_BadCredentials(t *testing.T) {
+	device := startTestServer(t, sshtest.Config{Username: "admin", Password: "secret"})
+	bastion := startTestServer(t, sshtest.Config{Username: "ops", Password: "bastion-pw"})
+
+	client := NewSSHClient("127.0.0.1", "admin", "secret", device.Port(), 5*time.Second)
+	client.JumpHosts = []JumpHost{
+		{Hostname: "127.0.0.1", Port: bastion.Port(), Username: "ops", Password: "wrong"},
+	}
+
+	_, err := client.Connect()
+	if err == nil {
+		t.Fatal("Expected error for bad bastion credentials, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "jump host") {
+		t.Errorf("Expected error to mention the jump host, got %v", err)
+	}
+
+	if len(bastion.Forwarded()) != 0 {
+		t.Error("Bastion should not forward without authentication")
+	}
+}
+
+// TestConnectThroughJumpHost_DeviceAuthFailure tests device authentication errors behind a bastion
+func TestConnectThroughJumpHost_DeviceAuthFailure(t *testing.T) {
+	device := startTestServer(t, sshtest.Config{Username: "admin", Password: "secret"})
+	bastion := startTestServer(t, sshtest.Config{Username: "ops", Password: "bastion-pw"})
+
+	client := NewSSHClient("127.0.0.1", "admin", "wrong", device.Port(), 5*time.Second)
+	client.JumpHosts = []JumpHost{
+		{Hostname: "127.0.0.1", Port: bastion.Port(), Username: "ops", Password: "bastion-pw"},
+	}
+
+	if _, err := client.Connect(); err == nil {
+		t.Fatal("Expected error for bad device credentials, got nil")
+	}
+}
@@ -0,0 +1,385 @@
+package securecom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HostState is the alerting state of a monitored host
+type HostState string
+
+const (
+	StateUnknown  HostState = "UNKNOWN"
+	StateUp       HostState = "UP"
+	StateDegraded HostState = "DEGRADED"
+	StateDown     HostState = "DOWN"
+)
+
+// StatusChecker is anything the monitor can check, such as an HTTPSChecker
+type StatusChecker interface {
+	CheckServer() *ServerStatus
+}
+
+// ObservedState classifies a single check result
+// A server that answers but fails an assertion or has policy findings is degraded
+func ObservedState(status *ServerStatus) HostState {
+	switch {
+	case !status.IsAlive:
+		return StateDown
+	case !status.Healthy() || WorstSeverity(status.Findings) != SeverityOK:
+		return StateDegraded
+	default:
+		return StateUp
+	}
+}
+
+// Event describes a state change that is sent to the notifiers
+type Event struct {
+	Host     string        `json:"host"`
+	Previous HostState     `json:"previous"`
+	Current  HostState     `json:"current"`
+	Flapping bool          `json:"flapping"`
+	Message  string        `json:"message"`
+	Time     time.Time     `json:"time"`
+	Status   *ServerStatus `json:"status,omitempty"`
+}
+
+// HostStatus is the tracked state of one monitored host
+type HostStatus struct {
+	Name        string        `json:"name"`
+	State       HostState     `json:"state"`
+	Since       time.Time     `json:"since"`
+	Flapping    bool          `json:"flapping"`
+	LastChecked time.Time     `json:"last_checked"`
+	LastStatus  *ServerStatus `json:"last_status,omitempty"`
+
+	checker      StatusChecker
+	interval     time.Duration
+	nextCheck    time.Time
+	pending      HostState
+	pendingCount int
+	transitions  []time.Time
+	notified     HostState
+}
+
+// Monitor checks hosts periodically and notifies on state transitions
+type Monitor struct {
+	Interval time.Duration
+	// FailThreshold is the number of consecutive bad checks before a host becomes DEGRADED or DOWN
+	FailThreshold int
+	// RecoverThreshold is the number of consecutive good checks before a host is UP again
+	RecoverThreshold int
+	// A host making FlapThreshold transitions within FlapWindow is flapping and its notifications are held back
+	FlapThreshold int
+	FlapWindow    time.Duration
+	Notifiers     []Notifier
+	// ListenAddr serves the status page and JSON endpoint when set
+	ListenAddr string
+
+	mu    sync.Mutex
+	hosts []*HostStatus
+	now   func() time.Time
+}
+
+// NewMonitor creates a monitor that marks a host down after 3 failed checks,
+// up after 2 good checks, and flapping after 4 transitions within 10 intervals
+func NewMonitor(interval time.Duration) *Monitor {
+	return &Monitor{
+		Interval:         interval,
+		FailThreshold:    3,
+		RecoverThreshold: 2,
+		FlapThreshold:    4,
+		FlapWindow:       10 * interval,
+		now:              time.Now,
+	}
+}
+
+// Method Add registers a host under a unique name
+func (m *Monitor) Add(name string, checker StatusChecker) {
+	m.AddWithInterval(name, checker, 0)
+}
+
+// Method AddWithInterval registers a host checked at its own interval, zero uses the monitor interval
+func (m *Monitor) AddWithInterval(name string, checker StatusChecker, interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hosts = append(m.hosts, &HostStatus{Name: name, State: StateUnknown, notified: StateUnknown,
+		checker: checker, interval: interval})
+}
+
+// tick returns the shortest interval of the monitor and its hosts
+func (m *Monitor) tick() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tick := m.Interval
+	for _, h := range m.hosts {
+		if h.interval > 0 && (tick <= 0 || h.interval < tick) {
+			tick = h.interval
+		}
+	}
+	return tick
+}
+
+// Method Hosts returns a copy of the tracked state of every host
+func (m *Monitor) Hosts() []HostStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hosts := make([]HostStatus, 0, len(m.hosts))
+	for _, h := range m.hosts {
+		hosts = append(hosts, HostStatus{
+			Name:        h.Name,
+			State:       h.State,
+			Since:       h.Since,
+			Flapping:    h.Flapping,
+			LastChecked: h.LastChecked,
+			LastStatus:  h.LastStatus,
+		})
+	}
+	return hosts
+}
+
+// Method Observe feeds one check result into the state machine of a host
+// It returns the event to notify, or nil when nothing should be sent
+func (m *Monitor) Observe(name string, status *ServerStatus) *Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var host *HostStatus
+	for _, h := range m.hosts {
+		if h.Name == name {
+			host = h
+		}
+	}
+	if host == nil {
+		return nil
+	}
+
+	now := m.now()
+	observed := ObservedState(status)
+	host.LastStatus = status
+	host.LastChecked = now
+
+	// The first check sets the state directly, only problems are announced
+	if host.State == StateUnknown {
+		host.State = observed
+		host.Since = now
+		host.notified = observed
+		if observed == StateUp {
+			return nil
+		}
+		return m.event(host, StateUnknown, status, now)
+	}
+
+	if observed == host.State {
+		host.pending, host.pendingCount = "", 0
+	} else {
+		if observed == host.pending {
+			host.pendingCount++
+		} else {
+			host.pending, host.pendingCount = observed, 1
+		}
+
+		threshold := m.FailThreshold
+		if observed == StateUp {
+			threshold = m.RecoverThreshold
+		}
+		if host.pendingCount >= threshold {
+			host.State = observed
+			host.Since = now
+			host.pending, host.pendingCount = "", 0
+			host.transitions = append(host.transitions, now)
+		}
+	}
+
+	// Flap damping, only transitions within the window count
+	recent := host.transitions[:0]
+	for _, t := range host.transitions {
+		if now.Sub(t) < m.FlapWindow {
+			recent = append(recent, t)
+		}
+	}
+	host.transitions = recent
+
+	wasFlapping := host.Flapping
+	host.Flapping = m.FlapThreshold > 0 && len(host.transitions) >= m.FlapThreshold
+
+	switch {
+	case host.Flapping && !wasFlapping:
+		event := m.event(host, host.notified, status, now)
+		host.notified = host.State
+		return event
+	case host.Flapping:
+		return nil
+	case host.State != host.notified:
+		event := m.event(host, host.notified, status, now)
+		host.notified = host.State
+		return event
+	}
+	return nil
+}
+
+func (m *Monitor) event(host *HostStatus, previous HostState, status *ServerStatus, now time.Time) *Event {
+	event := &Event{
+		Host:     host.Name,
+		Previous: previous,
+		Current:  host.State,
+		Flapping: host.Flapping,
+		Time:     now,
+		Status:   status,
+	}
+
+	switch {
+	case host.Flapping:
+		event.Message = fmt.Sprintf("%s is flapping, now %s, notifications held until it is stable", host.Name, host.State)
+	case status.Error != nil:
+		event.Message = fmt.Sprintf("%s is %s: %v", host.Name, host.State, status.Error)
+	case host.State != StateUp:
+		event.Message = fmt.Sprintf("%s is %s: %s", host.Name, host.State, findingSummary(status.Findings))
+	default:
+		event.Message = fmt.Sprintf("%s is %s", host.Name, host.State)
+	}
+	return event
+}
+
+// Method CheckOnce checks every host that is due concurrently and sends the resulting notifications
+func (m *Monitor) CheckOnce() []*Event {
+	now := m.now()
+
+	m.mu.Lock()
+	var hosts []*HostStatus
+	for _, h := range m.hosts {
+		if now.Before(h.nextCheck) {
+			continue
+		}
+		interval := h.interval
+		if interval <= 0 {
+			interval = m.Interval
+		}
+		h.nextCheck = now.Add(interval)
+		hosts = append(hosts, h)
+	}
+	m.mu.Unlock()
+
+	results := make([]*ServerStatus, len(hosts))
+	var wg sync.WaitGroup
+	for i, h := range hosts {
+		wg.Add(1)
+		go func(i int, checker StatusChecker) {
+			defer wg.Done()
+			results[i] = checker.CheckServer()
+		}(i, h.checker)
+	}
+	wg.Wait()
+
+	var events []*Event
+	for i, h := range hosts {
+		if event := m.Observe(h.Name, results[i]); event != nil {
+			events = append(events, event)
+		}
+	}
+
+	for _, event := range events {
+		fmt.Printf("[%s] %s -> %s: %s\n", event.Time.Format(time.RFC3339), event.Previous, event.Current, event.Message)
+		m.notify(event)
+	}
+	return events
+}
+
+// notify sends the event to every notifier, a failing notifier does not stop the others
+func (m *Monitor) notify(event *Event) {
+	for _, n := range m.Notifiers {
+		if err := n.Notify(event); err != nil {
+			fmt.Printf("Notification via %s failed: %v\n", n.Name(), err)
+		}
+	}
+}
+
+// Method Run checks all hosts every Interval until the context is cancelled
+func (m *Monitor) Run(ctx context.Context) error {
+	if m.ListenAddr != "" {
+		server := &http.Server{Addr: m.ListenAddr, Handler: m.Handler()}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fmt.Printf("Status page failed: %v\n", err)
+			}
+		}()
+		defer server.Close()
+		fmt.Printf("Status page listening on %s\n", m.ListenAddr)
+	}
+
+	tick := m.tick()
+	for {
+		m.CheckOnce()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(tick):
+		}
+	}
+}
+
+// Method Handler serves the status page on "/" and the host states as JSON on "/status.json"
+func (m *Monitor) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/status.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(m.Hosts())
+	})
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		statusPage.Execute(w, m.Hosts())
+	})
+
+	return mux
+}
+
+var statusPage = template.Must(template.New("status").Funcs(template.FuncMap{
+	"ago": func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return time.Since(t).Round(time.Second).String() + " ago"
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>Server Status</title>
+<meta http-equiv="refresh" content="30">
+<style>
+body { font-family: sans-serif; }
+td, th { padding: 4px 12px; text-align: left; }
+.UP { color: green; } .DEGRADED { color: orange; } .DOWN { color: red; } .UNKNOWN { color: gray; }
+</style>
+</head>
+<body>
+<h1>Server Status</h1>
+<table>
+<tr><th>Host</th><th>State</th><th>Since</th><th>Last Check</th><th>Details</th></tr>
+{{range .}}<tr>
+<td>{{.Name}}</td>
+<td class="{{.State}}">{{.State}}{{if .Flapping}} (flapping){{end}}</td>
+<td>{{ago .Since}}</td>
+<td>{{ago .LastChecked}}</td>
+<td>{{with .LastStatus}}{{if .Error}}{{.Error}}{{else}}{{.StatusCode}} in {{.ResponseTime}}{{end}}{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,250 @@
+package securecom
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	checkUp       = &ServerStatus{IsAlive: true, StatusCode: 200}
+	checkDegraded = &ServerStatus{IsAlive: true, StatusCode: 200, Findings: []Finding{{Name: "cert-expiry", Severity: SeverityWarning}}}
+	checkDown     = &ServerStatus{Error: errors.New("connection refused")}
+)
+
+// fakeChecker returns a fixed result
+type fakeChecker struct {
+	status *ServerStatus
+}
+
+func (f *fakeChecker) CheckServer() *ServerStatus {
+	return f.status
+}
+
+// newTestMonitor creates a monitor with a clock advancing one minute per check
+func newTestMonitor() *Monitor {
+	m := NewMonitor(time.Minute)
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	return m
+}
+
+// TestObservedState tests classification of single check results
+func TestObservedState(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   *ServerStatus
+		expected HostState
+	}{
+		{"Healthy", checkUp, StateUp},
+		{"Warning finding", checkDegraded, StateDegraded},
+		{"Wrong status", &ServerStatus{IsAlive: true, StatusCode: 500, ExpectedStatus: 200}, StateDegraded},
+		{"Unreachable", checkDown, StateDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ObservedState(tt.status); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestMonitorThresholds tests that states change only after consecutive checks agree
+func TestMonitorThresholds(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []*ServerStatus
+		states []HostState
+		events []HostState
+	}{
+		{
+			name:   "Up at start is not announced",
+			checks: []*ServerStatus{checkUp, checkUp},
+			states: []HostState{StateUp, StateUp},
+		},
+		{
+			name:   "Down at start is announced",
+			checks: []*ServerStatus{checkDown},
+			states: []HostState{StateDown},
+			events: []HostState{StateDown},
+		},
+		{
+			name:   "Single failure is ignored",
+			checks: []*ServerStatus{checkUp, checkDown, checkDown, checkUp},
+			states: []HostState{StateUp, StateUp, StateUp, StateUp},
+		},
+		{
+			name:   "Down after three failures, up after two good checks",
+			checks: []*ServerStatus{checkUp, checkDown, checkDown, checkDown, checkUp, checkUp},
+			states: []HostState{StateUp, StateUp, StateUp, StateDown, StateDown, StateUp},
+			events: []HostState{StateDown, StateUp},
+		},
+		{
+			name:   "Degraded",
+			checks: []*ServerStatus{checkUp, checkDegraded, checkDegraded, checkDegraded},
+			states: []HostState{StateUp, StateUp, StateUp, StateDegraded},
+			events: []HostState{StateDegraded},
+		},
+		{
+			name:   "Mixed failures restart the count",
+			checks: []*ServerStatus{checkUp, checkDown, checkDegraded, checkDown, checkDown},
+			states: []HostState{StateUp, StateUp, StateUp, StateUp, StateUp},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMonitor()
+			m.Add("web", &fakeChecker{})
+
+			var events []HostState
+			for i, status := range tt.checks {
+				if event := m.Observe("web", status); event != nil {
+					events = append(events, event.Current)
+				}
+				if state := m.Hosts()[0].State; state != tt.states[i] {
+					t.Errorf("Check %d: expected %s, got %s", i, tt.states[i], state)
+				}
+			}
+
+			if strings.Join(stateNames(events), ",") != strings.Join(stateNames(tt.events), ",") {
+				t.Errorf("Expected events %v, got %v", tt.events, events)
+			}
+		})
+	}
+}
+
+func stateNames(states []HostState) []string {
+	var names []string
+	for _, s := range states {
+		names = append(names, string(s))
+	}
+	return names
+}
+
+// TestMonitorFlapDamping tests that a flapping host sends one notification until it settles
+func TestMonitorFlapDamping(t *testing.T) {
+	m := newTestMonitor()
+	m.FailThreshold = 1
+	m.RecoverThreshold = 1
+	m.FlapThreshold = 3
+	m.FlapWindow = 5 * time.Minute
+	m.Add("web", &fakeChecker{})
+
+	var events []*Event
+	for _, status := range []*ServerStatus{checkUp, checkDown, checkUp, checkDown, checkUp} {
+		if event := m.Observe("web", status); event != nil {
+			events = append(events, event)
+		}
+	}
+
+	// DOWN, UP, then flapping is detected on the third transition and later ones are held back
+	if len(events) != 3 || !events[2].Flapping || events[1].Flapping {
+		t.Fatalf("Expected two transitions and one flapping event, got %+v", events)
+	}
+	if !m.Hosts()[0].Flapping {
+		t.Errorf("Expected host to be flapping")
+	}
+
+	// Staying up lets the transitions age out of the window, the held back recovery is then sent
+	var settled *Event
+	for i := 0; i < 5 && settled == nil; i++ {
+		settled = m.Observe("web", checkUp)
+	}
+	if settled == nil || settled.Flapping || settled.Previous != StateDown || settled.Current != StateUp {
+		t.Errorf("Expected a DOWN to UP event once the host settled, got %+v", settled)
+	}
+}
+
+// recordingNotifier keeps every event it receives
+type recordingNotifier struct {
+	events []*Event
+}
+
+func (r *recordingNotifier) Name() string {
+	return "recorder"
+}
+
+func (r *recordingNotifier) Notify(event *Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+// TestMonitorCheckOnce tests checking all hosts and notifying their transitions
+func TestMonitorCheckOnce(t *testing.T) {
+	m := newTestMonitor()
+	m.FailThreshold = 1
+	recorder := &recordingNotifier{}
+	m.Notifiers = []Notifier{recorder}
+
+	api := &fakeChecker{status: checkUp}
+	m.Add("api", api)
+	m.Add("www", &fakeChecker{status: checkUp})
+
+	if events := m.CheckOnce(); len(events) != 0 {
+		t.Errorf("Expected no events for healthy hosts, got %+v", events)
+	}
+
+	api.status = checkDown
+	m.CheckOnce()
+
+	if len(recorder.events) != 1 || recorder.events[0].Host != "api" || recorder.events[0].Current != StateDown {
+		t.Fatalf("Expected api DOWN notification, got %+v", recorder.events)
+	}
+	if !strings.Contains(recorder.events[0].Message, "connection refused") {
+		t.Errorf("Expected the error in the message, got %q", recorder.events[0].Message)
+	}
+}
+
+// TestMonitorHandler tests the status page and JSON endpoint
+func TestMonitorHandler(t *testing.T) {
+	m := newTestMonitor()
+	m.Add("api", &fakeChecker{})
+	m.Add("www", &fakeChecker{})
+	m.Observe("api", checkUp)
+	m.Observe("www", checkDown)
+
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/status.json")
+	if err != nil {
+		t.Fatalf("Failed to fetch status: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var hosts []struct {
+		Name       string    `json:"name"`
+		State      HostState `json:"state"`
+		LastStatus struct {
+			Error string `json:"error"`
+		} `json:"last_status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&hosts); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if len(hosts) != 2 || hosts[0].State != StateUp || hosts[1].State != StateDown || hosts[1].LastStatus.Error != "connection refused" {
+		t.Errorf("Unexpected status: %+v", hosts)
+	}
+
+	page, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("Failed to fetch status page: %v", err)
+	}
+	defer page.Body.Close()
+
+	body, _ := io.ReadAll(page.Body)
+	if !strings.Contains(string(body), `<td class="DOWN">DOWN</td>`) || !strings.Contains(string(body), "connection refused") {
+		t.Errorf("Status page is missing host details:\n%s", body)
+	}
+}
@@ -0,0 +1,167 @@
+package securecom
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Notifier delivers monitor events
+type Notifier interface {
+	Name() string
+	Notify(event *Event) error
+}
+
+// WebhookNotifier posts each event as JSON to a URL
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier creates a webhook notifier with a request timeout
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (n *WebhookNotifier) Name() string {
+	return "webhook " + n.URL
+}
+
+// Method Notify posts the event and fails on a non-2xx response
+func (n *WebhookNotifier) Notify(event *Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+
+	resp, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to post event: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SMTPNotifier mails each event, authenticating with PLAIN auth when a username is set
+type SMTPNotifier struct {
+	Addr     string
+	From     string
+	To       []string
+	Username string
+	Password string
+
+	// sendMail is smtp.SendMail unless replaced in tests
+	sendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPNotifier creates a notifier sending through the server at addr, given as host:port
+func NewSMTPNotifier(addr, from string, to []string) *SMTPNotifier {
+	return &SMTPNotifier{
+		Addr:     addr,
+		From:     from,
+		To:       to,
+		sendMail: smtp.SendMail,
+	}
+}
+
+func (n *SMTPNotifier) Name() string {
+	return "smtp " + n.Addr
+}
+
+// Method Notify sends the event as a plain text mail
+func (n *SMTPNotifier) Notify(event *Event) error {
+	var auth smtp.Auth
+	if n.Username != "" {
+		host, _, err := net.SplitHostPort(n.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address %s: %v", n.Addr, err)
+		}
+		auth = smtp.PlainAuth("", n.Username, n.Password, host)
+	}
+
+	if err := n.sendMail(n.Addr, auth, n.From, n.To, n.message(event)); err != nil {
+		return fmt.Errorf("failed to send mail: %v", err)
+	}
+	return nil
+}
+
+func (n *SMTPNotifier) message(event *Event) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", n.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&b, "Subject: [%s] %s\r\n", event.Current, event.Host)
+	fmt.Fprintf(&b, "Date: %s\r\n", event.Time.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+
+	fmt.Fprintf(&b, "%s\r\n\r\n", event.Message)
+	fmt.Fprintf(&b, "Host:     %s\r\n", event.Host)
+	fmt.Fprintf(&b, "State:    %s -> %s\r\n", event.Previous, event.Current)
+	fmt.Fprintf(&b, "Time:     %s\r\n", event.Time.Format(time.RFC3339))
+	if event.Status != nil && event.Status.IsAlive {
+		fmt.Fprintf(&b, "Status:   %d in %v\r\n", event.Status.StatusCode, event.Status.ResponseTime)
+	}
+
+	return []byte(b.String())
+}
+
+// CommandNotifier runs a local shell command for each event
+// The event is passed as JSON on stdin and summarized in MONITOR_* environment variables
+type CommandNotifier struct {
+	Command string
+	Timeout time.Duration
+}
+
+// NewCommandNotifier creates a command hook that is killed after the timeout, zero means no timeout
+func NewCommandNotifier(command string, timeout time.Duration) *CommandNotifier {
+	return &CommandNotifier{Command: command, Timeout: timeout}
+}
+
+func (n *CommandNotifier) Name() string {
+	return "command " + n.Command
+}
+
+// Method Notify runs the command and fails when it exits with an error
+func (n *CommandNotifier) Notify(event *Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+
+	ctx := context.Background()
+	if n.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", n.Command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"MONITOR_HOST="+event.Host,
+		"MONITOR_PREVIOUS_STATE="+string(event.Previous),
+		"MONITOR_STATE="+string(event.Current),
+		fmt.Sprintf("MONITOR_FLAPPING=%t", event.Flapping),
+		"MONITOR_MESSAGE="+event.Message,
+	)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("command failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
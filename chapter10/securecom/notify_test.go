@@ -0,0 +1,136 @@
+package securecom
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// receivedEvent decodes the event fields a receiver relies on
+type receivedEvent struct {
+	Host     string    `json:"host"`
+	Previous HostState `json:"previous"`
+	Current  HostState `json:"current"`
+	Status   struct {
+		Error string `json:"error"`
+	} `json:"status"`
+}
+
+func testEvent() *Event {
+	return &Event{
+		Host:     "https://api.example.com/",
+		Previous: StateUp,
+		Current:  StateDown,
+		Message:  "https://api.example.com/ is DOWN: connection refused",
+		Time:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Status:   checkDown,
+	}
+}
+
+// TestWebhookNotifier tests posting events as JSON
+func TestWebhookNotifier(t *testing.T) {
+	var received receivedEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode event: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	if err := NewWebhookNotifier(server.URL, 5*time.Second).Notify(testEvent()); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if received.Host != "https://api.example.com/" || received.Previous != StateUp || received.Current != StateDown ||
+		received.Status.Error != "connection refused" {
+		t.Errorf("Unexpected event received: %+v", received)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	if err := NewWebhookNotifier(failing.URL, 5*time.Second).Notify(testEvent()); err == nil {
+		t.Errorf("Expected error for status 500")
+	}
+}
+
+// TestSMTPNotifier tests the mail sent for an event
+func TestSMTPNotifier(t *testing.T) {
+	notifier := NewSMTPNotifier("mail.example.com:587", "monitor@example.com", []string{"noc@example.com", "oncall@example.com"})
+	notifier.Username = "monitor"
+	notifier.Password = "secret"
+
+	var sentTo []string
+	var message string
+	var authSet bool
+	notifier.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		sentTo = to
+		message = string(msg)
+		authSet = auth != nil
+		return nil
+	}
+
+	if err := notifier.Notify(testEvent()); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	if len(sentTo) != 2 || !authSet {
+		t.Errorf("Expected mail to 2 recipients with auth, got %v, auth %v", sentTo, authSet)
+	}
+	for _, expected := range []string{
+		"Subject: [DOWN] https://api.example.com/\r\n",
+		"To: noc@example.com, oncall@example.com\r\n",
+		"State:    UP -> DOWN\r\n",
+		"connection refused",
+	} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("Expected message to contain %q, got:\n%s", expected, message)
+		}
+	}
+}
+
+// TestCommandNotifier tests the local command hook
+func TestCommandNotifier(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "event.json")
+
+	notifier := NewCommandNotifier(`cat > "$OUT" && echo "$MONITOR_HOST $MONITOR_PREVIOUS_STATE $MONITOR_STATE" >> "$OUT.env"`, 5*time.Second)
+	t.Setenv("OUT", output)
+
+	if err := notifier.Notify(testEvent()); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Command did not write the event: %v", err)
+	}
+	var event receivedEvent
+	if err := json.Unmarshal(data, &event); err != nil || event.Current != StateDown {
+		t.Errorf("Unexpected event on stdin: %s (%v)", data, err)
+	}
+
+	env, _ := os.ReadFile(output + ".env")
+	if strings.TrimSpace(string(env)) != "https://api.example.com/ UP DOWN" {
+		t.Errorf("Unexpected environment: %q", env)
+	}
+
+	if err := NewCommandNotifier("exit 3", 5*time.Second).Notify(testEvent()); err == nil {
+		t.Errorf("Expected error for failing command")
+	}
+
+	// A zero timeout, as -timeout 0 produces, must not kill the command
+	if err := NewCommandNotifier("sleep 0.1", 0).Notify(testEvent()); err != nil {
+		t.Errorf("Expected command without timeout to finish, got %v", err)
+	}
+}
@@ -0,0 +1,344 @@
+package securecom
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Nagios compatible exit codes
+const (
+	ExitOK       = 0
+	ExitWarning  = 1
+	ExitCritical = 2
+	ExitUnknown  = 3
+)
+
+// OutputFormat selects how check results are reported
+type OutputFormat string
+
+const (
+	OutputText       OutputFormat = "text"
+	OutputJSON       OutputFormat = "json"
+	OutputJUnit      OutputFormat = "junit"
+	OutputPrometheus OutputFormat = "prometheus-textfile"
+)
+
+// ParseOutputFormat validates an output format name
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	switch format := OutputFormat(strings.ToLower(name)); format {
+	case OutputText, OutputJSON, OutputJUnit, OutputPrometheus:
+		return format, nil
+	case "":
+		return OutputText, nil
+	default:
+		return "", fmt.Errorf("unknown output format %s, expected text, json, junit or prometheus-textfile", name)
+	}
+}
+
+// Output describes where one-shot results are written
+type Output struct {
+	Format OutputFormat
+	// File is replaced atomically when set, otherwise results go to standard output
+	File string
+}
+
+// Method progress returns where progress lines are printed, kept off standard output
+// when it carries machine-readable results
+func (o *Output) progress() io.Writer {
+	if o == nil || o.Format == OutputText || o.File != "" {
+		return os.Stdout
+	}
+	return os.Stderr
+}
+
+// ResultExitCode grades a single result: critical when down or unhealthy,
+// warning for warning findings such as an unexpected error status code
+func ResultExitCode(status *ServerStatus) int {
+	switch {
+	case !status.Healthy():
+		return ExitCritical
+	case WorstSeverity(status.Findings) == SeverityWarning:
+		return ExitWarning
+	default:
+		return ExitOK
+	}
+}
+
+// ExitCode returns the worst exit code of all results, unknown when nothing was checked
+func ExitCode(results []*ServerStatus) int {
+	if len(results) == 0 {
+		return ExitUnknown
+	}
+
+	code := ExitOK
+	for _, result := range results {
+		if c := ResultExitCode(result); c > code {
+			code = c
+		}
+	}
+	return code
+}
+
+// ExitCodeName returns the Nagios state name of an exit code
+func ExitCodeName(code int) string {
+	switch code {
+	case ExitOK:
+		return "OK"
+	case ExitWarning:
+		return "WARNING"
+	case ExitCritical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// WriteResults writes the results in a machine-readable format
+func WriteResults(w io.Writer, format OutputFormat, results []*ServerStatus) error {
+	switch format {
+	case OutputJSON:
+		return writeJSON(w, results)
+	case OutputJUnit:
+		return writeJUnit(w, results)
+	case OutputPrometheus:
+		return writePrometheus(w, results)
+	default:
+		return fmt.Errorf("output format %s is not machine-readable", format)
+	}
+}
+
+// WriteResultsFile writes the results to a temporary file renamed over filename,
+// so collectors such as the node_exporter textfile collector never read a partial file
+func WriteResultsFile(filename string, format OutputFormat, results []*ServerStatus) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := WriteResults(tmp, format, results); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to write output file: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, results []*ServerStatus) error {
+	code := ExitCode(results)
+	report := struct {
+		State    string          `json:"state"`
+		ExitCode int             `json:"exit_code"`
+		Results  []*ServerStatus `json:"results"`
+	}{
+		State:    ExitCodeName(code),
+		ExitCode: code,
+		Results:  results,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      float64         `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit reports each result as a test case, unhealthy results as failures
+func writeJUnit(w io.Writer, results []*ServerStatus) error {
+	suite := junitTestSuite{
+		Name:      "securecom",
+		Tests:     len(results),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	for _, result := range results {
+		tc := junitTestCase{
+			Name:      result.URL,
+			ClassName: result.Hostname,
+			Time:      result.ResponseTime.Seconds(),
+		}
+		suite.Time += tc.Time
+
+		var details []string
+		for _, f := range result.Findings {
+			details = append(details, fmt.Sprintf("[%s] %s: %s", f.Severity, f.Name, f.Message))
+		}
+
+		if !result.Healthy() {
+			suite.Failures++
+			tc.Failure = &junitFailure{Type: "CRITICAL", Text: strings.Join(details, "\n")}
+			if result.Error != nil {
+				tc.Failure.Message = result.Error.Error()
+			} else {
+				tc.Failure.Message = findingSummary(result.Findings)
+			}
+		} else {
+			tc.SystemOut = strings.Join(details, "\n")
+		}
+
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writePrometheus writes the text exposition format read by the node_exporter textfile collector
+func writePrometheus(w io.Writer, results []*ServerStatus) error {
+	var b strings.Builder
+
+	metric := func(name, help, kind string, values func(result *ServerStatus) []string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, result := range results {
+			for _, line := range values(result) {
+				fmt.Fprintf(&b, "%s%s\n", name, line)
+			}
+		}
+	}
+	checks := seriesChecks(results)
+	labels := func(result *ServerStatus, extra ...string) string {
+		pairs := []string{
+			fmt.Sprintf(`target="%s"`, labelEscaper.Replace(result.Hostname)),
+			fmt.Sprintf(`url="%s"`, labelEscaper.Replace(result.URL)),
+			fmt.Sprintf(`probe="%s"`, labelEscaper.Replace(result.Probe)),
+			fmt.Sprintf(`check="%s"`, labelEscaper.Replace(checks[result])),
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], labelEscaper.Replace(extra[i+1])))
+		}
+		return "{" + strings.Join(pairs, ",") + "}"
+	}
+
+	metric("securecom_check_up", "Whether the server answered the check.", "gauge", func(r *ServerStatus) []string {
+		return []string{fmt.Sprintf("%s %d", labels(r), boolValue(r.IsAlive))}
+	})
+	metric("securecom_check_healthy", "Whether the check passed all assertions and TLS policy checks.", "gauge", func(r *ServerStatus) []string {
+		return []string{fmt.Sprintf("%s %d", labels(r), boolValue(r.Healthy()))}
+	})
+	metric("securecom_check_state", "Nagios state of the check, 0 OK, 1 WARNING, 2 CRITICAL.", "gauge", func(r *ServerStatus) []string {
+		return []string{fmt.Sprintf("%s %d", labels(r), ResultExitCode(r))}
+	})
+	metric("securecom_check_status_code", "HTTP status code of the response.", "gauge", func(r *ServerStatus) []string {
+		if !r.IsAlive {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s %d", labels(r), r.StatusCode)}
+	})
+	metric("securecom_check_duration_seconds", "Duration of each phase of the request.", "gauge", func(r *ServerStatus) []string {
+		t := r.Timing
+		phases := []struct {
+			name     string
+			duration time.Duration
+		}{
+			{"dns", t.DNSLookup},
+			{"connect", t.TCPConnect},
+			{"tls", t.TLSHandshake},
+			{"processing", t.ServerProcessing},
+			{"transfer", t.ContentTransfer},
+			{"total", r.ResponseTime},
+		}
+		var lines []string
+		for _, p := range phases {
+			lines = append(lines, fmt.Sprintf("%s %g", labels(r, "phase", p.name), p.duration.Seconds()))
+		}
+		return lines
+	})
+	metric("securecom_check_cert_expiry_timestamp_seconds", "Expiry of the server certificate as a Unix timestamp.", "gauge", func(r *ServerStatus) []string {
+		if r.CertExpiry.IsZero() {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s %d", labels(r), r.CertExpiry.Unix())}
+	})
+	metric("securecom_check_findings", "Number of findings by severity.", "gauge", func(r *ServerStatus) []string {
+		counts := make(map[Severity]int)
+		for _, f := range r.Findings {
+			counts[f.Severity]++
+		}
+		var lines []string
+		for _, s := range []Severity{SeverityOK, SeverityWarning, SeverityCritical} {
+			lines = append(lines, fmt.Sprintf("%s %d", labels(r, "severity", string(s)), counts[s]))
+		}
+		return lines
+	})
+	metric("securecom_check_timestamp_seconds", "Time of the check as a Unix timestamp.", "gauge", func(r *ServerStatus) []string {
+		return []string{fmt.Sprintf("%s %d", labels(r), r.CheckTimestamp.Unix())}
+	})
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// seriesChecks returns the check label of every result, unique across the file as node_exporter
+// rejects a file with duplicate series; repeated names, such as two checks of one URL run
+// without a checks file, get a "#2", "#3" suffix
+func seriesChecks(results []*ServerStatus) map[*ServerStatus]string {
+	checks := make(map[*ServerStatus]string, len(results))
+	used := make(map[string]bool, len(results))
+	for _, result := range results {
+		base := result.Check
+		if base == "" {
+			base = result.URL
+		}
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s#%d", base, n)
+		}
+		used[name] = true
+		checks[result] = name
+	}
+	return checks
+}
+
+// labelEscaper escapes label values as required by the exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func boolValue(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
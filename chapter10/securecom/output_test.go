@@ -0,0 +1,231 @@
+package securecom
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testResults() []*ServerStatus {
+	checked := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	return []*ServerStatus{
+		{
+			Probe:          "https",
+			Check:          "api",
+			Hostname:       "api.example.com",
+			URL:            "https://api.example.com/",
+			IsAlive:        true,
+			StatusCode:     200,
+			ResponseTime:   150 * time.Millisecond,
+			CertExpiry:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			Findings:       []Finding{{Name: "cert-expiry", Severity: SeverityWarning, Message: "certificate expires in 20 days"}},
+			Timing:         Timing{DNSLookup: 5 * time.Millisecond, TLSHandshake: 30 * time.Millisecond},
+			CheckTimestamp: checked,
+		},
+		{
+			Probe:          "https",
+			Hostname:       "www.example.com",
+			URL:            "https://www.example.com/",
+			Error:          errors.New("connection refused"),
+			CheckTimestamp: checked,
+		},
+	}
+}
+
+// TestExitCode tests Nagios exit codes derived from check results
+func TestExitCode(t *testing.T) {
+	ok := &ServerStatus{IsAlive: true, StatusCode: 200}
+	warning := &ServerStatus{IsAlive: true, StatusCode: 200, Findings: []Finding{{Severity: SeverityWarning}}}
+	notFound := &ServerStatus{IsAlive: true, StatusCode: 404, Findings: []Finding{{Name: "status", Severity: SeverityWarning}}}
+	expectedNotFound := &ServerStatus{IsAlive: true, StatusCode: 404, ExpectedStatus: 404,
+		Findings: []Finding{{Name: "status", Severity: SeverityOK}}}
+	failed := &ServerStatus{IsAlive: true, StatusCode: 200, Findings: []Finding{{Severity: SeverityCritical}}}
+	down := &ServerStatus{Error: errors.New("timeout")}
+
+	tests := []struct {
+		name     string
+		results  []*ServerStatus
+		expected int
+	}{
+		{"No results", nil, ExitUnknown},
+		{"All ok", []*ServerStatus{ok, ok}, ExitOK},
+		{"Warning finding", []*ServerStatus{ok, warning}, ExitWarning},
+		{"Error status code", []*ServerStatus{notFound}, ExitWarning},
+		{"Expected error status code", []*ServerStatus{expectedNotFound}, ExitOK},
+		{"Critical finding", []*ServerStatus{warning, failed}, ExitCritical},
+		{"Down", []*ServerStatus{down, ok}, ExitCritical},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ExitCode(tt.results); code != tt.expected {
+				t.Errorf("Expected %s, got %s", ExitCodeName(tt.expected), ExitCodeName(code))
+			}
+		})
+	}
+}
+
+// TestParseOutputFormat tests output format names
+func TestParseOutputFormat(t *testing.T) {
+	for _, name := range []string{"text", "JSON", "junit", "prometheus-textfile"} {
+		if _, err := ParseOutputFormat(name); err != nil {
+			t.Errorf("Unexpected error for %s: %v", name, err)
+		}
+	}
+	if _, err := ParseOutputFormat("yaml"); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+}
+
+// TestWriteResultsJSON tests the JSON report
+func TestWriteResultsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteResults(&buf, OutputJSON, testResults()); err != nil {
+		t.Fatalf("WriteResults failed: %v", err)
+	}
+
+	var report struct {
+		State    string `json:"state"`
+		ExitCode int    `json:"exit_code"`
+		Results  []struct {
+			URL      string    `json:"url"`
+			Error    string    `json:"error"`
+			Findings []Finding `json:"findings"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, buf.String())
+	}
+
+	if report.State != "CRITICAL" || report.ExitCode != ExitCritical || len(report.Results) != 2 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if report.Results[0].Findings[0].Severity != SeverityWarning || report.Results[1].Error != "connection refused" {
+		t.Errorf("Unexpected results: %+v", report.Results)
+	}
+}
+
+// TestWriteResultsJUnit tests the JUnit report
+func TestWriteResultsJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteResults(&buf, OutputJUnit, testResults()); err != nil {
+		t.Fatalf("WriteResults failed: %v", err)
+	}
+
+	var suite junitTestSuite
+	if err := xml.Unmarshal(buf.Bytes(), &suite); err != nil {
+		t.Fatalf("Invalid XML: %v\n%s", err, buf.String())
+	}
+
+	if suite.Tests != 2 || suite.Failures != 1 || len(suite.TestCases) != 2 {
+		t.Fatalf("Unexpected suite: %+v", suite)
+	}
+	if suite.TestCases[0].Failure != nil || !strings.Contains(suite.TestCases[0].SystemOut, "cert-expiry") {
+		t.Errorf("Expected passing test case with findings, got %+v", suite.TestCases[0])
+	}
+	if suite.TestCases[1].Failure == nil || suite.TestCases[1].Failure.Message != "connection refused" {
+		t.Errorf("Expected failing test case, got %+v", suite.TestCases[1])
+	}
+}
+
+// TestWriteResultsPrometheus tests the textfile collector format
+func TestWriteResultsPrometheus(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteResults(&buf, OutputPrometheus, testResults()); err != nil {
+		t.Fatalf("WriteResults failed: %v", err)
+	}
+	text := buf.String()
+
+	expected := []string{
+		"# TYPE securecom_check_up gauge",
+		`securecom_check_up{target="api.example.com",url="https://api.example.com/",probe="https",check="api"} 1`,
+		`securecom_check_up{target="www.example.com",url="https://www.example.com/",probe="https",check="https://www.example.com/"} 0`,
+		`securecom_check_state{target="api.example.com",url="https://api.example.com/",probe="https",check="api"} 1`,
+		`securecom_check_state{target="www.example.com",url="https://www.example.com/",probe="https",check="https://www.example.com/"} 2`,
+		`securecom_check_duration_seconds{target="api.example.com",url="https://api.example.com/",probe="https",check="api",phase="tls"} 0.03`,
+		`securecom_check_duration_seconds{target="api.example.com",url="https://api.example.com/",probe="https",check="api",phase="total"} 0.15`,
+		`securecom_check_cert_expiry_timestamp_seconds{target="api.example.com",url="https://api.example.com/",probe="https",check="api"} 1709251200`,
+		`securecom_check_findings{target="api.example.com",url="https://api.example.com/",probe="https",check="api",severity="warning"} 1`,
+		`securecom_check_timestamp_seconds{target="www.example.com",url="https://www.example.com/",probe="https",check="https://www.example.com/"} 1704110400`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("Expected line %q in:\n%s", line, text)
+		}
+	}
+
+	if strings.Contains(text, `securecom_check_status_code{target="www.example.com"`) {
+		t.Errorf("Expected no status code for an unreachable server")
+	}
+}
+
+// TestWriteResultsPrometheusDuplicates tests that checks of the same URL produce distinct series
+func TestWriteResultsPrometheusDuplicates(t *testing.T) {
+	results := []*ServerStatus{
+		{Probe: "https", Check: "head", Hostname: "api.example.com", URL: "https://api.example.com/", IsAlive: true, StatusCode: 200},
+		{Probe: "https", Check: "get", Hostname: "api.example.com", URL: "https://api.example.com/", IsAlive: true, StatusCode: 200},
+		{Probe: "tcp", Hostname: "api.example.com", URL: "api.example.com:443", IsAlive: true},
+		{Probe: "tcp", Hostname: "api.example.com", URL: "api.example.com:443", IsAlive: true},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteResults(&buf, OutputPrometheus, results); err != nil {
+		t.Fatalf("WriteResults failed: %v", err)
+	}
+
+	series := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		name := line[:strings.LastIndex(line, " ")]
+		if series[name] {
+			t.Errorf("Duplicate series %s", name)
+		}
+		series[name] = true
+	}
+
+	for _, name := range []string{
+		`securecom_check_up{target="api.example.com",url="https://api.example.com/",probe="https",check="head"}`,
+		`securecom_check_up{target="api.example.com",url="https://api.example.com/",probe="https",check="get"}`,
+		`securecom_check_up{target="api.example.com",url="api.example.com:443",probe="tcp",check="api.example.com:443"}`,
+		`securecom_check_up{target="api.example.com",url="api.example.com:443",probe="tcp",check="api.example.com:443#2"}`,
+	} {
+		if !series[name] {
+			t.Errorf("Expected series %s", name)
+		}
+	}
+}
+
+// TestWriteResultsFile tests that the output file is replaced without leaving temporary files
+func TestWriteResultsFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "securecom.prom")
+
+	if err := os.WriteFile(filename, []byte("stale"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := WriteResultsFile(filename, OutputPrometheus, testResults()); err != nil {
+		t.Fatalf("WriteResultsFile failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(filename)
+	if !strings.HasPrefix(string(data), "# HELP securecom_check_up") {
+		t.Errorf("Unexpected file content:\n%s", data)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the output file, got %d entries", len(entries))
+	}
+
+	if err := WriteResultsFile(filepath.Join(dir, "out.txt"), OutputText, testResults()); err == nil {
+		t.Errorf("Expected error for text format")
+	}
+}
@@ -0,0 +1,487 @@
+package securecom
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// newProbeStatus creates the status every probe starts from
+func newProbeStatus(probe, hostname, url string) *ServerStatus {
+	return &ServerStatus{
+		Probe:          probe,
+		Hostname:       hostname,
+		URL:            url,
+		CheckTimestamp: time.Now(),
+	}
+}
+
+// TCPChecker checks that a TCP port accepts connections
+// Like every probe here, a zero Timeout means no timeout
+type TCPChecker struct {
+	Hostname string
+	Port     int
+	Timeout  time.Duration
+}
+
+// NewTCPChecker creates a new TCP connect checker
+func NewTCPChecker(hostname string, port int, timeout time.Duration) *TCPChecker {
+	return &TCPChecker{Hostname: hostname, Port: port, Timeout: timeout}
+}
+
+// Method CheckServer connects to the port and closes the connection again
+func (c *TCPChecker) CheckServer() *ServerStatus {
+	address := net.JoinHostPort(c.Hostname, strconv.Itoa(c.Port))
+	status := newProbeStatus("tcp", c.Hostname, "tcp://"+address)
+
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", address, c.Timeout)
+	status.ResponseTime = time.Since(start)
+	status.Timing = Timing{TCPConnect: status.ResponseTime, Total: status.ResponseTime}
+
+	if err != nil {
+		status.Error = err
+		return status
+	}
+	conn.Close()
+
+	status.IsAlive = true
+	return status
+}
+
+// DNSChecker resolves a name and optionally compares the answer with expected records
+type DNSChecker struct {
+	Name       string
+	RecordType string // A, AAAA, CNAME, MX, NS or TXT
+	// Expected records must all be in the answer, names compare without the trailing dot
+	Expected []string
+	// Resolver is the host:port of the DNS server to ask, the system resolver when empty
+	Resolver string
+	Timeout  time.Duration
+}
+
+// dnsRecordTypes lists the record types a DNSChecker can resolve
+var dnsRecordTypes = map[string]bool{"A": true, "AAAA": true, "CNAME": true, "MX": true, "NS": true, "TXT": true}
+
+// NewDNSChecker creates a checker resolving A records through the system resolver
+func NewDNSChecker(name string, timeout time.Duration) *DNSChecker {
+	return &DNSChecker{Name: name, RecordType: "A", Timeout: timeout}
+}
+
+// Method CheckServer resolves the name, the check fails when the lookup fails or a record is missing
+func (c *DNSChecker) CheckServer() *ServerStatus {
+	recordType := strings.ToUpper(c.RecordType)
+	if recordType == "" {
+		recordType = "A"
+	}
+	url := fmt.Sprintf("dns://%s/%s?type=%s", c.Resolver, c.Name, recordType)
+	status := newProbeStatus("dns", c.Name, url)
+
+	resolver := net.DefaultResolver
+	if c.Resolver != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, c.Resolver)
+			},
+		}
+	}
+
+	ctx, cancel := probeContext(c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	records, err := lookupRecords(ctx, resolver, recordType, c.Name)
+	status.ResponseTime = time.Since(start)
+	status.Timing = Timing{DNSLookup: status.ResponseTime, Total: status.ResponseTime}
+
+	if err != nil {
+		status.Error = err
+		return status
+	}
+	status.IsAlive = true
+
+	status.Findings = append(status.Findings, Finding{Name: "dns-answer", Severity: SeverityOK,
+		Message: fmt.Sprintf("%s %s: %s", c.Name, recordType, strings.Join(records, ", "))})
+
+	answer := make(map[string]bool)
+	for _, r := range records {
+		answer[normalizeRecord(r)] = true
+	}
+	for _, expected := range c.Expected {
+		if answer[normalizeRecord(expected)] {
+			status.Findings = append(status.Findings, Finding{Name: "dns-record", Severity: SeverityOK,
+				Message: fmt.Sprintf("%s found", expected)})
+		} else {
+			status.Findings = append(status.Findings, Finding{Name: "dns-record", Severity: SeverityCritical,
+				Message: fmt.Sprintf("%s missing from answer", expected)})
+		}
+	}
+
+	return status
+}
+
+// lookupRecords returns the answer of one record type as sorted strings
+func lookupRecords(ctx context.Context, resolver *net.Resolver, recordType, name string) ([]string, error) {
+	var records []string
+
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			records = append(records, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, cname)
+	case "MX":
+		mxs, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			records = append(records, mx.Host)
+		}
+	case "NS":
+		nss, err := resolver.LookupNS(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			records = append(records, ns.Host)
+		}
+	case "TXT":
+		txts, err := resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, txts...)
+	default:
+		return nil, fmt.Errorf("unsupported record type %s", recordType)
+	}
+
+	sort.Strings(records)
+	return records, nil
+}
+
+func normalizeRecord(record string) string {
+	return strings.ToLower(strings.TrimSuffix(record, "."))
+}
+
+// ICMPChecker sends ICMP echo requests over IPv4
+// It uses an unprivileged ping socket where the kernel allows one, otherwise a raw socket
+type ICMPChecker struct {
+	Hostname string
+	Count    int
+	Timeout  time.Duration // Per echo request, zero waits for each reply without limit
+}
+
+// NewICMPChecker creates a checker sending three echo requests
+func NewICMPChecker(hostname string, timeout time.Duration) *ICMPChecker {
+	return &ICMPChecker{Hostname: hostname, Count: 3, Timeout: timeout}
+}
+
+// Method CheckServer pings the host, it is alive when any reply arrives and packet loss is a warning
+func (c *ICMPChecker) CheckServer() *ServerStatus {
+	status := newProbeStatus("icmp", c.Hostname, "icmp://"+c.Hostname)
+
+	start := time.Now()
+	addr, err := net.ResolveIPAddr("ip4", c.Hostname)
+	status.Timing.DNSLookup = time.Since(start)
+	if err != nil {
+		status.Error = err
+		return status
+	}
+
+	// Ping sockets need net.ipv4.ping_group_range, raw sockets need CAP_NET_RAW
+	privileged := false
+	conn, err := icmp.ListenPacket("udp4", "0.0.0.0")
+	if err != nil {
+		privileged = true
+		conn, err = icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+		if err != nil {
+			status.Error = fmt.Errorf("failed to open ICMP socket: %v", err)
+			return status
+		}
+	}
+	defer conn.Close()
+
+	var target net.Addr = addr
+	if !privileged {
+		target = &net.UDPAddr{IP: addr.IP}
+	}
+
+	count := c.Count
+	if count < 1 {
+		count = 1
+	}
+	id := nextICMPID()
+
+	var received int
+	var total time.Duration
+	for seq := 1; seq <= count; seq++ {
+		rtt, err := ping(conn, target, id, seq, c.Timeout, privileged)
+		if err != nil {
+			status.Error = err
+			continue
+		}
+		received++
+		total += rtt
+	}
+
+	if received == 0 {
+		return status
+	}
+	status.Error = nil
+	status.IsAlive = true
+	status.ResponseTime = total / time.Duration(received)
+	status.Timing.Total = time.Since(start)
+
+	loss := 100 * (count - received) / count
+	finding := Finding{Name: "packet-loss", Severity: SeverityOK,
+		Message: fmt.Sprintf("%d/%d replies, %d%% loss, average %v", received, count, loss, status.ResponseTime)}
+	if loss > 0 {
+		finding.Severity = SeverityWarning
+	}
+	status.Findings = append(status.Findings, finding)
+
+	return status
+}
+
+// probeContext bounds a probe by its timeout, zero means no timeout as for net.DialTimeout
+func probeContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// icmpProbes numbers the probes of this process, so concurrent probes use distinct IDs
+var icmpProbes atomic.Uint32
+
+// nextICMPID returns the echo ID of a new probe, derived from the pid so other processes differ too
+func nextICMPID() int {
+	return (os.Getpid() + int(icmpProbes.Add(1))) & 0xffff
+}
+
+// ping sends one echo request and waits for its reply
+// Replies must come from the target and echo the sequence and payload of the request
+// Raw sockets see every ICMP packet so replies are also matched on ID, ping sockets rewrite the ID
+func ping(conn *icmp.PacketConn, target net.Addr, id, seq int, timeout time.Duration, matchID bool) (time.Duration, error) {
+	payload := []byte(fmt.Sprintf("securecom %d %d", id, seq))
+	request := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: payload},
+	}
+	data, err := request.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if _, err := conn.WriteTo(data, target); err != nil {
+		return 0, fmt.Errorf("failed to send echo request: %v", err)
+	}
+
+	if timeout > 0 {
+		conn.SetReadDeadline(start.Add(timeout))
+	} else {
+		conn.SetReadDeadline(time.Time{})
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, fmt.Errorf("no echo reply: %v", err)
+		}
+		if isEchoReply(buf[:n], peer, target, id, seq, payload, matchID) {
+			return time.Since(start), nil
+		}
+	}
+}
+
+// isEchoReply reports whether a received packet answers the echo request sent to target
+func isEchoReply(packet []byte, peer, target net.Addr, id, seq int, payload []byte, matchID bool) bool {
+	if !addrIP(peer).Equal(addrIP(target)) {
+		return false
+	}
+	reply, err := icmp.ParseMessage(1, packet)
+	if err != nil || reply.Type != ipv4.ICMPTypeEchoReply {
+		return false
+	}
+	echo, ok := reply.Body.(*icmp.Echo)
+	return ok && echo.Seq == seq && (!matchID || echo.ID == id) && bytes.Equal(echo.Data, payload)
+}
+
+// addrIP returns the IP of a raw or datagram socket address
+func addrIP(addr net.Addr) net.IP {
+	switch addr := addr.(type) {
+	case *net.IPAddr:
+		return addr.IP
+	case *net.UDPAddr:
+		return addr.IP
+	default:
+		return nil
+	}
+}
+
+// SSHBannerChecker reads the identification string an SSH server sends on connect
+type SSHBannerChecker struct {
+	Hostname string
+	Port     int
+	Timeout  time.Duration
+	// Expected must be contained in the banner when set, for example "OpenSSH_9"
+	Expected string
+}
+
+// NewSSHBannerChecker creates a new SSH banner checker
+func NewSSHBannerChecker(hostname string, port int, timeout time.Duration) *SSHBannerChecker {
+	return &SSHBannerChecker{Hostname: hostname, Port: port, Timeout: timeout}
+}
+
+// Method CheckServer connects and reads the banner without starting the key exchange
+func (c *SSHBannerChecker) CheckServer() *ServerStatus {
+	address := net.JoinHostPort(c.Hostname, strconv.Itoa(c.Port))
+	status := newProbeStatus("ssh", c.Hostname, "ssh://"+address)
+
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", address, c.Timeout)
+	if err != nil {
+		status.ResponseTime = time.Since(start)
+		status.Error = err
+		return status
+	}
+	defer conn.Close()
+	status.Timing.TCPConnect = time.Since(start)
+
+	// Servers may send other lines before the identification string
+	if c.Timeout > 0 {
+		conn.SetReadDeadline(start.Add(c.Timeout))
+	}
+	reader := bufio.NewReader(conn)
+	var banner string
+	for i := 0; i < 10; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			status.ResponseTime = time.Since(start)
+			status.Error = fmt.Errorf("failed to read SSH banner: %v", err)
+			return status
+		}
+		if line = strings.TrimRight(line, "\r\n"); strings.HasPrefix(line, "SSH-") {
+			banner = line
+			break
+		}
+	}
+
+	status.ResponseTime = time.Since(start)
+	status.Timing.TimeToFirstByte = status.ResponseTime
+	status.Timing.Total = status.ResponseTime
+
+	if banner == "" {
+		status.Error = fmt.Errorf("no SSH identification string received")
+		return status
+	}
+	status.IsAlive = true
+
+	switch {
+	case c.Expected != "" && !strings.Contains(banner, c.Expected):
+		status.Findings = append(status.Findings, Finding{Name: "ssh-banner", Severity: SeverityCritical,
+			Message: fmt.Sprintf("banner %q does not contain %q", banner, c.Expected)})
+	case !strings.HasPrefix(banner, "SSH-2.0-"):
+		status.Findings = append(status.Findings, Finding{Name: "ssh-banner", Severity: SeverityWarning,
+			Message: fmt.Sprintf("server does not offer SSH 2.0: %s", banner)})
+	default:
+		status.Findings = append(status.Findings, Finding{Name: "ssh-banner", Severity: SeverityOK, Message: banner})
+	}
+
+	return status
+}
+
+// GRPCHealthChecker calls the standard grpc.health.v1.Health/Check method
+type GRPCHealthChecker struct {
+	Hostname string
+	Port     int
+	// Service is checked by name, empty asks for the overall server health
+	Service string
+	Timeout time.Duration
+	// TLS is used for the connection when set, otherwise the connection is plaintext
+	TLS *tls.Config
+}
+
+// NewGRPCHealthChecker creates a plaintext gRPC health checker for the whole server
+func NewGRPCHealthChecker(hostname string, port int, timeout time.Duration) *GRPCHealthChecker {
+	return &GRPCHealthChecker{Hostname: hostname, Port: port, Timeout: timeout}
+}
+
+// Method CheckServer reports the server alive when it answers, and critical unless it is SERVING
+func (c *GRPCHealthChecker) CheckServer() *ServerStatus {
+	address := net.JoinHostPort(c.Hostname, strconv.Itoa(c.Port))
+	status := newProbeStatus("grpc", c.Hostname, fmt.Sprintf("grpc://%s/%s", address, c.Service))
+
+	creds := insecure.NewCredentials()
+	if c.TLS != nil {
+		creds = credentials.NewTLS(c.TLS)
+	}
+
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		status.Error = err
+		return status
+	}
+	defer conn.Close()
+
+	ctx, cancel := probeContext(c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: c.Service})
+	status.ResponseTime = time.Since(start)
+	status.Timing.Total = status.ResponseTime
+
+	if err != nil {
+		status.Error = err
+		return status
+	}
+	status.IsAlive = true
+
+	service := c.Service
+	if service == "" {
+		service = "server"
+	}
+	if resp.GetStatus() == healthpb.HealthCheckResponse_SERVING {
+		status.Findings = append(status.Findings, Finding{Name: "grpc-health", Severity: SeverityOK,
+			Message: fmt.Sprintf("%s is SERVING", service)})
+	} else {
+		status.Findings = append(status.Findings, Finding{Name: "grpc-health", Severity: SeverityCritical,
+			Message: fmt.Sprintf("%s is %s", service, resp.GetStatus())})
+	}
+
+	return status
+}
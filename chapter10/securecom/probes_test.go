@@ -0,0 +1,398 @@
+package securecom
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"securecom/sshtest"
+)
+
+// TestTCPChecker tests connecting to an open and a closed port
+func TestTCPChecker(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	status := NewTCPChecker("127.0.0.1", port, 2*time.Second).CheckServer()
+	if !status.IsAlive || status.Probe != "tcp" || status.Timing.TCPConnect <= 0 {
+		t.Errorf("Expected open port to be alive, got %+v", status)
+	}
+
+	listener.Close()
+	status = NewTCPChecker("127.0.0.1", port, 2*time.Second).CheckServer()
+	if status.IsAlive || status.Error == nil {
+		t.Errorf("Expected closed port to be down, got %+v", status)
+	}
+}
+
+// startDNSServer answers A queries from the records map and NXDOMAIN otherwise
+func startDNSServer(t *testing.T, records map[string]string) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) == 0 {
+				continue
+			}
+			question := query.Questions[0]
+
+			reply := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true},
+				Questions: query.Questions,
+			}
+			ip, ok := records[question.Name.String()]
+			switch {
+			case !ok:
+				reply.RCode = dnsmessage.RCodeNameError
+			case question.Type == dnsmessage.TypeA:
+				var a dnsmessage.AResource
+				copy(a.A[:], net.ParseIP(ip).To4())
+				reply.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &a,
+				}}
+			}
+
+			packed, err := reply.Pack()
+			if err == nil {
+				conn.WriteTo(packed, addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+// TestDNSChecker tests resolution and expected records against a local DNS server
+func TestDNSChecker(t *testing.T) {
+	resolver := startDNSServer(t, map[string]string{"router.example.com.": "10.0.0.1"})
+
+	tests := []struct {
+		name     string
+		host     string
+		expected []string
+		alive    bool
+		healthy  bool
+	}{
+		{"Resolves", "router.example.com", nil, true, true},
+		{"Expected record present", "router.example.com", []string{"10.0.0.1"}, true, true},
+		{"Expected record missing", "router.example.com", []string{"10.0.0.2"}, true, false},
+		{"Name does not exist", "missing.example.com", nil, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewDNSChecker(tt.host, 2*time.Second)
+			checker.Resolver = resolver
+			checker.Expected = tt.expected
+
+			status := checker.CheckServer()
+			if status.IsAlive != tt.alive || status.Healthy() != tt.healthy {
+				t.Errorf("Expected alive %v healthy %v, got %v %v (%v %+v)",
+					tt.alive, tt.healthy, status.IsAlive, status.Healthy(), status.Error, status.Findings)
+			}
+		})
+	}
+
+	// A zero timeout means no timeout, as for the TCP checker
+	checker := NewDNSChecker("router.example.com", 0)
+	checker.Resolver = resolver
+	if status := checker.CheckServer(); !status.IsAlive {
+		t.Errorf("Expected lookup without timeout to succeed, got %v", status.Error)
+	}
+}
+
+// TestICMPChecker tests pinging the loopback address where ICMP sockets are permitted
+func TestICMPChecker(t *testing.T) {
+	checker := NewICMPChecker("127.0.0.1", 2*time.Second)
+	checker.Count = 2
+
+	status := checker.CheckServer()
+	if status.Error != nil && strings.Contains(status.Error.Error(), "failed to open ICMP socket") {
+		t.Skipf("ICMP sockets not permitted: %v", status.Error)
+	}
+
+	if !status.IsAlive || status.ResponseTime <= 0 {
+		t.Fatalf("Expected loopback to answer, got %v", status.Error)
+	}
+	if f, ok := findingByName(status.Findings, "packet-loss"); !ok || f.Severity != SeverityOK {
+		t.Errorf("Expected no packet loss, got %+v", status.Findings)
+	}
+
+	if status := NewICMPChecker("127.0.0.1", 0).CheckServer(); !status.IsAlive {
+		t.Errorf("Expected ping without timeout to succeed, got %v", status.Error)
+	}
+}
+
+// TestICMPReplyMatching tests that replies from other hosts or other probes are ignored
+func TestICMPReplyMatching(t *testing.T) {
+	target := &net.UDPAddr{IP: net.ParseIP("192.0.2.1")}
+	payload := []byte("securecom 7 1")
+
+	reply := func(id, seq int, data []byte) []byte {
+		message := icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: id, Seq: seq, Data: data}}
+		packet, err := message.Marshal(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return packet
+	}
+
+	tests := []struct {
+		name    string
+		packet  []byte
+		peer    net.Addr
+		matchID bool
+		want    bool
+	}{
+		{"Reply from the target", reply(7, 1, payload), &net.UDPAddr{IP: net.ParseIP("192.0.2.1")}, false, true},
+		{"Raw socket reply", reply(7, 1, payload), &net.IPAddr{IP: net.ParseIP("192.0.2.1")}, true, true},
+		{"Reply from another host", reply(7, 1, payload), &net.UDPAddr{IP: net.ParseIP("192.0.2.2")}, false, false},
+		{"Reply to another probe", reply(8, 1, []byte("securecom 8 1")), &net.IPAddr{IP: net.ParseIP("192.0.2.1")}, true, false},
+		{"Reply with another payload", reply(7, 1, []byte("securecom 9 1")), &net.UDPAddr{IP: net.ParseIP("192.0.2.1")}, false, false},
+		{"Reply to another sequence", reply(7, 2, payload), &net.UDPAddr{IP: net.ParseIP("192.0.2.1")}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEchoReply(tt.packet, tt.peer, target, 7, 1, payload, tt.matchID); got != tt.want {
+				t.Errorf("Expected match %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if a, b := nextICMPID(), nextICMPID(); a == b {
+		t.Errorf("Expected distinct IDs for each probe, got %d twice", a)
+	}
+}
+
+// TestSSHBannerChecker tests reading and matching the SSH identification string
+func TestSSHBannerChecker(t *testing.T) {
+	server := startTestServer(t, sshtest.Config{})
+
+	tests := []struct {
+		name     string
+		expected string
+		healthy  bool
+	}{
+		{"Any SSH 2.0 server", "", true},
+		{"Expected implementation", "SSH-2.0-Go", true},
+		{"Different implementation", "OpenSSH", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewSSHBannerChecker(server.Host(), server.Port(), 2*time.Second)
+			checker.Expected = tt.expected
+
+			status := checker.CheckServer()
+			if !status.IsAlive || status.Healthy() != tt.healthy {
+				t.Errorf("Expected alive and healthy %v, got %v %+v", tt.healthy, status.Error, status.Findings)
+			}
+		})
+	}
+
+	// A server that is not SSH
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			conn.Write([]byte("220 smtp.example.com ESMTP\r\n"))
+			conn.Close()
+		}
+	}()
+
+	status := NewSSHBannerChecker("127.0.0.1", listener.Addr().(*net.TCPAddr).Port, 2*time.Second).CheckServer()
+	if status.IsAlive {
+		t.Errorf("Expected a non SSH server to fail, got %+v", status)
+	}
+
+	if status := NewSSHBannerChecker(server.Host(), server.Port(), 0).CheckServer(); !status.IsAlive {
+		t.Errorf("Expected banner without timeout to be read, got %v", status.Error)
+	}
+}
+
+// TestGRPCHealthChecker tests the gRPC health checking protocol
+func TestGRPCHealthChecker(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("inventory", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("config", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	tests := []struct {
+		service string
+		alive   bool
+		healthy bool
+	}{
+		{"", true, true},
+		{"inventory", true, true},
+		{"config", true, false},
+		{"unknown", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run("service "+tt.service, func(t *testing.T) {
+			checker := NewGRPCHealthChecker("127.0.0.1", port, 2*time.Second)
+			checker.Service = tt.service
+
+			status := checker.CheckServer()
+			if status.IsAlive != tt.alive || status.Healthy() != tt.healthy {
+				t.Errorf("Expected alive %v healthy %v, got %v %v (%v %+v)",
+					tt.alive, tt.healthy, status.IsAlive, status.Healthy(), status.Error, status.Findings)
+			}
+		})
+	}
+
+	if status := NewGRPCHealthChecker("127.0.0.1", port, 0).CheckServer(); !status.IsAlive {
+		t.Errorf("Expected health check without timeout to succeed, got %v", status.Error)
+	}
+}
+
+// TestHTTPProbe tests plain HTTP checks through the HTTPS checker
+func TestHTTPProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	target, err := ParseCheckTarget("http://" + strings.TrimPrefix(server.URL, "http://") + "/health")
+	if err != nil {
+		t.Fatalf("ParseCheckTarget failed: %v", err)
+	}
+
+	checker := NewMultiChecker(2*time.Second, true, 1).Checker(target)
+	status := checker.CheckServer()
+
+	if !status.IsAlive || status.Probe != "http" || status.TLSVersion != "" {
+		t.Errorf("Expected plain HTTP check to succeed, got %v %+v", status.Error, status)
+	}
+	if !strings.HasPrefix(status.URL, "http://127.0.0.1:") {
+		t.Errorf("Unexpected URL %s", status.URL)
+	}
+}
+
+// TestChecksFileProbeTypes tests building each probe type from a checks file
+func TestChecksFileProbeTypes(t *testing.T) {
+	filename := writeChecksFile(t, "checks.yaml", `
+checks:
+  - host: www.example.com
+  - type: http
+    host: www.example.com
+  - type: tcp
+    host: db.example.com
+    port: 5432
+  - type: dns
+    host: example.com
+    record_type: mx
+    expect: [mail.example.com]
+    resolver: 10.0.0.53:53
+  - type: icmp
+    host: 10.0.0.1
+  - type: ssh
+    host: r1.example.com
+    banner: Cisco
+  - type: grpc
+    host: api.example.com
+    port: 9090
+    plaintext: true
+`)
+
+	file, err := LoadChecksFile(filename)
+	if err != nil {
+		t.Fatalf("LoadChecksFile failed: %v", err)
+	}
+	checks, err := file.NamedChecks()
+	if err != nil {
+		t.Fatalf("NamedChecks failed: %v", err)
+	}
+
+	expected := []struct {
+		name    string
+		checker string
+	}{
+		{"https://www.example.com/", "*securecom.HTTPSChecker"},
+		{"http://www.example.com/", "*securecom.HTTPSChecker"},
+		{"tcp://db.example.com:5432", "*securecom.TCPChecker"},
+		{"dns://example.com?type=MX", "*securecom.DNSChecker"},
+		{"icmp://10.0.0.1", "*securecom.ICMPChecker"},
+		{"ssh://r1.example.com:22", "*securecom.SSHBannerChecker"},
+		{"grpc://api.example.com:9090", "*securecom.GRPCHealthChecker"},
+	}
+	for i, e := range expected {
+		if checks[i].Name != e.name || fmt.Sprintf("%T", checks[i].Checker) != e.checker {
+			t.Errorf("Check %d: expected %s %s, got %s %T", i, e.name, e.checker, checks[i].Name, checks[i].Checker)
+		}
+	}
+
+	if dns := checks[3].Checker.(*DNSChecker); dns.RecordType != "MX" || dns.Expected[0] != "mail.example.com" {
+		t.Errorf("Unexpected DNS checker: %+v", dns)
+	}
+	if grpcChecker := checks[6].Checker.(*GRPCHealthChecker); grpcChecker.TLS != nil {
+		t.Errorf("Expected plaintext gRPC checker")
+	}
+
+	_, err = LoadChecksFile(writeChecksFile(t, "invalid.yaml", `
+checks:
+  - type: tcp
+    host: db.example.com
+  - type: smtp
+    host: mail.example.com
+  - type: dns
+    host: example.com
+    record_type: SRV
+  - type: ssh
+    host: r1.example.com
+    proxy: http://proxy:3128
+`))
+	for _, problem := range []string{
+		"checks[0]: port 0 out of range",
+		"checks[1]: unknown probe type smtp",
+		"checks[2]: unsupported record type SRV",
+		"checks[3]: proxy is only supported for http and https probes",
+	} {
+		if err == nil || !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected error to contain %q, got: %v", problem, err)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package securecom
+
+import (
+	"discovery"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// RunStatus describes the outcome of running commands on a single device
+type RunStatus string
+
+const (
+	RunSuccess RunStatus = "success"
+	RunFailure RunStatus = "failure"
+	RunTimeout RunStatus = "timeout"
+)
+
+// DeviceTarget identifies a device the fan-out runner connects to
+type DeviceTarget struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// DeviceResult represents the outcome of running commands on one device
+type DeviceResult struct {
+	Name       string            `json:"name"`
+	Address    string            `json:"address"`
+	Status     RunStatus         `json:"status"`
+	Outputs    map[string]string `json:"-"`
+	OutputFile string            `json:"output_file,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Duration   time.Duration     `json:"duration"`
+}
+
+// RunSummary represents the outcome of a fan-out run across all devices
+type RunSummary struct {
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Commands   []string       `json:"commands"`
+	Total      int            `json:"total"`
+	Succeeded  int            `json:"succeeded"`
+	Failed     int            `json:"failed"`
+	TimedOut   int            `json:"timed_out"`
+	Results    []DeviceResult `json:"results"`
+}
+
+// FanOutRunner runs commands on many devices with bounded concurrency
+type FanOutRunner struct {
+	Username      string
+	Password      string
+	Port          int
+	Workers       int
+	DeviceTimeout time.Duration
+	OutputDir     string
+}
+
+// NewFanOutRunner creates a new fan-out runner and returns the pointer to FanOutRunner
+func NewFanOutRunner(username, password string, port, workers int, deviceTimeout time.Duration, outputDir string) *FanOutRunner {
+	return &FanOutRunner{
+		Username:      username,
+		Password:      password,
+		Port:          port,
+		Workers:       workers,
+		DeviceTimeout: deviceTimeout,
+		OutputDir:     outputDir,
+	}
+}
+
+// TargetsFromInventory converts discovery inventory into runner targets
+func TargetsFromInventory(inventory discovery.InfraDevices) []DeviceTarget {
+	targets := make([]DeviceTarget, 0, len(inventory.Devices))
+	for _, device := range inventory.Devices {
+		address := device.IPv4Address
+		if address == "" {
+			address = device.Hostname
+		}
+		name := device.Hostname
+		if name == "" {
+			name = address
+		}
+		targets = append(targets, DeviceTarget{Name: name, Address: address})
+	}
+	return targets
+}
+
+// TargetsFromHosts converts a list of hostnames or IP addresses into runner targets
+func TargetsFromHosts(hosts []string) []DeviceTarget {
+	targets := make([]DeviceTarget, 0, len(hosts))
+	for _, host := range hosts {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		targets = append(targets, DeviceTarget{Name: host, Address: host})
+	}
+	return targets
+}
+
+// Method Run executes the commands on every target and returns the summary
+// Results keep the order of the targets regardless of completion order
+func (r *FanOutRunner) Run(targets []DeviceTarget, commands []string) *RunSummary {
+	summary := &RunSummary{
+		StartedAt: time.Now(),
+		Commands:  commands,
+		Total:     len(targets),
+		Results:   make([]DeviceResult, len(targets)),
+	}
+
+	workers := r.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	outputNames := uniqueOutputNames(targets)
+
+	var wg sync.WaitGroup
+	indexChan := make(chan int, len(targets))
+
+	// Start workers
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexChan {
+				summary.Results[idx] = r.runDevice(targets[idx], outputNames[idx], commands)
+			}
+		}()
+	}
+
+	// Send target indexes to workers
+	for i := range targets {
+		indexChan <- i
+	}
+	close(indexChan)
+
+	wg.Wait()
+
+	for _, result := range summary.Results {
+		switch result.Status {
+		case RunSuccess:
+			summary.Succeeded++
+		case RunTimeout:
+			summary.TimedOut++
+		default:
+			summary.Failed++
+		}
+	}
+	summary.FinishedAt = time.Now()
+
+	return summary
+}
+
+// runDevice connects to a single device and runs the commands within the per-device timeout
+// Outputs are saved as outputName.txt in the output directory, including those a timeout cut short
+func (r *FanOutRunner) runDevice(target DeviceTarget, outputName string, commands []string) DeviceResult {
+	result := DeviceResult{
+		Name:    target.Name,
+		Address: target.Address,
+	}
+	startTime := time.Now()
+
+	sshClient := NewSSHClient(target.Address, r.Username, r.Password, r.Port, r.DeviceTimeout)
+	done := make(chan error, 1)
+
+	// Outputs are collected as each command finishes, so a timeout keeps the earlier results
+	var mu sync.Mutex
+	var conn *ssh.Client
+	outputs := make(map[string]string)
+	abandoned := false
+
+	go func() {
+		client, err := sshClient.Connect()
+		if err != nil {
+			done <- err
+			return
+		}
+
+		mu.Lock()
+		if abandoned {
+			mu.Unlock()
+			client.Close()
+			return
+		}
+		conn = client
+		mu.Unlock()
+		defer client.Close()
+
+		for _, cmd := range commands {
+			output, err := sshClient.ExecuteCommand(client, cmd)
+			if err != nil {
+				done <- err
+				return
+			}
+			mu.Lock()
+			outputs[cmd] = output
+			mu.Unlock()
+		}
+		done <- nil
+	}()
+
+	var timer <-chan time.Time
+	if r.DeviceTimeout > 0 {
+		timer = time.After(r.DeviceTimeout)
+	}
+
+	select {
+	case err := <-done:
+		switch {
+		case err == nil:
+			result.Status = RunSuccess
+		case isTimeout(err):
+			result.Status = RunTimeout
+			result.Error = err.Error()
+		default:
+			result.Status = RunFailure
+			result.Error = err.Error()
+		}
+	case <-timer:
+		// Closing the connection unblocks any command still waiting for output
+		mu.Lock()
+		abandoned = true
+		if conn != nil {
+			conn.Close()
+		}
+		mu.Unlock()
+		result.Status = RunTimeout
+		result.Error = fmt.Sprintf("device did not finish within %v", r.DeviceTimeout)
+	}
+
+	mu.Lock()
+	result.Outputs = make(map[string]string, len(outputs))
+	for cmd, output := range outputs {
+		result.Outputs[cmd] = output
+	}
+	mu.Unlock()
+	result.Duration = time.Since(startTime)
+
+	if r.OutputDir != "" && len(result.Outputs) > 0 {
+		filename, err := saveDeviceOutput(r.OutputDir, outputName, commands, result.Outputs)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		} else {
+			result.OutputFile = filename
+		}
+	}
+
+	return result
+}
+
+// isTimeout reports whether the error was caused by a network timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// saveDeviceOutput writes the outputs of one device into its own file
+func saveDeviceOutput(outputDir, name string, commands []string, outputs map[string]string) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	var sb strings.Builder
+	for _, cmd := range commands {
+		output, ok := outputs[cmd]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&sb, "### %s\n%s\n", cmd, output)
+	}
+
+	filename := filepath.Join(outputDir, name+".txt")
+	if err := SaveToFile(filename, sb.String()); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// uniqueOutputNames returns a safe file name per target, in target order
+// Names that collide after sanitising, such as "a/b" and "a_b", get a numeric suffix
+func uniqueOutputNames(targets []DeviceTarget) []string {
+	names := make([]string, len(targets))
+	used := make(map[string]bool, len(targets))
+	for i, target := range targets {
+		base := safeFilename(target.Name)
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// safeFilename replaces characters that are not safe in file names
+func safeFilename(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// SaveRunSummary saves the run summary as JSON
+func SaveRunSummary(filename string, summary *RunSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary: %v", err)
+	}
+	return SaveToFile(filename, string(data))
+}
+
+// PrintRunSummary prints the fan-out run summary
+func PrintRunSummary(summary *RunSummary) {
+	fmt.Println("Run Summary")
+
+	for _, result := range summary.Results {
+		switch result.Status {
+		case RunSuccess:
+			fmt.Printf("✓ %-30s [%s] %v\n", result.Name, result.Status, result.Duration)
+		default:
+			fmt.Printf("✗ %-30s [%s] %s\n", result.Name, result.Status, result.Error)
+		}
+	}
+
+	fmt.Printf("Total Devices:   %d\n", summary.Total)
+	fmt.Printf("Succeeded:       %d\n", summary.Succeeded)
+	fmt.Printf("Failed:          %d\n", summary.Failed)
+	fmt.Printf("Timed Out:       %d\n", summary.TimedOut)
+}
+
+// ConnectAndRunMany runs the commands on every device from an inventory file or host list
+func ConnectAndRunMany(inventoryFile, hostnames, username, password, commands, outputDir *string, port, timeout, workers *int) {
+	var targets []DeviceTarget
+
+	if *inventoryFile != "" {
+		devices, err := discovery.ReadDevicesFromFile(*inventoryFile)
+		if err != nil {
+			log.Fatalf("Failed to read inventory: %v", err)
+		}
+		targets = append(targets, TargetsFromInventory(discovery.InfraDevices{Devices: devices})...)
+	}
+
+	if *hostnames != "" {
+		targets = append(targets, TargetsFromHosts(strings.Split(*hostnames, ","))...)
+	}
+
+	if len(targets) == 0 {
+		log.Fatal("No devices to run commands on")
+	}
+
+	var cmdList []string
+	for _, cmd := range strings.Split(*commands, ";") {
+		if cmd = strings.TrimSpace(cmd); cmd != "" {
+			cmdList = append(cmdList, cmd)
+		}
+	}
+
+	runner := NewFanOutRunner(*username, *password, *port, *workers, time.Duration(*timeout)*time.Second, *outputDir)
+	summary := runner.Run(targets, cmdList)
+
+	PrintRunSummary(summary)
+
+	if *outputDir != "" {
+		if err := SaveRunSummary(filepath.Join(*outputDir, "summary.json"), summary); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	if summary.Succeeded != summary.Total {
+		os.Exit(1)
+	}
+}
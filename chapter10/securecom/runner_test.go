@@ -0,0 +1,311 @@
+package securecom
+
+import (
+	"discovery"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"securecom/sshtest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTargetsFromInventory tests conversion of discovery inventory into targets
+func TestTargetsFromInventory(t *testing.T) {
+	inventory := discovery.InfraDevices{
+		Devices: []discovery.Device{
+			{Hostname: "router-main", IPv4Address: "192.168.1.1"},
+			{Hostname: "switch-01", IPv4Address: ""},
+			{Hostname: "", IPv4Address: "192.168.1.3"},
+		},
+	}
+
+	targets := TargetsFromInventory(inventory)
+
+	expected := []DeviceTarget{
+		{Name: "router-main", Address: "192.168.1.1"},
+		{Name: "switch-01", Address: "switch-01"},
+		{Name: "192.168.1.3", Address: "192.168.1.3"},
+	}
+
+	if len(targets) != len(expected) {
+		t.Fatalf("Expected %d targets, got %d", len(expected), len(targets))
+	}
+
+	for i, target := range targets {
+		if target != expected[i] {
+			t.Errorf("Target %d: expected %+v, got %+v", i, expected[i], target)
+		}
+	}
+}
+
+// TestTargetsFromHosts tests conversion of a host list into targets
+func TestTargetsFromHosts(t *testing.T) {
+	targets := TargetsFromHosts([]string{" router1 ", "", "10.0.0.1"})
+
+	if len(targets) != 2 {
+		t.Fatalf("Expected 2 targets, got %d", len(targets))
+	}
+
+	if targets[0].Name != "router1" || targets[0].Address != "router1" {
+		t.Errorf("Unexpected first target: %+v", targets[0])
+	}
+
+	if targets[1].Address != "10.0.0.1" {
+		t.Errorf("Unexpected second target: %+v", targets[1])
+	}
+}
+
+// TestSafeFilename tests replacement of unsafe characters
+func TestSafeFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"router-main", "router-main"},
+		{"192.168.1.1", "192_168_1_1"},
+		{"core/sw:01", "core_sw_01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := safeFilename(tt.name); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestFanOutRunner_Failure tests that refused connections are reported as failures
+func TestFanOutRunner_Failure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	runner := NewFanOutRunner("user", "pass", port, 2, 2*time.Second, "")
+	summary := runner.Run(TargetsFromHosts([]string{"127.0.0.1"}), []string{"show version"})
+
+	if summary.Total != 1 || summary.Failed != 1 {
+		t.Errorf("Expected 1 failed device, got %+v", summary)
+	}
+
+	if summary.Results[0].Status != RunFailure {
+		t.Errorf("Expected status %s, got %s", RunFailure, summary.Results[0].Status)
+	}
+
+	if summary.Results[0].Error == "" {
+		t.Error("Expected error message for failed device")
+	}
+}
+
+// TestFanOutRunner_Timeout tests that a device that never answers is reported as timed out
+func TestFanOutRunner_Timeout(t *testing.T) {
+	// Accept TCP connections but never start the SSH handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	runner := NewFanOutRunner("user", "pass", port, 4, 300*time.Millisecond, "")
+
+	start := time.Now()
+	summary := runner.Run(TargetsFromHosts([]string{"127.0.0.1", "localhost"}), []string{"show version"})
+	elapsed := time.Since(start)
+
+	if summary.TimedOut != 2 {
+		t.Errorf("Expected 2 timed out devices, got %+v", summary)
+	}
+
+	if elapsed > 2*time.Second {
+		t.Errorf("Runner took too long: %v", elapsed)
+	}
+
+	// The abandoned dial must give up on its own instead of holding the connection open
+	dialStart := time.Now()
+	if _, err := NewSSHClient("127.0.0.1", "user", "pass", port, 300*time.Millisecond).Connect(); err == nil {
+		t.Error("Expected the handshake to fail")
+	}
+	if elapsed := time.Since(dialStart); elapsed > 2*time.Second {
+		t.Errorf("Handshake was not bounded by the timeout, took %v", elapsed)
+	}
+}
+
+// TestFanOutRunner_ResultOrder tests that results follow the order of the targets
+func TestFanOutRunner_ResultOrder(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	hosts := []string{"127.0.0.1", "localhost", "127.0.0.2", "127.0.0.3"}
+	runner := NewFanOutRunner("user", "pass", port, 3, 2*time.Second, "")
+	summary := runner.Run(TargetsFromHosts(hosts), nil)
+
+	for i, result := range summary.Results {
+		if result.Name != hosts[i] {
+			t.Errorf("Result %d: expected %s, got %s", i, hosts[i], result.Name)
+		}
+	}
+}
+
+// TestFanOutRunner_TestServer tests a run where the second device drops the connection
+func TestFanOutRunner_TestServer(t *testing.T) {
+	server := startTestServer(t, sshtest.Config{
+		Username:            "admin",
+		Password:            "secret",
+		Responses:           map[string]string{"show version": "Cisco IOS Software\n"},
+		DisconnectOnCommand: 2,
+	})
+
+	outputDir := t.TempDir()
+	runner := NewFanOutRunner("admin", "secret", server.Port(), 1, 5*time.Second, outputDir)
+	summary := runner.Run(TargetsFromHosts([]string{"127.0.0.1", "localhost"}), []string{"show version"})
+
+	if summary.Succeeded != 1 || summary.Failed != 1 {
+		t.Fatalf("Expected 1 succeeded and 1 failed device, got %+v", summary)
+	}
+
+	if summary.Results[0].Outputs["show version"] != "Cisco IOS Software\n" {
+		t.Errorf("Unexpected output: %q", summary.Results[0].Outputs["show version"])
+	}
+	if summary.Results[0].OutputFile == "" {
+		t.Error("Expected output file for successful device")
+	}
+	if summary.Results[1].Status != RunFailure {
+		t.Errorf("Expected status %s for disconnected device, got %s", RunFailure, summary.Results[1].Status)
+	}
+}
+
+// TestFanOutRunner_PartialTimeout tests that outputs collected before a timeout are kept and saved
+func TestFanOutRunner_PartialTimeout(t *testing.T) {
+	server := startTestServer(t, sshtest.Config{
+		Username: "admin",
+		Password: "secret",
+		Responses: map[string]string{
+			"show version": "Cisco IOS Software\n",
+			"show tech":    strings.Repeat("detail\n", 50),
+		},
+		LineDelay: 50 * time.Millisecond,
+	})
+
+	outputDir := t.TempDir()
+	runner := NewFanOutRunner("admin", "secret", server.Port(), 1, time.Second, outputDir)
+	summary := runner.Run(TargetsFromHosts([]string{"127.0.0.1"}), []string{"show version", "show tech"})
+
+	result := summary.Results[0]
+	if result.Status != RunTimeout {
+		t.Fatalf("Expected status %s, got %s (%s)", RunTimeout, result.Status, result.Error)
+	}
+	if result.Outputs["show version"] != "Cisco IOS Software\n" {
+		t.Errorf("Expected the first output to be kept, got %q", result.Outputs["show version"])
+	}
+	if _, ok := result.Outputs["show tech"]; ok {
+		t.Error("Expected no output for the command cut short")
+	}
+
+	data, err := os.ReadFile(result.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if string(data) != "### show version\nCisco IOS Software\n\n" {
+		t.Errorf("Unexpected output file content: %q", string(data))
+	}
+}
+
+// TestUniqueOutputNames tests that targets whose names sanitise to the same file get distinct files
+func TestUniqueOutputNames(t *testing.T) {
+	targets := TargetsFromHosts([]string{"a/b", "a_b", "a.b", "a_b-2", "core1"})
+
+	got := uniqueOutputNames(targets)
+	expected := []string{"a_b", "a_b-2", "a_b-3", "a_b-2-2", "core1"}
+
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+// TestSaveDeviceOutput tests writing per-device output files
+func TestSaveDeviceOutput(t *testing.T) {
+	dir := t.TempDir()
+	target := DeviceTarget{Name: "router.lab", Address: "10.0.0.1"}
+	commands := []string{"show version", "show clock"}
+	outputs := map[string]string{
+		"show version": "Version 1.0",
+		"show clock":   "12:00:00",
+	}
+
+	filename, err := saveDeviceOutput(dir, safeFilename(target.Name), commands, outputs)
+	if err != nil {
+		t.Fatalf("saveDeviceOutput failed: %v", err)
+	}
+
+	if filepath.Base(filename) != "router_lab.txt" {
+		t.Errorf("Unexpected output file name: %s", filename)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+
+	expected := "### show version\nVersion 1.0\n### show clock\n12:00:00\n"
+	if string(data) != expected {
+		t.Errorf("Expected content:\n%s\nGot:\n%s", expected, string(data))
+	}
+}
+
+// TestSaveRunSummary tests saving the run summary as JSON
+func TestSaveRunSummary(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "summary.json")
+	summary := &RunSummary{
+		Commands:  []string{"show version"},
+		Total:     2,
+		Succeeded: 1,
+		TimedOut:  1,
+		Results: []DeviceResult{
+			{Name: "r1", Address: "10.0.0.1", Status: RunSuccess},
+			{Name: "r2", Address: "10.0.0.2", Status: RunTimeout, Error: "timeout"},
+		},
+	}
+
+	if err := SaveRunSummary(filename, summary); err != nil {
+		t.Fatalf("SaveRunSummary failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read summary: %v", err)
+	}
+
+	var decoded RunSummary
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to parse summary: %v", err)
+	}
+
+	if decoded.Total != 2 || decoded.Succeeded != 1 || decoded.TimedOut != 1 {
+		t.Errorf("Unexpected summary counts: %+v", decoded)
+	}
+
+	if decoded.Results[1].Status != RunTimeout {
+		t.Errorf("Expected status %s, got %s", RunTimeout, decoded.Results[1].Status)
+	}
+}
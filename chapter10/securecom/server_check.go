@@ -1,16 +1,26 @@
 package securecom
 
 import (
+	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 // ServerStatus represents the status of a server check
 type ServerStatus struct {
+	Probe          string // https, http, tcp, dns, icmp, ssh or grpc
+	Check          string // Name of the check that produced the status, empty when run directly
 	Hostname       string
 	URL            string
 	IsAlive        bool
@@ -18,57 +28,160 @@ type ServerStatus struct {
 	ResponseTime   time.Duration
 	ContentLength  int64
 	TLSVersion     string
+	CipherSuite    string
 	CertExpiry     time.Time
+	ExpectedStatus int
+	Findings       []Finding
+	Timing         Timing
 	Error          error
 	CheckTimestamp time.Time
 }
 
+// MarshalJSON exports the status with durations in milliseconds and the error as text
+func (s *ServerStatus) MarshalJSON() ([]byte, error) {
+	var errText string
+	if s.Error != nil {
+		errText = s.Error.Error()
+	}
+
+	var certExpiry *time.Time
+	if !s.CertExpiry.IsZero() {
+		certExpiry = &s.CertExpiry
+	}
+
+	return json.Marshal(struct {
+		Probe          string     `json:"probe"`
+		Check          string     `json:"check,omitempty"`
+		Hostname       string     `json:"hostname"`
+		URL            string     `json:"url"`
+		IsAlive        bool       `json:"alive"`
+		Healthy        bool       `json:"healthy"`
+		StatusCode     int        `json:"status_code,omitempty"`
+		ExpectedStatus int        `json:"expected_status,omitempty"`
+		ResponseTime   float64    `json:"response_time_ms"`
+		ContentLength  int64      `json:"content_length"`
+		TLSVersion     string     `json:"tls_version,omitempty"`
+		CipherSuite    string     `json:"cipher_suite,omitempty"`
+		CertExpiry     *time.Time `json:"cert_expiry,omitempty"`
+		Findings       []Finding  `json:"findings,omitempty"`
+		Timing         Timing     `json:"timing"`
+		Error          string     `json:"error,omitempty"`
+		CheckTimestamp time.Time  `json:"checked_at"`
+	}{
+		Probe:          s.Probe,
+		Check:          s.Check,
+		Hostname:       s.Hostname,
+		URL:            s.URL,
+		IsAlive:        s.IsAlive,
+		Healthy:        s.Healthy(),
+		StatusCode:     s.StatusCode,
+		ExpectedStatus: s.ExpectedStatus,
+		ResponseTime:   milliseconds(s.ResponseTime),
+		ContentLength:  s.ContentLength,
+		TLSVersion:     s.TLSVersion,
+		CipherSuite:    s.CipherSuite,
+		CertExpiry:     certExpiry,
+		Findings:       s.Findings,
+		Timing:         s.Timing,
+		Error:          errText,
+		CheckTimestamp: s.CheckTimestamp,
+	})
+}
+
+// Method Healthy reports whether the server is alive, answered with the expected
+// status code when one is set, and has no critical findings
+func (s *ServerStatus) Healthy() bool {
+	if !s.IsAlive || WorstSeverity(s.Findings) == SeverityCritical {
+		return false
+	}
+	return s.ExpectedStatus == 0 || s.StatusCode == s.ExpectedStatus
+}
+
 // HTTPSChecker handles HTTPS server validation
 type HTTPSChecker struct {
+	Scheme         string // https unless set to http for plain HTTP probes
 	Hostname       string
 	Port           int
 	Timeout        time.Duration
 	FollowRedirect bool
 	VerifyTLS      bool
 	CustomPath     string
+	ExpectedStatus int
+	// Method defaults to GET, Headers are added to every request
+	Method  string
+	Headers map[string]string
+	// Transport is shared between checkers when set, otherwise each check builds its own
+	Transport *http.Transport
+	// Policy is evaluated against the TLS connection when set
+	Policy *TLSPolicy
+	// Assertions are evaluated against the response when set, ExpectedStatus overrides their status codes
+	Assertions *Assertions
 }
 
 // NewHTTPSChecker creates a new HTTPS checker instance
 func NewHTTPSChecker(hostname string, port int, timeout time.Duration, verifyTLS bool) *HTTPSChecker {
 	return &HTTPSChecker{
+		Scheme:         "https",
 		Hostname:       hostname,
 		Port:           port,
 		Timeout:        timeout,
 		FollowRedirect: true,
 		VerifyTLS:      verifyTLS,
 		CustomPath:     "/",
+		Method:         http.MethodGet,
 	}
 }
 
-// BuildURL constructs the full URL
+// BuildURL constructs the full URL, leaving out the default port of the scheme
 func (c *HTTPSChecker) BuildURL() string {
-	if c.Port == 443 {
-		return fmt.Sprintf("https://%s%s", c.Hostname, c.CustomPath)
+	scheme, defaultPort := "https", 443
+	if c.Scheme == "http" {
+		scheme, defaultPort = "http", 80
+	}
+
+	if c.Port == defaultPort {
+		host := c.Hostname
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		return fmt.Sprintf("%s://%s%s", scheme, host, c.CustomPath)
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(c.Hostname, strconv.Itoa(c.Port)), c.CustomPath)
+}
+
+// NewTransport creates the HTTP transport used for checks
+// One transport can be shared by many checkers to reuse connections
+func NewTransport(verifyTLS bool) *http.Transport {
+	return &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: !verifyTLS,
+		},
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     30 * time.Second,
+		DisableCompression:  false,
 	}
-	return fmt.Sprintf("https://%s:%d%s", c.Hostname, c.Port, c.CustomPath)
 }
 
 // CheckServer validates the server liveness
 func (c *HTTPSChecker) CheckServer() *ServerStatus {
 	status := &ServerStatus{
+		Probe:          "https",
 		Hostname:       c.Hostname,
 		URL:            c.BuildURL(),
+		ExpectedStatus: c.ExpectedStatus,
 		CheckTimestamp: time.Now(),
 	}
+	if c.Scheme == "http" {
+		status.Probe = "http"
+	}
 
-	// Create custom HTTP client
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: !c.VerifyTLS,
-		},
-		MaxIdleConns:       10,
-		IdleConnTimeout:    30 * time.Second,
-		DisableCompression: false,
+	transport := c.Transport
+	if transport == nil {
+		transport = NewTransport(c.VerifyTLS)
+		if c.Policy != nil {
+			transport.TLSClientConfig.RootCAs = c.Policy.Roots
+		}
 	}
 
 	client := &http.Client{
@@ -83,15 +196,38 @@ func (c *HTTPSChecker) CheckServer() *ServerStatus {
 		}
 	}
 
-	// Measure response time
+	// Measure response time, with a breakdown of each phase
 	startTime := time.Now()
+	tracer := newTimingTracer(startTime)
+
+	method := c.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), tracer.ClientTrace()),
+		method, status.URL, nil)
+	if err != nil {
+		status.Error = err
+		return status
+	}
+	for name, value := range c.Headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+		} else {
+			req.Header.Set(name, value)
+		}
+	}
 
-	resp, err := client.Get(status.URL)
+	resp, err := client.Do(req)
 	status.ResponseTime = time.Since(startTime)
 
 	if err != nil {
 		status.IsAlive = false
 		status.Error = err
+		status.Timing = tracer.Timing(time.Now())
+		if state := c.probePolicy(status, transport); state != nil {
+			recordTLS(status, state)
+		}
 		return status
 	}
 	defer resp.Body.Close()
@@ -100,23 +236,60 @@ func (c *HTTPSChecker) CheckServer() *ServerStatus {
 	status.IsAlive = true
 	status.StatusCode = resp.StatusCode
 
-	// Read response body to get content length
+	// Read response body to get content length and evaluate assertions
 	body, err := io.ReadAll(resp.Body)
 	if err == nil {
 		status.ContentLength = int64(len(body))
 	}
+	status.Timing = tracer.Timing(time.Now())
+
+	c.probePolicy(status, transport)
+
+	var assertions Assertions
+	if c.Assertions != nil {
+		assertions = *c.Assertions
+	}
+	if c.ExpectedStatus != 0 {
+		assertions.StatusCodes = []StatusRange{{Min: c.ExpectedStatus, Max: c.ExpectedStatus}}
+	}
+	if c.Assertions != nil || c.ExpectedStatus != 0 {
+		status.Findings = append(status.Findings, assertions.Evaluate(resp, body, status.ResponseTime)...)
+	}
+	// Without an expected status an error status is a warning, an accepted one is not
+	if len(assertions.StatusCodes) == 0 && resp.StatusCode >= 400 {
+		status.Findings = append(status.Findings, Finding{Name: "status", Severity: SeverityWarning,
+			Message: fmt.Sprintf("server returned error status code %d", resp.StatusCode)})
+	}
 
 	// Get TLS information
 	if resp.TLS != nil {
-		status.TLSVersion = getTLSVersionString(resp.TLS.Version)
-		if len(resp.TLS.PeerCertificates) > 0 {
-			status.CertExpiry = resp.TLS.PeerCertificates[0].NotAfter
-		}
+		recordTLS(status, resp.TLS)
 	}
 
 	return status
 }
 
+// probePolicy evaluates the TLS policy on its own connection and adds the findings, as Go
+// refuses the old versions, weak suites and untrusted chains the policy exists to report
+// It runs once the timing is recorded, so the extra connection does not count as transfer time
+func (c *HTTPSChecker) probePolicy(status *ServerStatus, transport *http.Transport) *tls.ConnectionState {
+	if c.Policy == nil || status.Probe != "https" {
+		return nil
+	}
+	state, findings := c.Policy.Probe(net.JoinHostPort(c.Hostname, strconv.Itoa(c.Port)), transport, c.Timeout, time.Now())
+	status.Findings = append(status.Findings, findings...)
+	return state
+}
+
+// recordTLS copies the negotiated version, cipher suite and certificate expiry into the status
+func recordTLS(status *ServerStatus, state *tls.ConnectionState) {
+	status.TLSVersion = getTLSVersionString(state.Version)
+	status.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	if len(state.PeerCertificates) > 0 {
+		status.CertExpiry = state.PeerCertificates[0].NotAfter
+	}
+}
+
 // getTLSVersionString converts TLS version to string
 func getTLSVersionString(version uint16) string {
 	switch version {
@@ -135,20 +308,40 @@ func getTLSVersionString(version uint16) string {
 
 // PrintStatus prints the server status in a formatted way
 func PrintStatus(status *ServerStatus) {
-	fmt.Println("HTTPS Server Liveness Check")
+	if status.Probe == "" || status.Probe == "https" {
+		fmt.Println("HTTPS Server Liveness Check")
+	} else {
+		fmt.Printf("%s Liveness Check\n", strings.ToUpper(status.Probe))
+	}
 	fmt.Printf("Hostname:        %s\n", status.Hostname)
 	fmt.Printf("URL:             %s\n", status.URL)
 	fmt.Printf("Check Time:      %s\n", status.CheckTimestamp.Format("2006-01-02 15:04:05"))
 
 	if status.IsAlive {
 		fmt.Printf("Status:          ✓ ALIVE\n")
-		fmt.Printf("HTTP Code:       %d %s\n", status.StatusCode, http.StatusText(status.StatusCode))
+		if status.StatusCode != 0 {
+			fmt.Printf("HTTP Code:       %d %s\n", status.StatusCode, http.StatusText(status.StatusCode))
+		}
 		fmt.Printf("Response Time:   %v\n", status.ResponseTime)
-		fmt.Printf("Content Length:  %d bytes\n", status.ContentLength)
+		PrintTiming(status.Timing)
+		if status.StatusCode != 0 {
+			fmt.Printf("Content Length:  %d bytes\n", status.ContentLength)
+		}
+		if status.TLSVersion != "" {
+			fmt.Printf("TLS:             %s, %s\n", status.TLSVersion, status.CipherSuite)
+			fmt.Printf("Cert Expiry:     %s\n", status.CertExpiry.Format("2006-01-02"))
+		}
+
+		// Assertion and TLS policy findings
+		for _, finding := range status.Findings {
+			fmt.Printf("  [%-8s] %-14s %s\n", finding.Severity, finding.Name, finding.Message)
+		}
 
 		// Status code warnings
-		if status.StatusCode >= 400 {
-			fmt.Printf("\n⚠ WARNING: Server returned error status code %d\n", status.StatusCode)
+		if !status.Healthy() {
+			fmt.Printf("\n✗ FAILED: %s\n", findingSummary(status.Findings))
+		} else if WorstSeverity(status.Findings) == SeverityWarning {
+			fmt.Printf("\n⚠ WARNING: %s\n", findingSummary(status.Findings))
 		} else if status.StatusCode >= 300 && status.StatusCode < 400 {
 			fmt.Printf("\nℹ INFO: Server returned redirect status code %d\n", status.StatusCode)
 		}
@@ -159,22 +352,182 @@ func PrintStatus(status *ServerStatus) {
 
 }
 
-// CheckMultipleServers checks multiple hostnames
-func CheckMultipleServers(hostnames []string, port int, timeout time.Duration, verifyTLS bool) []*ServerStatus {
-	results := make([]*ServerStatus, 0, len(hostnames))
+// CheckTarget is one endpoint to check, with optional per-host overrides
+// Zero values fall back to the defaults of the MultiChecker
+type CheckTarget struct {
+	Scheme         string
+	Hostname       string
+	Port           int
+	Path           string
+	ExpectedStatus int
+}
+
+// ParseCheckTarget parses "[http://]host[:port][/path][=status]", for example "api.example.com:8443/health=204"
+// Targets are checked over HTTPS unless prefixed with http://
+// IPv6 addresses are written in brackets, as in "[2001:db8::1]:8443". Only the last "=" can set the
+// status, and never inside a query string, so "host/search?page=200" is checked with its query intact
+func ParseCheckTarget(spec string) (CheckTarget, error) {
+	var target CheckTarget
+
+	spec = strings.TrimSpace(spec)
+	if rest, found := strings.CutPrefix(spec, "http://"); found {
+		target.Scheme = "http"
+		spec = rest
+	} else {
+		spec = strings.TrimPrefix(spec, "https://")
+	}
+	if i := strings.LastIndex(spec, "="); i >= 0 && !strings.Contains(spec[:i], "?") {
+		status, err := strconv.Atoi(spec[i+1:])
+		if err != nil || status < 100 || status > 599 {
+			return target, fmt.Errorf("invalid expected status in %q", spec)
+		}
+		target.ExpectedStatus = status
+		spec = spec[:i]
+	}
+
+	if i := strings.Index(spec, "/"); i >= 0 {
+		target.Path = spec[i:]
+		spec = spec[:i]
+	}
+
+	if strings.HasPrefix(spec, "[") && strings.HasSuffix(spec, "]") {
+		spec = spec[1 : len(spec)-1]
+	} else if strings.Contains(spec, ":") {
+		host, port, err := net.SplitHostPort(spec)
+		if err != nil {
+			return target, fmt.Errorf("invalid host and port in %q: %v", spec, err)
+		}
+		p, err := strconv.Atoi(port)
+		if err != nil || p < 1 || p > 65535 {
+			return target, fmt.Errorf("invalid port in %q", spec)
+		}
+		target.Port = p
+		spec = host
+	}
+
+	if spec == "" {
+		return target, fmt.Errorf("missing hostname in check target")
+	}
+	target.Hostname = spec
+
+	return target, nil
+}
+
+// MultiChecker checks many servers concurrently over one shared transport
+type MultiChecker struct {
+	Port           int
+	Path           string
+	Timeout        time.Duration
+	VerifyTLS      bool
+	FollowRedirect bool
+	Workers        int
+	Transport      *http.Transport
+	Policy         *TLSPolicy
+	Assertions     *Assertions
+}
+
+// NewMultiChecker creates a concurrent checker with default port 443 and path "/"
+func NewMultiChecker(timeout time.Duration, verifyTLS bool, workers int) *MultiChecker {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &MultiChecker{
+		Port:           443,
+		Path:           "/",
+		Timeout:        timeout,
+		VerifyTLS:      verifyTLS,
+		FollowRedirect: true,
+		Workers:        workers,
+		Transport:      NewTransport(verifyTLS),
+	}
+}
+
+// Method Checker builds the HTTPS checker for a target, applying its overrides
+func (m *MultiChecker) Checker(target CheckTarget) *HTTPSChecker {
+	checker := NewHTTPSChecker(target.Hostname, m.Port, m.Timeout, m.VerifyTLS)
+	checker.CustomPath = m.Path
+	checker.FollowRedirect = m.FollowRedirect
+	checker.Transport = m.Transport
+	checker.Policy = m.Policy
+	checker.Assertions = m.Assertions
+
+	if target.Scheme == "http" {
+		checker.Scheme = "http"
+		checker.Port = 80
+	}
+	if target.Port != 0 {
+		checker.Port = target.Port
+	}
+	if target.Path != "" {
+		checker.CustomPath = target.Path
+	}
+	checker.ExpectedStatus = target.ExpectedStatus
+
+	return checker
+}
 
-	fmt.Printf("\nChecking %d server(s)...\n", len(hostnames))
+// Method CheckTargets checks every target with at most Workers checks in flight
+// Results are returned in the order of the targets
+func (m *MultiChecker) CheckTargets(targets []CheckTarget) []*ServerStatus {
+	checkers := make([]StatusChecker, 0, len(targets))
+	for _, target := range targets {
+		checkers = append(checkers, m.Checker(target))
+	}
+	return CheckAll(checkers, m.Workers, os.Stdout)
+}
+
+// CheckAll runs the checkers with at most workers checks in flight, printing progress to the writer
+// Results are returned in the order of the checkers
+func CheckAll(checkers []StatusChecker, workers int, progress io.Writer) []*ServerStatus {
+	results := make([]*ServerStatus, len(checkers))
+	if workers < 1 {
+		workers = 1
+	}
+
+	fmt.Fprintf(progress, "\nChecking %d server(s) with %d worker(s)...\n", len(checkers), workers)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	completed := 0
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = checkers[i].CheckServer()
+
+				mu.Lock()
+				completed++
+				fmt.Fprintf(progress, "[%d/%d] Checked %s\n", completed, len(checkers), results[i].URL)
+				mu.Unlock()
+			}
+		}()
+	}
 
-	for i, hostname := range hostnames {
-		fmt.Printf("\n[%d/%d] Checking %s...\n", i+1, len(hostnames), hostname)
-		checker := NewHTTPSChecker(hostname, port, timeout, verifyTLS)
-		status := checker.CheckServer()
-		results = append(results, status)
+	for i := range checkers {
+		jobs <- i
 	}
+	close(jobs)
+	wg.Wait()
 
 	return results
 }
 
+// CheckMultipleServers checks multiple hostnames concurrently
+func CheckMultipleServers(hostnames []string, port int, timeout time.Duration, verifyTLS bool) []*ServerStatus {
+	targets := make([]CheckTarget, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		targets = append(targets, CheckTarget{Hostname: hostname})
+	}
+
+	checker := NewMultiChecker(timeout, verifyTLS, 10)
+	checker.Port = port
+	return checker.CheckTargets(targets)
+}
+
 // PrintSummary prints a summary of multiple checks
 func PrintSummary(results []*ServerStatus) {
 	fmt.Println("Summary")
@@ -183,9 +536,12 @@ func PrintSummary(results []*ServerStatus) {
 	down := 0
 
 	for _, result := range results {
-		if result.IsAlive {
+		if result.Healthy() {
 			alive++
-			fmt.Printf("✓ %-30s [%d] %v\n", result.Hostname, result.StatusCode, result.ResponseTime)
+			fmt.Printf("✓ %-30s [%d] %v %s\n", result.Hostname, result.StatusCode, result.ResponseTime, findingSummary(result.Findings))
+		} else if result.IsAlive {
+			down++
+			fmt.Printf("✗ %-30s [%d] %s\n", result.Hostname, result.StatusCode, findingSummary(result.Findings))
 		} else {
 			down++
 			fmt.Printf("✗ %-30s [ERROR]\n", result.Hostname)
@@ -197,79 +553,132 @@ func PrintSummary(results []*ServerStatus) {
 	fmt.Printf("Down:            %d\n", down)
 }
 
-func ConnectAndCheck(hostname, hostnames, path *string, port, timeout, interval *int, verifyTLS, followRedirect, continuous *bool) {
-	// Parse hostnames
-	var hostList []string
-	if *hostname != "" {
-		hostList = append(hostList, *hostname)
+// findingSummary lists the names of findings that are not ok
+func findingSummary(findings []Finding) string {
+	var names []string
+	for _, f := range findings {
+		if f.Severity != SeverityOK {
+			names = append(names, fmt.Sprintf("%s:%s", f.Severity, f.Name))
+		}
 	}
+	return strings.Join(names, " ")
+}
 
+func ConnectAndCheck(hostname, hostnames, path *string, port, timeout, interval, workers *int, verifyTLS, followRedirect, continuous *bool, policy *TLSPolicy, assertions *Assertions, transportConfig *TransportConfig, monitor *Monitor, output *Output) {
+	// Parse targets, each may override port, path and expected status
+	var specs []string
+	if *hostname != "" {
+		specs = append(specs, *hostname)
+	}
 	if *hostnames != "" {
-		// Simple split by comma (could be improved with proper CSV parsing)
-		for i := 0; i < len(*hostnames); i++ {
-			start := i
-			for i < len(*hostnames) && (*hostnames)[i] != ',' {
-				i++
-			}
-			host := (*hostnames)[start:i]
-			// Trim spaces
-			for len(host) > 0 && host[0] == ' ' {
-				host = host[1:]
-			}
-			for len(host) > 0 && host[len(host)-1] == ' ' {
-				host = host[:len(host)-1]
-			}
-			if len(host) > 0 {
-				hostList = append(hostList, host)
-			}
+		specs = append(specs, strings.Split(*hostnames, ",")...)
+	}
+
+	var targets []CheckTarget
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
 		}
+		target, err := ParseCheckTarget(spec)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(ExitUnknown)
+		}
+		targets = append(targets, target)
 	}
 
-	// Continuous monitoring mode
-	if *continuous {
-		fmt.Printf("Starting continuous monitoring (interval: %d seconds)\n", *interval)
-		fmt.Println("Press Ctrl+C to stop")
+	checker := NewMultiChecker(time.Duration(*timeout)*time.Second, *verifyTLS, *workers)
+	checker.Port = *port
+	checker.Path = *path
+	checker.FollowRedirect = *followRedirect
+	checker.Policy = policy
+	checker.Assertions = assertions
+	if transportConfig != nil {
+		transport, err := transportConfig.Transport()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(ExitUnknown)
+		}
+		checker.Transport = transport
+	}
+	if policy != nil && policy.Roots != nil {
+		checker.Transport.TLSClientConfig.RootCAs = policy.Roots
+	}
 
-		for {
-			results := CheckMultipleServers(hostList, *port, time.Duration(*timeout)*time.Second, *verifyTLS)
+	checks := make([]NamedCheck, 0, len(targets))
+	for _, target := range targets {
+		c := checker.Checker(target)
+		checks = append(checks, NamedCheck{Name: c.BuildURL(), Checker: c})
+	}
 
-			if len(results) == 1 {
-				PrintStatus(results[0])
-			} else {
-				PrintSummary(results)
-			}
+	if monitor == nil {
+		monitor = NewMonitor(time.Duration(*interval) * time.Second)
+	}
+	RunChecks(checks, *workers, *continuous, monitor, output)
+}
 
-			time.Sleep(time.Duration(*interval) * time.Second)
+// NamedCheck is a checker with the name and interval it is monitored under
+type NamedCheck struct {
+	Name     string
+	Interval time.Duration // Zero uses the interval of the monitor
+	Checker  StatusChecker
+}
+
+// RunChecks checks once and exits with a Nagios compatible code, or hands the checks
+// to the monitor in continuous mode
+func RunChecks(checks []NamedCheck, workers int, continuous bool, monitor *Monitor, output *Output) {
+	// Continuous monitoring mode, the monitor tracks state and notifies on transitions
+	if continuous {
+		for _, check := range checks {
+			monitor.AddWithInterval(check.Name, check.Checker, check.Interval)
 		}
-	}
 
-	// Single check mode
-	results := CheckMultipleServers(hostList, *port, time.Duration(*timeout)*time.Second, *verifyTLS)
+		fmt.Printf("Starting continuous monitoring (interval: %v)\n", monitor.Interval)
+		fmt.Println("Press Ctrl+C to stop")
 
-	if len(results) == 1 {
-		// Single server - detailed output
-		checker := NewHTTPSChecker(hostList[0], *port, time.Duration(*timeout)*time.Second, *verifyTLS)
-		checker.CustomPath = *path
-		checker.FollowRedirect = *followRedirect
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		monitor.Run(ctx)
+		return
+	}
 
-		status := checker.CheckServer()
-		PrintStatus(status)
+	// Single check mode
+	checkers := make([]StatusChecker, 0, len(checks))
+	for _, check := range checks {
+		checkers = append(checkers, check.Checker)
+	}
+	results := CheckAll(checkers, workers, output.progress())
+	for i, check := range checks {
+		results[i].Check = check.Name
+	}
+	code := ExitCode(results)
 
-		// Exit with appropriate code
-		if !status.IsAlive {
-			os.Exit(1)
+	switch {
+	case output != nil && output.Format != OutputText && output.File != "":
+		if err := WriteResultsFile(output.File, output.Format, results); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(ExitUnknown)
 		}
-	} else {
+		fmt.Printf("%s: results written to %s\n", ExitCodeName(code), output.File)
+	case output != nil && output.Format != OutputText:
+		if err := WriteResults(os.Stdout, output.Format, results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(ExitUnknown)
+		}
+	case len(results) == 1:
+		// Single server - detailed output
+		PrintStatus(results[0])
+	default:
 		// Multiple servers - summary output
 		PrintSummary(results)
+	}
 
-		// Exit with error if any server is down
-		for _, result := range results {
-			if !result.IsAlive {
-				os.Exit(1)
-			}
-		}
+	// Exit with the worst state: critical when down or failing an assertion or the TLS policy
+	if code != ExitOK {
+		os.Exit(code)
 	}
 
-	fmt.Println("\nCheck completed successfully!")
+	if output == nil || output.Format == OutputText {
+		fmt.Println("\nCheck completed successfully!")
+	}
 }
@@ -3,8 +3,11 @@ package securecom
 import (
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -76,6 +79,20 @@ func TestBuildURL(t *testing.T) {
 			customPath: "/api/status",
 			expected:   "https://localhost:9443/api/status",
 		},
+		{
+			name:       "IPv6 with custom port",
+			hostname:   "::1",
+			port:       8443,
+			customPath: "/health",
+			expected:   "https://[::1]:8443/health",
+		},
+		{
+			name:       "IPv6 with standard port",
+			hostname:   "2001:db8::1",
+			port:       443,
+			customPath: "/",
+			expected:   "https://[2001:db8::1]/",
+		},
 	}
 
 	for _, tt := range tests {
@@ -498,3 +515,170 @@ func TestServerStatusFields(t *testing.T) {
 		t.Error("CheckTimestamp should not be zero")
 	}
 }
+
+// TestServerStatusHealthy tests health evaluation with and without an expected status
+func TestServerStatusHealthy(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   ServerStatus
+		expected bool
+	}{
+		{"Alive without expectation", ServerStatus{IsAlive: true, StatusCode: 500}, true},
+		{"Down", ServerStatus{IsAlive: false}, false},
+		{"Expected status matches", ServerStatus{IsAlive: true, StatusCode: 204, ExpectedStatus: 204}, true},
+		{"Expected status differs", ServerStatus{IsAlive: true, StatusCode: 200, ExpectedStatus: 204}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.status.Healthy(); got != tt.expected {
+				t.Errorf("Expected Healthy() %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestParseCheckTarget tests parsing of per-host check overrides
+func TestParseCheckTarget(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected CheckTarget
+		wantErr  bool
+	}{
+		{spec: "example.com", expected: CheckTarget{Hostname: "example.com"}},
+		{spec: " example.com:8443 ", expected: CheckTarget{Hostname: "example.com", Port: 8443}},
+		{spec: "api.example.com/health", expected: CheckTarget{Hostname: "api.example.com", Path: "/health"}},
+		{
+			spec:     "api.example.com:8443/v1/status=204",
+			expected: CheckTarget{Hostname: "api.example.com", Port: 8443, Path: "/v1/status", ExpectedStatus: 204},
+		},
+		{spec: "api.example.com/search?page=200", expected: CheckTarget{Hostname: "api.example.com", Path: "/search?page=200"}},
+		{spec: "api.example.com/search?q=a=b=200", expected: CheckTarget{Hostname: "api.example.com", Path: "/search?q=a=b=200"}},
+		{spec: "api.example.com/list?page=2", expected: CheckTarget{Hostname: "api.example.com", Path: "/list?page=2"}},
+		{spec: "[::1]:8443", expected: CheckTarget{Hostname: "::1", Port: 8443}},
+		{
+			spec:     "http://[2001:db8::1]:8080/health=204",
+			expected: CheckTarget{Scheme: "http", Hostname: "2001:db8::1", Port: 8080, Path: "/health", ExpectedStatus: 204},
+		},
+		{spec: "[2001:db8::1]/health", expected: CheckTarget{Hostname: "2001:db8::1", Path: "/health"}},
+		{spec: "2001:db8::1", wantErr: true},
+		{spec: "example.com:https", wantErr: true},
+		{spec: "example.com=ok", wantErr: true},
+		{spec: "example.com=99", wantErr: true},
+		{spec: ":8443", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			target, err := ParseCheckTarget(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %+v", tt.spec, target)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if target != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, target)
+			}
+		})
+	}
+}
+
+// TestMultiCheckerChecker tests that target overrides and the shared transport are applied
+func TestMultiCheckerChecker(t *testing.T) {
+	multi := NewMultiChecker(5*time.Second, false, 4)
+	multi.Path = "/health"
+
+	checker := multi.Checker(CheckTarget{Hostname: "a.example.com"})
+	if checker.Port != 443 || checker.CustomPath != "/health" || checker.ExpectedStatus != 0 {
+		t.Errorf("Expected defaults to apply, got %+v", checker)
+	}
+
+	checker = multi.Checker(CheckTarget{Hostname: "b.example.com", Port: 8443, Path: "/ready", ExpectedStatus: 204})
+	if checker.Port != 8443 || checker.CustomPath != "/ready" || checker.ExpectedStatus != 204 {
+		t.Errorf("Expected overrides to apply, got %+v", checker)
+	}
+
+	if checker.Transport != multi.Transport {
+		t.Error("Expected checkers to share the transport")
+	}
+}
+
+// TestMultiCheckerCheckTargets tests bounded concurrent checks with ordered results
+func TestMultiCheckerCheckTargets(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(100 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	var targets []CheckTarget
+	for i := 0; i < 8; i++ {
+		targets = append(targets, CheckTarget{Hostname: "127.0.0.1", Port: port, Path: fmt.Sprintf("/item/%d", i)})
+	}
+	targets = append(targets,
+		CheckTarget{Hostname: "127.0.0.1", Port: port, Path: "/missing", ExpectedStatus: 404},
+		CheckTarget{Hostname: "127.0.0.1", Port: port, Path: "/item/8", ExpectedStatus: 204},
+		CheckTarget{Hostname: "127.0.0.1", Port: port, Path: "/missing"},
+	)
+
+	multi := NewMultiChecker(5*time.Second, false, 4)
+
+	start := time.Now()
+	results := multi.CheckTargets(targets)
+	elapsed := time.Since(start)
+
+	if len(results) != len(targets) {
+		t.Fatalf("Expected %d results, got %d", len(targets), len(results))
+	}
+
+	for i, result := range results {
+		if !strings.HasSuffix(result.URL, targets[i].Path) {
+			t.Errorf("Result %d: expected path %s, got %s", i, targets[i].Path, result.URL)
+		}
+	}
+
+	if !results[8].Healthy() || results[8].StatusCode != 404 {
+		t.Errorf("Expected 404 to be healthy when expected, got %+v", results[8])
+	}
+	if code := ResultExitCode(results[8]); code != ExitOK {
+		t.Errorf("Expected an expected 404 to be OK, got %s", ExitCodeName(code))
+	}
+	if results[9].Healthy() {
+		t.Errorf("Expected 200 to be unhealthy when 204 is expected, got %+v", results[9])
+	}
+	if code := ResultExitCode(results[10]); code != ExitWarning {
+		t.Errorf("Expected an unexpected 404 to be a warning, got %s %+v", ExitCodeName(code), results[10].Findings)
+	}
+
+	if maxInFlight > 4 {
+		t.Errorf("Expected at most 4 concurrent checks, saw %d", maxInFlight)
+	}
+	if elapsed > 900*time.Millisecond {
+		t.Errorf("Checks did not run concurrently, took %v", elapsed)
+	}
+}
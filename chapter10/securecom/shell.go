@@ -0,0 +1,365 @@
+package securecom
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// PromptMode describes the CLI mode indicated by a device prompt
+type PromptMode int
+
+const (
+	ModeUnknown PromptMode = iota
+	ModeUser
+	ModeEnable
+	ModeConfig
+)
+
+// String returns the name of the prompt mode
+func (m PromptMode) String() string {
+	switch m {
+	case ModeUser:
+		return "user"
+	case ModeEnable:
+		return "enable"
+	case ModeConfig:
+		return "config"
+	default:
+		return "unknown"
+	}
+}
+
+// PromptSet holds the regular expressions used to recognise a vendor CLI
+// Prompt expressions are matched against the last line of output only
+type PromptSet struct {
+	Vendor   string
+	User     *regexp.Regexp
+	Enable   *regexp.Regexp
+	Config   *regexp.Regexp
+	Pager    *regexp.Regexp
+	Password *regexp.Regexp
+}
+
+// CiscoPrompts recognises Cisco IOS, IOS-XE and NX-OS prompts
+var CiscoPrompts = PromptSet{
+	Vendor:   "cisco",
+	User:     regexp.MustCompile(`^[\w.\-@/:]+>\s*$`),
+	Enable:   regexp.MustCompile(`^[\w.\-@/:]+#\s*$`),
+	Config:   regexp.MustCompile(`^[\w.\-@/:]+\(config[^)]*\)#\s*$`),
+	Pager:    regexp.MustCompile(`\s*--More--\s*|\s*<--- More --->\s*`),
+	Password: regexp.MustCompile(`(?i)password:\s*$`),
+}
+
+// JuniperPrompts recognises Junos operational and configuration prompts
+var JuniperPrompts = PromptSet{
+	Vendor:   "juniper",
+	User:     regexp.MustCompile(`^(\{[^}]*\}\s*)?[\w.\-]+@[\w.\-]+>\s*$`),
+	Config:   regexp.MustCompile(`^(\[edit[^\]]*\]\s*)?[\w.\-]+@[\w.\-]+#\s*$`),
+	Pager:    regexp.MustCompile(`\s*---\(more[^)]*\)---\s*`),
+	Password: regexp.MustCompile(`(?i)password:\s*$`),
+}
+
+// PromptsForVendor returns the prompt set for the vendor name used in the model
+func PromptsForVendor(vendor string) (PromptSet, error) {
+	switch strings.ToLower(vendor) {
+	case "cisco":
+		return CiscoPrompts, nil
+	case "juniper":
+		return JuniperPrompts, nil
+	default:
+		return PromptSet{}, fmt.Errorf("unsupported vendor: %s", vendor)
+	}
+}
+
+// CommandOutput holds the output of a single command run in an interactive shell
+type CommandOutput struct {
+	Command string
+	Output  string
+	Mode    PromptMode
+}
+
+// ShellSession drives an interactive device CLI over a PTY, expect-style
+type ShellSession struct {
+	Prompts PromptSet
+	Timeout time.Duration
+
+	session  *ssh.Session
+	stdin    io.Writer
+	chunks   chan []byte
+	readErr  chan error
+	prompt   string
+	mode     PromptMode
+	password string
+}
+
+// NewShellSession requests a PTY on the client, starts a shell and waits for the first prompt
+func NewShellSession(client *ssh.Client, prompts PromptSet, timeout time.Duration) (*ShellSession, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %v", err)
+	}
+
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+
+	if err := session.RequestPty("vt100", 200, 511, modes); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to request pty: %v", err)
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to get stdin pipe: %v", err)
+	}
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to get stdout pipe: %v", err)
+	}
+
+	if err := session.Shell(); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to start shell: %v", err)
+	}
+
+	shell := newShellSession(stdout, stdin, prompts, timeout)
+	shell.session = session
+
+	if _, err := shell.expect(); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to detect prompt: %v", err)
+	}
+
+	return shell, nil
+}
+
+// newShellSession wires a shell driver to an arbitrary reader and writer
+func newShellSession(r io.Reader, w io.Writer, prompts PromptSet, timeout time.Duration) *ShellSession {
+	s := &ShellSession{
+		Prompts: prompts,
+		Timeout: timeout,
+		stdin:   w,
+		chunks:  make(chan []byte, 64),
+		readErr: make(chan error, 1),
+	}
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				chunk := make([]byte, n)
+				copy(chunk, buf[:n])
+				s.chunks <- chunk
+			}
+			if err != nil {
+				s.readErr <- err
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// Mode returns the CLI mode of the last prompt seen
+func (s *ShellSession) Mode() PromptMode {
+	return s.mode
+}
+
+// Prompt returns the last prompt seen
+func (s *ShellSession) Prompt() string {
+	return s.prompt
+}
+
+// Method Run sends a command and returns its output without the echo and trailing prompt
+func (s *ShellSession) Run(command string) (string, error) {
+	if _, err := io.WriteString(s.stdin, command+"\n"); err != nil {
+		return "", fmt.Errorf("failed to write command: %v", err)
+	}
+
+	output, err := s.expect()
+	if err != nil {
+		return output, fmt.Errorf("command %q: %v", command, err)
+	}
+
+	return strings.TrimRight(stripEcho(output, command), "\n"), nil
+}
+
+// Method RunCommands runs the commands in order and returns each output separately
+func (s *ShellSession) RunCommands(commands []string) ([]CommandOutput, error) {
+	results := make([]CommandOutput, 0, len(commands))
+
+	for _, cmd := range commands {
+		output, err := s.Run(cmd)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, CommandOutput{
+			Command: cmd,
+			Output:  output,
+			Mode:    s.mode,
+		})
+	}
+
+	return results, nil
+}
+
+// Method Enable enters privileged mode, answering the password prompt if one appears
+func (s *ShellSession) Enable(password string) error {
+	if s.mode == ModeEnable || s.mode == ModeConfig {
+		return nil
+	}
+	if s.Prompts.Enable == nil {
+		return fmt.Errorf("%s CLI has no enable mode", s.Prompts.Vendor)
+	}
+
+	s.password = password
+	defer func() { s.password = "" }()
+
+	if _, err := io.WriteString(s.stdin, "enable\n"); err != nil {
+		return fmt.Errorf("failed to write command: %v", err)
+	}
+
+	if _, err := s.expect(); err != nil {
+		return fmt.Errorf("enable: %v", err)
+	}
+
+	if s.mode != ModeEnable {
+		return fmt.Errorf("enable failed, device prompt is %q", s.prompt)
+	}
+
+	return nil
+}
+
+// Method Close ends the shell session
+func (s *ShellSession) Close() error {
+	if s.session == nil {
+		return nil
+	}
+	io.WriteString(s.stdin, "exit\n")
+	return s.session.Close()
+}
+
+// expect reads output until a known prompt appears at the end of the buffer
+// Pagination prompts are answered with a space and removed from the output
+func (s *ShellSession) expect() (string, error) {
+	var buf bytes.Buffer
+
+	var timer <-chan time.Time
+	if s.Timeout > 0 {
+		t := time.NewTimer(s.Timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	for {
+		select {
+		case chunk := <-s.chunks:
+			buf.Write(chunk)
+		case err := <-s.readErr:
+			// Collect output that arrived together with the error
+			for len(s.chunks) > 0 {
+				buf.Write(<-s.chunks)
+			}
+			s.readErr <- err
+			return cleanOutput(buf.String()), fmt.Errorf("connection closed: %v", err)
+		case <-timer:
+			return cleanOutput(buf.String()), fmt.Errorf("timed out waiting for prompt")
+		}
+
+		text := cleanOutput(buf.String())
+		lastLine := text[strings.LastIndex(text, "\n")+1:]
+
+		if s.Prompts.Pager != nil && s.Prompts.Pager.MatchString(lastLine) {
+			if _, err := io.WriteString(s.stdin, " "); err != nil {
+				return text, fmt.Errorf("failed to answer pager: %v", err)
+			}
+			buf.Reset()
+			buf.WriteString(s.Prompts.Pager.ReplaceAllString(text, "\n"))
+			continue
+		}
+
+		if s.Prompts.Password != nil && s.Prompts.Password.MatchString(lastLine) {
+			if s.password == "" {
+				return text, fmt.Errorf("device asked for a password")
+			}
+			if _, err := io.WriteString(s.stdin, s.password+"\n"); err != nil {
+				return text, fmt.Errorf("failed to send password: %v", err)
+			}
+			buf.Reset()
+			buf.WriteString(text[:len(text)-len(lastLine)])
+			continue
+		}
+
+		if mode := s.detectMode(lastLine); mode != ModeUnknown {
+			s.mode = mode
+			s.prompt = strings.TrimSpace(lastLine)
+			return strings.TrimSuffix(text[:len(text)-len(lastLine)], "\n"), nil
+		}
+	}
+}
+
+// detectMode matches a line against the prompt expressions, most specific first
+func (s *ShellSession) detectMode(line string) PromptMode {
+	line = strings.TrimRight(line, " ")
+	switch {
+	case s.Prompts.Config != nil && s.Prompts.Config.MatchString(line):
+		return ModeConfig
+	case s.Prompts.Enable != nil && s.Prompts.Enable.MatchString(line):
+		return ModeEnable
+	case s.Prompts.User != nil && s.Prompts.User.MatchString(line):
+		return ModeUser
+	default:
+		return ModeUnknown
+	}
+}
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// cleanOutput removes carriage returns, ANSI escapes and backspace erasures
+func cleanOutput(text string) string {
+	text = ansiEscape.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var out []rune
+	for _, r := range text {
+		switch r {
+		case '\b':
+			if len(out) > 0 && out[len(out)-1] != '\n' {
+				out = out[:len(out)-1]
+			}
+		case '\r':
+			// Bare carriage return rewrites the current line
+			for len(out) > 0 && out[len(out)-1] != '\n' {
+				out = out[:len(out)-1]
+			}
+		default:
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
+
+// stripEcho removes the echoed command line from the start of the output
+func stripEcho(output, command string) string {
+	first, rest, found := strings.Cut(output, "\n")
+	if strings.TrimSpace(first) == strings.TrimSpace(command) {
+		if !found {
+			return ""
+		}
+		return rest
+	}
+	return output
+}
@@ -0,0 +1,337 @@
+package securecom
+
+import (
+	"bufio"
+	"io"
+	"securecom/sshtest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeCLI emulates an interactive Cisco CLI on the far end of a pair of pipes
+type fakeCLI struct {
+	hostname       string
+	enablePassword string
+	responses      map[string]string
+	mode           PromptMode
+}
+
+func (f *fakeCLI) prompt() string {
+	switch f.mode {
+	case ModeConfig:
+		return f.hostname + "(config)#"
+	case ModeEnable:
+		return f.hostname + "#"
+	default:
+		return f.hostname + ">"
+	}
+}
+
+func (f *fakeCLI) serve(in io.Reader, out io.Writer) {
+	reader := bufio.NewReader(in)
+	io.WriteString(out, "\r\n"+f.prompt())
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		io.WriteString(out, cmd+"\r\n")
+
+		switch cmd {
+		case "enable":
+			io.WriteString(out, "Password: ")
+			password, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if strings.TrimSpace(password) == f.enablePassword {
+				f.mode = ModeEnable
+			} else {
+				io.WriteString(out, "\r\n% Access denied\r\n")
+			}
+		case "configure terminal":
+			f.mode = ModeConfig
+		case "end":
+			f.mode = ModeEnable
+		case "show long":
+			io.WriteString(out, "line1\r\nline2\r\n --More-- ")
+			if b, err := reader.ReadByte(); err != nil || b != ' ' {
+				return
+			}
+			io.WriteString(out, "\b\b\b\b\b\b\b\b\b\b          \b\b\b\b\b\b\b\b\b\bline3\r\n")
+		default:
+			if response, ok := f.responses[cmd]; ok {
+				io.WriteString(out, strings.ReplaceAll(response, "\n", "\r\n")+"\r\n")
+			} else {
+				io.WriteString(out, "% Invalid input detected\r\n")
+			}
+		}
+		io.WriteString(out, f.prompt())
+	}
+}
+
+// startFakeCLI connects a shell driver to a fake CLI
+func startFakeCLI(t *testing.T, cli *fakeCLI, prompts PromptSet) *ShellSession {
+	t.Helper()
+
+	deviceIn, driverOut := io.Pipe()
+	driverIn, deviceOut := io.Pipe()
+	t.Cleanup(func() {
+		driverOut.Close()
+		deviceOut.Close()
+	})
+
+	go cli.serve(deviceIn, deviceOut)
+
+	shell := newShellSession(driverIn, driverOut, prompts, 2*time.Second)
+	if _, err := shell.expect(); err != nil {
+		t.Fatalf("Failed to detect initial prompt: %v", err)
+	}
+	return shell
+}
+
+// TestShellSession_Cisco tests command execution, pagination and mode tracking
+func TestShellSession_Cisco(t *testing.T) {
+	cli := &fakeCLI{
+		hostname:       "R1",
+		enablePassword: "secret",
+		responses: map[string]string{
+			"show version": "Cisco IOS Software, Version 15.2\nuptime is 1 week",
+		},
+	}
+	shell := startFakeCLI(t, cli, CiscoPrompts)
+
+	if shell.Mode() != ModeUser {
+		t.Errorf("Expected mode %s, got %s", ModeUser, shell.Mode())
+	}
+
+	output, err := shell.Run("show version")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if output != "Cisco IOS Software, Version 15.2\nuptime is 1 week" {
+		t.Errorf("Unexpected output: %q", output)
+	}
+
+	if err := shell.Enable("secret"); err != nil {
+		t.Fatalf("Enable failed: %v", err)
+	}
+	if shell.Mode() != ModeEnable {
+		t.Errorf("Expected mode %s, got %s", ModeEnable, shell.Mode())
+	}
+	if shell.Prompt() != "R1#" {
+		t.Errorf("Expected prompt R1#, got %s", shell.Prompt())
+	}
+
+	output, err = shell.Run("show long")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if output != "line1\nline2\nline3" {
+		t.Errorf("Expected paginated output to be joined, got %q", output)
+	}
+
+	results, err := shell.RunCommands([]string{"configure terminal", "end"})
+	if err != nil {
+		t.Fatalf("RunCommands failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Mode != ModeConfig {
+		t.Errorf("Expected config mode after configure terminal, got %s", results[0].Mode)
+	}
+	if results[1].Mode != ModeEnable {
+		t.Errorf("Expected enable mode after end, got %s", results[1].Mode)
+	}
+}
+
+// TestShellSession_EnableWrongPassword tests that a rejected enable password is reported
+func TestShellSession_EnableWrongPassword(t *testing.T) {
+	cli := &fakeCLI{hostname: "R1", enablePassword: "secret"}
+	shell := startFakeCLI(t, cli, CiscoPrompts)
+
+	err := shell.Enable("wrong")
+	if err == nil {
+		t.Fatal("Expected error for wrong enable password, got nil")
+	}
+
+	if shell.Mode() != ModeUser {
+		t.Errorf("Expected mode %s, got %s", ModeUser, shell.Mode())
+	}
+}
+
+// TestShellSession_Timeout tests that a device without a prompt times out
+func TestShellSession_Timeout(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+
+	go io.WriteString(writer, "Welcome banner without prompt\r\n")
+
+	shell := newShellSession(reader, io.Discard, CiscoPrompts, 200*time.Millisecond)
+	_, err := shell.expect()
+	if err == nil {
+		t.Fatal("Expected timeout error, got nil")
+	}
+}
+
+// TestShellSession_Juniper tests Junos prompts and pagination
+func TestShellSession_Juniper(t *testing.T) {
+	deviceIn, driverOut := io.Pipe()
+	driverIn, deviceOut := io.Pipe()
+	defer driverOut.Close()
+	defer deviceOut.Close()
+
+	go func() {
+		reader := bufio.NewReader(deviceIn)
+		io.WriteString(deviceOut, "\r\nadmin@vmx1> ")
+		reader.ReadString('\n')
+		io.WriteString(deviceOut, "show interfaces terse\r\nge-0/0/0 up up\r\n---(more)---")
+		reader.ReadByte()
+		io.WriteString(deviceOut, "\r                    \rge-0/0/1 up down\r\n\r\nadmin@vmx1> ")
+		reader.ReadString('\n')
+		io.WriteString(deviceOut, "configure\r\nEntering configuration mode\r\n\r\n[edit]\r\nadmin@vmx1# ")
+	}()
+
+	shell := newShellSession(driverIn, driverOut, JuniperPrompts, 2*time.Second)
+	if _, err := shell.expect(); err != nil {
+		t.Fatalf("Failed to detect initial prompt: %v", err)
+	}
+
+	if shell.Mode() != ModeUser {
+		t.Errorf("Expected mode %s, got %s", ModeUser, shell.Mode())
+	}
+
+	output, err := shell.Run("show interfaces terse")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if output != "ge-0/0/0 up up\nge-0/0/1 up down" {
+		t.Errorf("Unexpected output: %q", output)
+	}
+
+	if _, err := shell.Run("configure"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if shell.Mode() != ModeConfig {
+		t.Errorf("Expected mode %s, got %s", ModeConfig, shell.Mode())
+	}
+
+	if err := shell.Enable(""); err != nil {
+		t.Errorf("Enable should be a no-op in config mode, got %v", err)
+	}
+}
+
+// TestDetectMode tests prompt recognition for each vendor
+func TestDetectMode(t *testing.T) {
+	tests := []struct {
+		prompts  PromptSet
+		line     string
+		expected PromptMode
+	}{
+		{CiscoPrompts, "R1>", ModeUser},
+		{CiscoPrompts, "core-sw.lab#", ModeEnable},
+		{CiscoPrompts, "R1(config)#", ModeConfig},
+		{CiscoPrompts, "R1(config-if)# ", ModeConfig},
+		{CiscoPrompts, "interface Ethernet1/1", ModeUnknown},
+		{JuniperPrompts, "admin@vmx1> ", ModeUser},
+		{JuniperPrompts, "admin@vmx1# ", ModeConfig},
+		{JuniperPrompts, "{master:0}admin@ex4300>", ModeUser},
+		{JuniperPrompts, "R1#", ModeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prompts.Vendor+"_"+tt.line, func(t *testing.T) {
+			shell := &ShellSession{Prompts: tt.prompts}
+			if got := shell.detectMode(tt.line); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestCleanOutput tests removal of terminal control sequences
+func TestCleanOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"CRLF", "line1\r\nline2\r\n", "line1\nline2\n"},
+		{"Backspaces", "abc\b\bd", "ad"},
+		{"ANSI escape", "\x1b[Kline\x1b[0m", "line"},
+		{"Carriage return rewrite", "progress 10%\rprogress 100%", "progress 100%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanOutput(tt.input); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestPromptsForVendor tests prompt set lookup by vendor
+func TestPromptsForVendor(t *testing.T) {
+	prompts, err := PromptsForVendor("Cisco")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prompts.Vendor != "cisco" {
+		t.Errorf("Expected cisco prompts, got %s", prompts.Vendor)
+	}
+
+	if _, err := PromptsForVendor("arista"); err == nil {
+		t.Error("Expected error for unsupported vendor, got nil")
+	}
+}
+
+// TestExecuteInteractive tests a PTY shell against the sshtest device, through enable and paging
+func TestExecuteInteractive(t *testing.T) {
+	server, client, conn := connectTestServer(t, sshtest.Config{
+		EnablePassword: "enable-pw",
+		PageLines:      3,
+		RunningConfig:  "hostname R1\ninterface Ethernet1/1\n description uplink\n ip address 10.0.0.1 255.255.255.252\n",
+		Responses:      map[string]string{"show clock": "12:00:00.000 UTC Mon Jan 1 2024\n"},
+	})
+
+	results, err := client.ExecuteInteractive(conn, "cisco", "enable-pw", []string{"show clock", "show running-config"})
+	if err != nil {
+		t.Fatalf("ExecuteInteractive failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Output != "12:00:00.000 UTC Mon Jan 1 2024" || results[0].Mode != ModeEnable {
+		t.Errorf("Unexpected first result: %+v", results[0])
+	}
+	if results[1].Output != strings.TrimRight(server.RunningConfig(), "\n") {
+		t.Errorf("Expected paginated running config to be joined, got %q", results[1].Output)
+	}
+}
+
+// TestExecuteInteractive_Juniper tests the Juniper prompts against the sshtest device
+func TestExecuteInteractive_Juniper(t *testing.T) {
+	_, client, conn := connectTestServer(t, sshtest.Config{
+		Vendor:    "juniper",
+		Hostname:  "vmx1",
+		Responses: map[string]string{"show system uptime": "Current time: 2024-01-01 12:00:00 UTC\n"},
+	})
+
+	results, err := client.ExecuteInteractive(conn, "juniper", "", []string{"show system uptime", "configure", "exit"})
+	if err != nil {
+		t.Fatalf("ExecuteInteractive failed: %v", err)
+	}
+
+	if results[0].Output != "Current time: 2024-01-01 12:00:00 UTC" {
+		t.Errorf("Unexpected output: %q", results[0].Output)
+	}
+	if results[1].Mode != ModeConfig || results[2].Mode != ModeUser {
+		t.Errorf("Unexpected modes: %s, %s", results[1].Mode, results[2].Mode)
+	}
+}
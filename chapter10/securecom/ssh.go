@@ -1,10 +1,15 @@
 package securecom
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"golang.org/x/crypto/ssh"
+	"io"
 	"log"
 	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,6 +20,9 @@ type SSHClient struct {
 	Password string
 	Port     int
 	Timeout  time.Duration
+
+	// JumpHosts are the bastions to chain through, in order, before reaching Hostname
+	JumpHosts []JumpHost
 }
 
 // NewSSHClient creates a new SSH client instance and returns the pointer to SSHClient
@@ -43,7 +51,11 @@ func (c *SSHClient) Connect() (*ssh.Client, error) {
 	address := fmt.Sprintf("%s:%d", c.Hostname, c.Port)
 	fmt.Printf("Connecting to %s@%s...\n", c.Username, address)
 
-	client, err := ssh.Dial("tcp", address, config)
+	if len(c.JumpHosts) > 0 {
+		fmt.Printf("Using %d jump host(s)\n", len(c.JumpHosts))
+	}
+
+	client, err := DialThroughJumpHosts(c.JumpHosts, address, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial: %v", err)
 	}
@@ -70,6 +82,156 @@ func (c *SSHClient) ExecuteCommand(client *ssh.Client, command string) (string,
 	return string(output), nil
 }
 
+// CommandResult holds the structured outcome of a remote command
+type CommandResult struct {
+	Command   string
+	Stdout    string
+	Stderr    string
+	ExitCode  int
+	StartTime time.Time
+	EndTime   time.Time
+	TimedOut  bool
+}
+
+// Duration returns how long the command ran
+func (r *CommandResult) Duration() time.Duration {
+	return r.EndTime.Sub(r.StartTime)
+}
+
+// RunOptions configures streaming and timeout for Run
+type RunOptions struct {
+	// Timeout closes the session if the command runs longer, zero means no limit
+	Timeout time.Duration
+	// Stream receives stdout and stderr lines as they arrive
+	Stream io.Writer
+	// OnLine is called for every line with the stream name ("stdout" or "stderr")
+	// and the line without its trailing newline
+	OnLine func(stream, line string)
+}
+
+// Method Run executes a command and returns stdout, stderr, exit code and timing separately
+// A non-zero exit code is reported in the result, not as an error
+func (c *SSHClient) Run(client *ssh.Client, command string, opts *RunOptions) (*CommandResult, error) {
+	if opts == nil {
+		opts = &RunOptions{}
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdout pipe: %v", err)
+	}
+	stderr, err := session.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stderr pipe: %v", err)
+	}
+
+	fmt.Printf("Executing command: %s\n", command)
+
+	result := &CommandResult{
+		Command:   command,
+		StartTime: time.Now(),
+	}
+
+	if err := session.Start(command); err != nil {
+		return nil, fmt.Errorf("failed to start command: %v", err)
+	}
+
+	var mu sync.Mutex
+	var stdoutBuf, stderrBuf strings.Builder
+	var stopped bool
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		streamLines(stdout, "stdout", &stdoutBuf, &mu, &stopped, opts)
+	}()
+	go func() {
+		defer wg.Done()
+		streamLines(stderr, "stderr", &stderrBuf, &mu, &stopped, opts)
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		wg.Wait()
+		done <- session.Wait()
+	}()
+
+	var timer <-chan time.Time
+	if opts.Timeout > 0 {
+		t := time.NewTimer(opts.Timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	var waitErr error
+	select {
+	case waitErr = <-done:
+	case <-timer:
+		// Closing the session ends the remote command, but a device that never answers the close
+		// would block the readers, so Run returns without waiting for them
+		result.TimedOut = true
+		session.Close()
+	}
+
+	result.EndTime = time.Now()
+	mu.Lock()
+	stopped = true
+	result.Stdout = stdoutBuf.String()
+	result.Stderr = stderrBuf.String()
+	mu.Unlock()
+
+	if result.TimedOut {
+		result.ExitCode = -1
+		return result, fmt.Errorf("command timed out after %v", opts.Timeout)
+	}
+
+	var exitErr *ssh.ExitError
+	switch {
+	case waitErr == nil:
+		result.ExitCode = 0
+	case errors.As(waitErr, &exitErr):
+		result.ExitCode = exitErr.ExitStatus()
+	default:
+		result.ExitCode = -1
+		return result, fmt.Errorf("failed to execute command: %v", waitErr)
+	}
+
+	return result, nil
+}
+
+// streamLines copies output line by line into buf and to the configured stream and callback
+// Lines read once stopped is set are dropped, as Run has already returned
+func streamLines(r io.Reader, name string, buf *strings.Builder, mu *sync.Mutex, stopped *bool, opts *RunOptions) {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			mu.Lock()
+			if *stopped {
+				mu.Unlock()
+				return
+			}
+			buf.WriteString(line)
+			if opts.Stream != nil {
+				io.WriteString(opts.Stream, line)
+			}
+			if opts.OnLine != nil {
+				opts.OnLine(name, strings.TrimRight(line, "\r\n"))
+			}
+			mu.Unlock()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
 // Method ExecuteCommands executes multiple commands on the remote device
 func (c *SSHClient) ExecuteCommands(client *ssh.Client, commands []string) (map[string]string, error) {
 	results := make(map[string]string)
@@ -85,6 +247,29 @@ func (c *SSHClient) ExecuteCommands(client *ssh.Client, commands []string) (map[
 	return results, nil
 }
 
+// Method ExecuteInteractive runs commands through an interactive PTY shell
+// Use it for devices that reject exec requests and only allow a login shell
+func (c *SSHClient) ExecuteInteractive(client *ssh.Client, vendor, enablePassword string, commands []string) ([]CommandOutput, error) {
+	prompts, err := PromptsForVendor(vendor)
+	if err != nil {
+		return nil, err
+	}
+
+	shell, err := NewShellSession(client, prompts, c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer shell.Close()
+
+	if enablePassword != "" {
+		if err := shell.Enable(enablePassword); err != nil {
+			return nil, err
+		}
+	}
+
+	return shell.RunCommands(commands)
+}
+
 // SaveToFile saves the output to a file
 func SaveToFile(filename, content string) error {
 	err := os.WriteFile(filename, []byte(content), 0644)
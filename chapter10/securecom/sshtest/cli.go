@@ -0,0 +1,249 @@
+package sshtest
+
+import (
+	"strings"
+)
+
+// CLI modes shared by the emulated vendors
+const (
+	modeUser   = "user"
+	modeEnable = "enable"
+	modeConfig = "config"
+)
+
+// cliResult is the outcome of one command line
+type cliResult struct {
+	output   string
+	invalid  bool
+	password bool
+	exit     bool
+}
+
+// deviceCLI interprets commands for one session against the server's configuration
+type deviceCLI struct {
+	server  *Server
+	mode    string
+	replace bool
+	pending []string
+	paging  bool
+}
+
+// newDeviceCLI creates an interpreter in privileged mode, as exec requests run
+func newDeviceCLI(server *Server) *deviceCLI {
+	return &deviceCLI{
+		server: server,
+		mode:   modeEnable,
+		paging: server.cfg.PageLines > 0,
+	}
+}
+
+// prompt returns the prompt for the current mode
+func (c *deviceCLI) prompt() string {
+	cfg := c.server.cfg
+	if cfg.Vendor == "juniper" {
+		if c.mode == modeConfig {
+			return "\n[edit]\n" + cfg.Username + "@" + cfg.Hostname + "# "
+		}
+		return cfg.Username + "@" + cfg.Hostname + "> "
+	}
+
+	switch c.mode {
+	case modeUser:
+		return cfg.Hostname + ">"
+	case modeConfig:
+		return cfg.Hostname + "(config)#"
+	default:
+		return cfg.Hostname + "#"
+	}
+}
+
+// execute runs one command line, canned responses taking precedence
+func (c *deviceCLI) execute(line string) cliResult {
+	if output, ok := c.server.cfg.Responses[line]; ok {
+		return cliResult{output: output}
+	}
+	if c.server.cfg.Vendor == "juniper" {
+		return c.executeJuniper(line)
+	}
+	return c.executeCisco(line)
+}
+
+func (c *deviceCLI) executeCisco(line string) cliResult {
+	invalid := cliResult{output: "% Invalid input detected at '^' marker.\n", invalid: true}
+
+	if c.mode == modeConfig {
+		switch line {
+		case "end", "exit":
+			c.commitCisco()
+			c.mode = modeEnable
+		default:
+			c.pending = append(c.pending, line)
+		}
+		return cliResult{}
+	}
+
+	switch line {
+	case "exit", "logout", "quit":
+		return cliResult{exit: true}
+	case "enable":
+		if c.mode == modeUser && c.server.cfg.EnablePassword != "" {
+			return cliResult{password: true}
+		}
+		c.mode = modeEnable
+		return cliResult{}
+	case "disable":
+		c.mode = modeUser
+		return cliResult{}
+	case "terminal length 0":
+		c.paging = false
+		return cliResult{}
+	}
+
+	if c.mode == modeUser {
+		if strings.HasPrefix(line, "show running-config") || strings.HasPrefix(line, "conf") {
+			return invalid
+		}
+	}
+
+	switch line {
+	case "configure terminal", "conf t":
+		c.mode = modeConfig
+		c.replace = false
+		return cliResult{output: "Enter configuration commands, one per line.  End with CNTL/Z.\n"}
+	case "configure replace terminal":
+		c.mode = modeConfig
+		c.replace = true
+		return cliResult{output: "Enter configuration commands, one per line.  End with CNTL/Z.\n"}
+	case "show running-config":
+		return cliResult{output: c.server.RunningConfig()}
+	case "write memory", "copy running-config startup-config":
+		return cliResult{output: "Building configuration...\n[OK]\n"}
+	}
+
+	return invalid
+}
+
+// enable answers the enable password prompt
+func (c *deviceCLI) enable(password string) cliResult {
+	if password != c.server.cfg.EnablePassword {
+		return cliResult{output: "% Access denied\n", invalid: true}
+	}
+	c.mode = modeEnable
+	return cliResult{}
+}
+
+// commitCisco applies the lines entered in configuration mode
+func (c *deviceCLI) commitCisco() {
+	var lines []string
+	for _, line := range c.pending {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	c.pending = nil
+
+	s := c.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c.replace {
+		s.runningConfig = ""
+	}
+	if len(lines) > 0 {
+		if s.runningConfig != "" && !strings.HasSuffix(s.runningConfig, "\n") {
+			s.runningConfig += "\n"
+		}
+		s.runningConfig += strings.Join(lines, "\n") + "\n"
+	}
+}
+
+func (c *deviceCLI) executeJuniper(line string) cliResult {
+	invalid := cliResult{output: "syntax error.\n", invalid: true}
+
+	if c.mode != modeConfig {
+		switch {
+		case line == "exit" || line == "quit":
+			return cliResult{exit: true}
+		case line == "configure" || line == "edit":
+			c.mode = modeConfig
+			return cliResult{output: "Entering configuration mode\n"}
+		case line == "set cli screen-length 0":
+			c.paging = false
+			return cliResult{}
+		case strings.HasPrefix(line, "show configuration"):
+			return cliResult{output: c.server.RunningConfig()}
+		}
+		return cliResult{output: "unknown command.\n", invalid: true}
+	}
+
+	s := c.server
+	switch {
+	case line == "exit" || line == "quit":
+		c.mode = modeEnable
+		return cliResult{output: "Exiting configuration mode\n"}
+	case line == "commit check":
+		return cliResult{output: "configuration check succeeds\n"}
+	case line == "commit" || line == "commit and-quit":
+		s.mu.Lock()
+		if s.candidate != nil {
+			s.runningConfig = joinLines(s.candidate)
+			s.candidate = nil
+		}
+		s.mu.Unlock()
+		if line == "commit and-quit" {
+			c.mode = modeEnable
+			return cliResult{output: "commit complete\nExiting configuration mode\n"}
+		}
+		return cliResult{output: "commit complete\n"}
+	case line == "rollback" || line == "rollback 0":
+		s.mu.Lock()
+		s.candidate = nil
+		s.mu.Unlock()
+		return cliResult{output: "load complete\n"}
+	case strings.HasPrefix(line, "set "):
+		s.mu.Lock()
+		s.candidate = append(s.candidateLocked(), line)
+		s.mu.Unlock()
+		return cliResult{}
+	case strings.HasPrefix(line, "delete "):
+		prefix := "set " + strings.TrimPrefix(line, "delete ")
+		s.mu.Lock()
+		var kept []string
+		for _, existing := range s.candidateLocked() {
+			if existing != prefix && !strings.HasPrefix(existing, prefix+" ") {
+				kept = append(kept, existing)
+			}
+		}
+		s.candidate = kept
+		s.mu.Unlock()
+		return cliResult{}
+	case strings.HasPrefix(line, "show"):
+		s.mu.Lock()
+		output := joinLines(s.candidateLocked())
+		s.mu.Unlock()
+		return cliResult{output: output}
+	}
+
+	return invalid
+}
+
+// candidateLocked returns the shared candidate configuration, starting from the running one
+// The caller must hold the server lock
+func (s *Server) candidateLocked() []string {
+	if s.candidate == nil {
+		s.candidate = []string{}
+		for _, line := range strings.Split(s.runningConfig, "\n") {
+			if strings.TrimSpace(line) != "" {
+				s.candidate = append(s.candidate, line)
+			}
+		}
+	}
+	return s.candidate
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,168 @@
+package sshtest
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// fileCommand serves "scp -t", "scp -f" and "sha256sum" exec requests under FileRoot
+func (s *Server) fileCommand(channel ssh.Channel, command string) uint32 {
+	if s.cfg.FileRoot == "" {
+		fmt.Fprintf(channel.Stderr(), "%s: command not available\n", strings.Fields(command)[0])
+		return 127
+	}
+
+	var err error
+	switch {
+	case strings.HasPrefix(command, "scp -t "):
+		err = s.scpSink(channel, s.resolvePath(strings.TrimPrefix(command, "scp -t ")))
+	case strings.HasPrefix(command, "scp -f "):
+		err = s.scpSource(channel, s.resolvePath(strings.TrimPrefix(command, "scp -f ")))
+	case strings.HasPrefix(command, "sha256sum "):
+		arg := strings.TrimPrefix(command, "sha256sum ")
+		var sum string
+		sum, err = fileSHA256(s.resolvePath(arg))
+		if err == nil {
+			fmt.Fprintf(channel, "%s  %s\n", sum, unquote(arg))
+		}
+	default:
+		err = fmt.Errorf("unsupported command: %s", command)
+	}
+
+	if err != nil {
+		fmt.Fprintf(channel.Stderr(), "%v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// resolvePath unquotes a shell argument and places relative paths under FileRoot
+func (s *Server) resolvePath(arg string) string {
+	path := unquote(strings.TrimSpace(arg))
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.cfg.FileRoot, path)
+}
+
+// scpSink receives one file, writing into the directory when the target is one
+func (s *Server) scpSink(channel ssh.Channel, target string) error {
+	reader := bufio.NewReader(channel)
+	if _, err := channel.Write([]byte{0}); err != nil {
+		return err
+	}
+
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("scp: failed to read header: %v", err)
+	}
+
+	fields := strings.SplitN(strings.TrimSuffix(header, "\n"), " ", 3)
+	if len(fields) != 3 || !strings.HasPrefix(fields[0], "C") {
+		return fmt.Errorf("scp: unexpected header %q", header)
+	}
+	mode, err := strconv.ParseUint(fields[0][1:], 8, 32)
+	if err != nil {
+		return fmt.Errorf("scp: invalid mode %q", fields[0])
+	}
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("scp: invalid size %q", fields[1])
+	}
+
+	if info, err := os.Stat(target); err == nil && info.IsDir() {
+		target = filepath.Join(target, fields[2])
+	}
+
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(mode))
+	if err != nil {
+		channel.Write([]byte(fmt.Sprintf("\x01scp: %s: %v\n", target, err)))
+		return err
+	}
+	defer file.Close()
+
+	channel.Write([]byte{0})
+	if _, err := io.CopyN(file, reader, size); err != nil {
+		return fmt.Errorf("scp: failed to receive data: %v", err)
+	}
+	if _, err := reader.ReadByte(); err != nil {
+		return fmt.Errorf("scp: missing end of data: %v", err)
+	}
+
+	_, err = channel.Write([]byte{0})
+	return err
+}
+
+// scpSource sends one file, reporting missing files with an SCP error message
+func (s *Server) scpSource(channel ssh.Channel, source string) error {
+	reader := bufio.NewReader(channel)
+	if _, err := reader.ReadByte(); err != nil {
+		return err
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		channel.Write([]byte(fmt.Sprintf("\x01scp: %s: No such file or directory\n", source)))
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(channel, "C%04o %d %s\n", info.Mode().Perm(), info.Size(), filepath.Base(source))
+	if _, err := reader.ReadByte(); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(channel, file); err != nil {
+		return err
+	}
+	channel.Write([]byte{0})
+
+	_, err = reader.ReadByte()
+	return err
+}
+
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("sha256sum: %s: No such file or directory", path)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// unquote reverses the single-quote shell quoting applied by the client
+func unquote(arg string) string {
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(arg); i++ {
+		switch {
+		case arg[i] == '\'':
+			quoted = !quoted
+		case arg[i] == '\\' && !quoted && i+1 < len(arg):
+			i++
+			b.WriteByte(arg[i])
+		default:
+			b.WriteByte(arg[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,361 @@
+// Package sshtest provides an in-process SSH server that emulates network
+// device CLIs, for testing code that connects to devices over SSH.
+package sshtest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Config describes the behaviour of the emulated device
+type Config struct {
+	// Username and Password are the only accepted credentials
+	Username string
+	Password string
+
+	// Vendor selects the emulated CLI, "cisco" (default) or "juniper"
+	Vendor string
+	// Hostname is shown in prompts, defaults to "R1"
+	Hostname string
+	// EnablePassword is requested by the Cisco "enable" command when set
+	EnablePassword string
+
+	// Responses maps commands to canned output, taking precedence over built-in commands
+	Responses map[string]string
+	// RunningConfig is the initial configuration returned by show commands
+	RunningConfig string
+
+	// LineDelay is the pause before each output line, to emulate slow devices
+	LineDelay time.Duration
+	// PageLines paginates interactive output every PageLines lines, zero disables paging
+	PageLines int
+	// DisconnectOnCommand drops the connection instead of answering the Nth command
+	DisconnectOnCommand int
+
+	// FileRoot enables SFTP and SCP, with relative paths resolved under it
+	FileRoot string
+	// DisableSFTP rejects the SFTP subsystem so clients fall back to SCP
+	DisableSFTP bool
+}
+
+// Server is an in-process SSH server listening on a random local port
+type Server struct {
+	cfg      Config
+	listener net.Listener
+	config   *ssh.ServerConfig
+
+	mu            sync.Mutex
+	conns         map[*ssh.ServerConn]struct{}
+	commands      []string
+	forwarded     []string
+	runningConfig string
+	candidate     []string
+	commandCount  int
+}
+
+// NewServer starts a fake device SSH server on 127.0.0.1
+func NewServer(cfg Config) (*Server, error) {
+	if cfg.Vendor == "" {
+		cfg.Vendor = "cisco"
+	}
+	if cfg.Vendor != "cisco" && cfg.Vendor != "juniper" {
+		return nil, fmt.Errorf("unsupported vendor: %s", cfg.Vendor)
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname = "R1"
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate host key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create host key signer: %v", err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if conn.User() == cfg.Username && string(pass) == cfg.Password {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("invalid credentials for %s", conn.User())
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %v", err)
+	}
+
+	s := &Server{
+		cfg:           cfg,
+		listener:      listener,
+		config:        config,
+		conns:         make(map[*ssh.ServerConn]struct{}),
+		runningConfig: cfg.RunningConfig,
+	}
+
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the host:port the server listens on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Host returns the IP address the server listens on
+func (s *Server) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the TCP port the server listens on
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Commands returns every command received, in order, from exec requests and shells
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// Forwarded returns the addresses requested through direct-tcpip channels
+func (s *Server) Forwarded() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.forwarded...)
+}
+
+// RunningConfig returns the configuration after all committed changes
+func (s *Server) RunningConfig() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.runningConfig
+}
+
+// DropConnections closes every active client connection, emulating a device reload
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// Close stops the server and drops all connections
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.DropConnections()
+	return err
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	sshConn, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	s.mu.Lock()
+	s.conns[sshConn] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, sshConn)
+		s.mu.Unlock()
+		sshConn.Close()
+	}()
+
+	go ssh.DiscardRequests(reqs)
+
+	for newChan := range chans {
+		switch newChan.ChannelType() {
+		case "session":
+			go s.handleSession(sshConn, newChan)
+		case "direct-tcpip":
+			go s.handleDirectTCPIP(newChan)
+		default:
+			newChan.Reject(ssh.UnknownChannelType, "unsupported channel type")
+		}
+	}
+}
+
+// recordCommand stores the command and reports whether the connection should be dropped
+func (s *Server) recordCommand(conn *ssh.ServerConn, command string) bool {
+	s.mu.Lock()
+	s.commands = append(s.commands, command)
+	s.commandCount++
+	drop := s.cfg.DisconnectOnCommand > 0 && s.commandCount == s.cfg.DisconnectOnCommand
+	s.mu.Unlock()
+
+	if drop {
+		conn.Close()
+	}
+	return drop
+}
+
+func (s *Server) handleSession(conn *ssh.ServerConn, newChan ssh.NewChannel) {
+	channel, reqs, err := newChan.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+
+	pty := false
+	for req := range reqs {
+		switch req.Type {
+		case "pty-req", "env", "window-change":
+			if req.Type == "pty-req" {
+				pty = true
+			}
+			req.Reply(true, nil)
+		case "exec":
+			var payload struct{ Command string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+			if s.recordCommand(conn, payload.Command) {
+				return
+			}
+			status := s.exec(channel, payload.Command)
+			sendExitStatus(channel, status)
+			return
+		case "shell":
+			req.Reply(true, nil)
+			go ssh.DiscardRequests(reqs)
+			status := s.shell(conn, channel, pty)
+			sendExitStatus(channel, status)
+			return
+		case "subsystem":
+			var payload struct{ Name string }
+			ssh.Unmarshal(req.Payload, &payload)
+			if payload.Name != "sftp" || s.cfg.FileRoot == "" || s.cfg.DisableSFTP {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+			go ssh.DiscardRequests(reqs)
+			server, err := sftp.NewServer(channel, sftp.WithServerWorkingDirectory(s.cfg.FileRoot))
+			if err != nil {
+				return
+			}
+			server.Serve()
+			sendExitStatus(channel, 0)
+			return
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+func (s *Server) handleDirectTCPIP(newChan ssh.NewChannel) {
+	var payload struct {
+		Host     string
+		Port     uint32
+		OrigHost string
+		OrigPort uint32
+	}
+	if err := ssh.Unmarshal(newChan.ExtraData(), &payload); err != nil {
+		newChan.Reject(ssh.ConnectionFailed, "invalid payload")
+		return
+	}
+
+	address := net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port)))
+	s.mu.Lock()
+	s.forwarded = append(s.forwarded, address)
+	s.mu.Unlock()
+
+	target, err := net.Dial("tcp", address)
+	if err != nil {
+		newChan.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+
+	channel, reqs, err := newChan.Accept()
+	if err != nil {
+		target.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	go func() {
+		io.Copy(target, channel)
+		target.Close()
+	}()
+	io.Copy(channel, target)
+	channel.Close()
+}
+
+// exec answers a single exec request and returns the exit status
+func (s *Server) exec(channel ssh.Channel, command string) uint32 {
+	if strings.HasPrefix(command, "scp ") || strings.HasPrefix(command, "sha256sum ") {
+		return s.fileCommand(channel, command)
+	}
+
+	cli := newDeviceCLI(s)
+	statements := []string{command}
+	if s.cfg.Vendor == "juniper" {
+		statements = strings.Split(command, ";")
+	}
+
+	status := uint32(0)
+	for _, statement := range statements {
+		statement = strings.TrimSpace(statement)
+		if statement == "" {
+			continue
+		}
+		result := cli.execute(statement)
+		if result.invalid {
+			s.writeSlowly(channel.Stderr(), result.output)
+			status = 1
+			continue
+		}
+		s.writeSlowly(channel, result.output)
+	}
+	return status
+}
+
+// writeSlowly writes output line by line, honouring LineDelay
+func (s *Server) writeSlowly(w io.Writer, output string) error {
+	for _, line := range strings.SplitAfter(output, "\n") {
+		if line == "" {
+			continue
+		}
+		time.Sleep(s.cfg.LineDelay)
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sendExitStatus(channel ssh.Channel, status uint32) {
+	channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+}
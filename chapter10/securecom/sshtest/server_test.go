@@ -0,0 +1,402 @@
+package sshtest
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// startServer starts a test device and stops it when the test ends
+func startServer(t *testing.T, cfg Config) *Server {
+	t.Helper()
+
+	if cfg.Username == "" {
+		cfg.Username, cfg.Password = "admin", "secret"
+	}
+	server, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+// dial connects to the server with the given credentials
+func dial(server *Server, username, password string) (*ssh.Client, error) {
+	return ssh.Dial("tcp", server.Addr(), &ssh.ClientConfig{
+		User:            username,
+		Auth:            []ssh.AuthMethod{ssh.Password(password)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+}
+
+// connect dials with the default test credentials and fails the test on error
+func connect(t *testing.T, server *Server) *ssh.Client {
+	t.Helper()
+
+	client, err := dial(server, "admin", "secret")
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// execute runs one exec request and returns stdout, stderr and the session error
+func execute(t *testing.T, client *ssh.Client, command string) (string, string, error) {
+	t.Helper()
+
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	err = session.Run(command)
+	return stdout.String(), stderr.String(), err
+}
+
+// interactive is a PTY shell with output collected in the background
+type interactive struct {
+	stdin io.Writer
+	mu    sync.Mutex
+	out   bytes.Buffer
+}
+
+func startShell(t *testing.T, client *ssh.Client) *interactive {
+	t.Helper()
+
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	t.Cleanup(func() { session.Close() })
+
+	if err := session.RequestPty("vt100", 24, 80, ssh.TerminalModes{}); err != nil {
+		t.Fatalf("RequestPty failed: %v", err)
+	}
+	stdin, _ := session.StdinPipe()
+	stdout, _ := session.StdoutPipe()
+	if err := session.Shell(); err != nil {
+		t.Fatalf("Shell failed: %v", err)
+	}
+
+	sh := &interactive{stdin: stdin}
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := stdout.Read(buf)
+			sh.mu.Lock()
+			sh.out.Write(buf[:n])
+			sh.mu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return sh
+}
+
+// waitFor returns the output collected once it ends with suffix, and resets the buffer
+func (sh *interactive) waitFor(t *testing.T, suffix string) string {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		sh.mu.Lock()
+		text := sh.out.String()
+		if strings.HasSuffix(text, suffix) {
+			sh.out.Reset()
+			sh.mu.Unlock()
+			return text
+		}
+		sh.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	t.Fatalf("Timed out waiting for %q, got %q", suffix, sh.out.String())
+	return ""
+}
+
+func (sh *interactive) send(text string) {
+	io.WriteString(sh.stdin, text)
+}
+
+// TestNewServer_UnsupportedVendor tests vendor validation
+func TestNewServer_UnsupportedVendor(t *testing.T) {
+	if _, err := NewServer(Config{Vendor: "arista"}); err == nil {
+		t.Error("Expected error for unsupported vendor, got nil")
+	}
+}
+
+// TestAuthentication tests that only the configured credentials are accepted
+func TestAuthentication(t *testing.T) {
+	server := startServer(t, Config{})
+
+	if _, err := dial(server, "admin", "wrong"); err == nil {
+		t.Error("Expected authentication failure, got nil")
+	}
+
+	client, err := dial(server, "admin", "secret")
+	if err != nil {
+		t.Fatalf("Expected successful authentication, got %v", err)
+	}
+	client.Close()
+}
+
+// TestExec tests canned responses, built-in commands and command recording
+func TestExec(t *testing.T) {
+	server := startServer(t, Config{
+		Responses:     map[string]string{"show version": "Cisco IOS Software\n"},
+		RunningConfig: "hostname R1\n",
+	})
+	client := connect(t, server)
+
+	tests := []struct {
+		command string
+		stdout  string
+		stderr  string
+		wantErr bool
+	}{
+		{command: "show version", stdout: "Cisco IOS Software\n"},
+		{command: "show running-config", stdout: "hostname R1\n"},
+		{command: "show bogus", stderr: "% Invalid input detected at '^' marker.\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		stdout, stderr, err := execute(t, client, tt.command)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: unexpected error state: %v", tt.command, err)
+		}
+		if stdout != tt.stdout || stderr != tt.stderr {
+			t.Errorf("%s: expected %q/%q, got %q/%q", tt.command, tt.stdout, tt.stderr, stdout, stderr)
+		}
+	}
+
+	commands := server.Commands()
+	if len(commands) != 3 || commands[0] != "show version" || commands[2] != "show bogus" {
+		t.Errorf("Unexpected recorded commands: %v", commands)
+	}
+}
+
+// TestExec_ExitStatus tests that invalid commands report a non-zero exit status
+func TestExec_ExitStatus(t *testing.T) {
+	client := connect(t, startServer(t, Config{}))
+
+	_, _, err := execute(t, client, "show bogus")
+	exitErr, ok := err.(*ssh.ExitError)
+	if !ok || exitErr.ExitStatus() != 1 {
+		t.Errorf("Expected exit status 1, got %v", err)
+	}
+}
+
+// TestExec_LineDelay tests slow output
+func TestExec_LineDelay(t *testing.T) {
+	server := startServer(t, Config{
+		Responses: map[string]string{"show logging": "a\nb\nc\n"},
+		LineDelay: 30 * time.Millisecond,
+	})
+	client := connect(t, server)
+
+	start := time.Now()
+	execute(t, client, "show logging")
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected output to take at least 90ms, took %v", elapsed)
+	}
+}
+
+// TestDisconnectOnCommand tests dropping the connection instead of answering
+func TestDisconnectOnCommand(t *testing.T) {
+	server := startServer(t, Config{
+		Responses:           map[string]string{"show clock": "12:00\n"},
+		DisconnectOnCommand: 2,
+	})
+	client := connect(t, server)
+
+	if stdout, _, err := execute(t, client, "show clock"); err != nil || stdout != "12:00\n" {
+		t.Fatalf("First command should succeed, got %q, %v", stdout, err)
+	}
+
+	if _, _, err := execute(t, client, "show clock"); err == nil {
+		t.Error("Expected second command to fail on disconnect, got nil")
+	}
+
+	if _, err := client.NewSession(); err == nil {
+		t.Error("Expected connection to be closed")
+	}
+}
+
+// TestDropConnections tests closing active connections from the test
+func TestDropConnections(t *testing.T) {
+	server := startServer(t, Config{})
+	client := connect(t, server)
+
+	done := make(chan error, 1)
+	go func() { done <- client.Wait() }()
+
+	server.DropConnections()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Client connection was not closed")
+	}
+}
+
+// TestShell_CiscoModes tests enable, configuration mode and running config updates
+func TestShell_CiscoModes(t *testing.T) {
+	server := startServer(t, Config{
+		EnablePassword: "enable-pw",
+		RunningConfig:  "hostname R1\n",
+	})
+	sh := startShell(t, connect(t, server))
+
+	sh.waitFor(t, "R1>")
+
+	sh.send("configure terminal\n")
+	if out := sh.waitFor(t, "R1>"); !strings.Contains(out, "Invalid input") {
+		t.Errorf("Expected configure to be rejected in user mode, got %q", out)
+	}
+
+	sh.send("enable\n")
+	sh.waitFor(t, "Password: ")
+	sh.send("enable-pw\n")
+	sh.waitFor(t, "R1#")
+
+	sh.send("configure terminal\n")
+	sh.waitFor(t, "R1(config)#")
+	sh.send("interface Loopback0\n")
+	sh.waitFor(t, "R1(config)#")
+	sh.send(" ip address 10.0.0.1 255.255.255.255\n")
+	sh.waitFor(t, "R1(config)#")
+	sh.send("end\n")
+	sh.waitFor(t, "R1#")
+
+	expected := "hostname R1\ninterface Loopback0\n ip address 10.0.0.1 255.255.255.255\n"
+	if got := server.RunningConfig(); got != expected {
+		t.Errorf("Expected running config %q, got %q", expected, got)
+	}
+
+	commands := server.Commands()
+	for _, cmd := range commands {
+		if cmd == "enable-pw" {
+			t.Error("Enable password should not be recorded as a command")
+		}
+	}
+}
+
+// TestShell_WrongEnablePassword tests that a wrong password keeps user mode
+func TestShell_WrongEnablePassword(t *testing.T) {
+	sh := startShell(t, connect(t, startServer(t, Config{EnablePassword: "enable-pw"})))
+
+	sh.waitFor(t, "R1>")
+	sh.send("enable\n")
+	sh.waitFor(t, "Password: ")
+	sh.send("wrong\n")
+	if out := sh.waitFor(t, "R1>"); !strings.Contains(out, "Access denied") {
+		t.Errorf("Expected access denied, got %q", out)
+	}
+}
+
+// TestShell_Paging tests pagination and disabling it with terminal length 0
+func TestShell_Paging(t *testing.T) {
+	server := startServer(t, Config{
+		Responses: map[string]string{"show log": "1\n2\n3\n4\n5\n"},
+		PageLines: 2,
+	})
+	sh := startShell(t, connect(t, server))
+	sh.waitFor(t, "R1#")
+
+	sh.send("show log\n")
+	sh.waitFor(t, " --More-- ")
+	sh.send(" ")
+	sh.waitFor(t, " --More-- ")
+	sh.send("q")
+	out := sh.waitFor(t, "R1#")
+	if strings.Contains(out, "5") {
+		t.Errorf("Expected output to stop at the pager, got %q", out)
+	}
+
+	sh.send("terminal length 0\n")
+	sh.waitFor(t, "R1#")
+	sh.send("show log\n")
+	out = sh.waitFor(t, "R1#")
+	if strings.Contains(out, "More") || !strings.Contains(out, "5\r\n") {
+		t.Errorf("Expected unpaginated output, got %q", out)
+	}
+}
+
+// TestJuniperExecCommit tests candidate configuration shared across exec requests
+func TestJuniperExecCommit(t *testing.T) {
+	server := startServer(t, Config{
+		Vendor:        "juniper",
+		RunningConfig: "set system host-name vmx1\nset interfaces ge-0/0/0 unit 0 family inet address 10.0.0.1/30\n",
+	})
+	client := connect(t, server)
+
+	stdout, _, err := execute(t, client, "configure; set system host-name vmx2; commit check; exit")
+	if err != nil || !strings.Contains(stdout, "configuration check succeeds") {
+		t.Fatalf("Unexpected commit check result: %q, %v", stdout, err)
+	}
+	execute(t, client, "configure; delete interfaces ge-0/0/0; exit")
+
+	if strings.Contains(server.RunningConfig(), "vmx2") {
+		t.Error("Changes should not be applied before commit")
+	}
+
+	if _, _, err := execute(t, client, "configure; commit and-quit"); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	stdout, _, _ = execute(t, client, "show configuration")
+	if stdout != "set system host-name vmx1\nset system host-name vmx2\n" {
+		t.Errorf("Unexpected committed configuration: %q", stdout)
+	}
+}
+
+// TestJuniperShell tests the operational and configuration prompts
+func TestJuniperShell(t *testing.T) {
+	server := startServer(t, Config{Vendor: "juniper", Hostname: "vmx1"})
+	sh := startShell(t, connect(t, server))
+
+	sh.waitFor(t, "admin@vmx1> ")
+	sh.send("configure\n")
+	sh.waitFor(t, "[edit]\r\nadmin@vmx1# ")
+	sh.send("set system ntp server 10.0.0.10\n")
+	sh.waitFor(t, "admin@vmx1# ")
+	sh.send("commit and-quit\n")
+	sh.waitFor(t, "admin@vmx1> ")
+
+	if server.RunningConfig() != "set system ntp server 10.0.0.10\n" {
+		t.Errorf("Unexpected running config: %q", server.RunningConfig())
+	}
+}
+
+// TestUnquote tests reversing client-side shell quoting
+func TestUnquote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"'/flash/image.bin'", "/flash/image.bin"},
+		{`'it'\''s'`, "it's"},
+		{"plain", "plain"},
+	}
+
+	for _, tt := range tests {
+		if got := unquote(tt.input); got != tt.expected {
+			t.Errorf("unquote(%s): expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package sshtest
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// shell runs an interactive CLI on the channel until the client exits or disconnects
+// Cisco shells start in user mode when an enable password is configured
+func (s *Server) shell(conn *ssh.ServerConn, channel ssh.Channel, pty bool) uint32 {
+	cli := newDeviceCLI(s)
+	if s.cfg.Vendor == "cisco" && s.cfg.EnablePassword != "" {
+		cli.mode = modeUser
+	}
+
+	term := &terminal{
+		channel:   channel,
+		reader:    bufio.NewReader(channel),
+		pty:       pty,
+		lineDelay: s.cfg.LineDelay,
+		pager:     "--More--",
+	}
+	if s.cfg.Vendor == "juniper" {
+		term.pager = "---(more)---"
+	}
+
+	term.write(cli.prompt())
+	for {
+		line, err := term.readLine()
+		if err != nil {
+			return 0
+		}
+		term.echo(line)
+
+		if strings.TrimSpace(line) == "" {
+			// Blank lines only matter inside configuration blocks
+			if cli.mode == modeConfig {
+				cli.execute(line)
+			}
+			term.write(cli.prompt())
+			continue
+		}
+
+		if s.recordCommand(conn, line) {
+			return 0
+		}
+
+		result := cli.execute(strings.TrimRight(line, " "))
+		if result.password {
+			term.write("Password: ")
+			password, err := term.readLine()
+			if err != nil {
+				return 0
+			}
+			term.write("\n")
+			result = cli.enable(password)
+		}
+
+		pageLines := 0
+		if cli.paging {
+			pageLines = s.cfg.PageLines
+		}
+		if err := term.writeOutput(result.output, pageLines); err != nil {
+			return 0
+		}
+
+		if result.exit {
+			return 0
+		}
+		term.write(cli.prompt())
+	}
+}
+
+// terminal handles line discipline for an interactive channel
+type terminal struct {
+	channel   ssh.Channel
+	reader    *bufio.Reader
+	pty       bool
+	lineDelay time.Duration
+	pager     string
+}
+
+// readLine reads one line of input without its terminator
+func (t *terminal) readLine() (string, error) {
+	line, err := t.reader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// echo repeats the input back, as a device does when a PTY is allocated
+func (t *terminal) echo(line string) {
+	if t.pty {
+		t.write(line + "\n")
+	}
+}
+
+// write sends text, translating newlines for a PTY
+func (t *terminal) write(text string) error {
+	if t.pty {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	_, err := io.WriteString(t.channel, text)
+	return err
+}
+
+// writeOutput sends command output line by line, pausing at the pager every pageLines lines
+// A "q" at the pager discards the rest of the output
+func (t *terminal) writeOutput(output string, pageLines int) error {
+	lines := strings.SplitAfter(output, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	for i, line := range lines {
+		if pageLines > 0 && i > 0 && i%pageLines == 0 {
+			if err := t.write(" " + t.pager + " "); err != nil {
+				return err
+			}
+			key, err := t.reader.ReadByte()
+			if err != nil {
+				return err
+			}
+			if key == 'q' {
+				return t.write("\n")
+			}
+		}
+
+		time.Sleep(t.lineDelay)
+		if err := t.write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package securecom
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing breaks a request down into its phases
+// Phases that did not happen, such as DNS for an IP address or a reused connection, are zero
+type Timing struct {
+	DNSLookup        time.Duration
+	TCPConnect       time.Duration
+	TLSHandshake     time.Duration
+	ServerProcessing time.Duration // From request written to first response byte
+	TimeToFirstByte  time.Duration // From request start to first response byte
+	ContentTransfer  time.Duration
+	Total            time.Duration
+	ConnectionReused bool
+}
+
+// MarshalJSON exports durations as milliseconds
+func (t Timing) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		DNSLookup        float64 `json:"dns_lookup_ms"`
+		TCPConnect       float64 `json:"tcp_connect_ms"`
+		TLSHandshake     float64 `json:"tls_handshake_ms"`
+		ServerProcessing float64 `json:"server_processing_ms"`
+		TimeToFirstByte  float64 `json:"time_to_first_byte_ms"`
+		ContentTransfer  float64 `json:"content_transfer_ms"`
+		Total            float64 `json:"total_ms"`
+		ConnectionReused bool    `json:"connection_reused"`
+	}{
+		DNSLookup:        milliseconds(t.DNSLookup),
+		TCPConnect:       milliseconds(t.TCPConnect),
+		TLSHandshake:     milliseconds(t.TLSHandshake),
+		ServerProcessing: milliseconds(t.ServerProcessing),
+		TimeToFirstByte:  milliseconds(t.TimeToFirstByte),
+		ContentTransfer:  milliseconds(t.ContentTransfer),
+		Total:            milliseconds(t.Total),
+		ConnectionReused: t.ConnectionReused,
+	})
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// PrintTiming prints the duration of each request phase
+func PrintTiming(t Timing) {
+	fmt.Printf("  DNS Lookup:        %v\n", t.DNSLookup)
+	fmt.Printf("  TCP Connect:       %v\n", t.TCPConnect)
+	fmt.Printf("  TLS Handshake:     %v\n", t.TLSHandshake)
+	fmt.Printf("  Server Processing: %v\n", t.ServerProcessing)
+	fmt.Printf("  Content Transfer:  %v\n", t.ContentTransfer)
+	fmt.Printf("  Time To 1st Byte:  %v\n", t.TimeToFirstByte)
+	fmt.Printf("  Total:             %v\n", t.Total)
+	if t.ConnectionReused {
+		fmt.Println("  (connection reused)")
+	}
+}
+
+// timingTracer records httptrace events, the last request wins when redirects are followed
+type timingTracer struct {
+	mu sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+func newTimingTracer(start time.Time) *timingTracer {
+	return &timingTracer{start: start}
+}
+
+// record stores the time of an event under the lock
+func (tr *timingTracer) record(field *time.Time) {
+	tr.mu.Lock()
+	*field = time.Now()
+	tr.mu.Unlock()
+}
+
+// ClientTrace returns the hooks to attach to the request context
+func (tr *timingTracer) ClientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { tr.record(&tr.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { tr.record(&tr.dnsDone) },
+		ConnectStart: func(network, addr string) {
+			tr.mu.Lock()
+			// Dual-stack dialing may start several connections, keep the first
+			if tr.connectStart.IsZero() || !tr.connectDone.IsZero() {
+				tr.connectStart = time.Now()
+				tr.connectDone = time.Time{}
+			}
+			tr.mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err == nil {
+				tr.record(&tr.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() { tr.record(&tr.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { tr.record(&tr.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			tr.mu.Lock()
+			tr.reused = info.Reused
+			tr.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { tr.record(&tr.wroteRequest) },
+		GotFirstResponseByte: func() { tr.record(&tr.firstByte) },
+	}
+}
+
+// Timing computes the phase durations, with end marking the completed body read
+func (tr *timingTracer) Timing(end time.Time) Timing {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	t := Timing{
+		DNSLookup:        between(tr.dnsStart, tr.dnsDone),
+		TCPConnect:       between(tr.connectStart, tr.connectDone),
+		TLSHandshake:     between(tr.tlsStart, tr.tlsDone),
+		ServerProcessing: between(tr.wroteRequest, tr.firstByte),
+		TimeToFirstByte:  between(tr.start, tr.firstByte),
+		ContentTransfer:  between(tr.firstByte, end),
+		Total:            end.Sub(tr.start),
+		ConnectionReused: tr.reused,
+	}
+	if tr.reused {
+		t.DNSLookup, t.TCPConnect, t.TLSHandshake = 0, 0, 0
+	}
+	return t
+}
+
+// between returns the duration from start to end, zero when either is missing
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
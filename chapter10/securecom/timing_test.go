@@ -0,0 +1,107 @@
+package securecom
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestBetween tests phase durations with missing or out of order events
+func TestBetween(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		end      time.Time
+		expected time.Duration
+	}{
+		{"Both set", base, base.Add(15 * time.Millisecond), 15 * time.Millisecond},
+		{"Missing start", time.Time{}, base, 0},
+		{"Missing end", base, time.Time{}, 0},
+		{"End before start", base, base.Add(-time.Second), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := between(tt.start, tt.end); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestTimingJSON tests that timing and status export durations in milliseconds
+func TestTimingJSON(t *testing.T) {
+	status := &ServerStatus{
+		Hostname:     "example.com",
+		IsAlive:      true,
+		StatusCode:   200,
+		ResponseTime: 120 * time.Millisecond,
+		Timing: Timing{
+			DNSLookup:        2500 * time.Microsecond,
+			ServerProcessing: 80 * time.Millisecond,
+			Total:            120 * time.Millisecond,
+		},
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded struct {
+		Healthy      bool    `json:"healthy"`
+		ResponseTime float64 `json:"response_time_ms"`
+		Timing       struct {
+			DNSLookup        float64 `json:"dns_lookup_ms"`
+			ServerProcessing float64 `json:"server_processing_ms"`
+			Total            float64 `json:"total_ms"`
+		} `json:"timing"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if !decoded.Healthy || decoded.ResponseTime != 120 {
+		t.Errorf("Unexpected status fields: %s", data)
+	}
+	if decoded.Timing.DNSLookup != 2.5 || decoded.Timing.ServerProcessing != 80 || decoded.Timing.Total != 120 {
+		t.Errorf("Unexpected timing fields: %s", data)
+	}
+}
+
+// TestCheckServerTiming tests the phase breakdown against a slow local server
+func TestCheckServerTiming(t *testing.T) {
+	delay := 50 * time.Millisecond
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	checker := NewHTTPSChecker("127.0.0.1", port, 5*time.Second, false)
+	status := checker.CheckServer()
+	if !status.IsAlive {
+		t.Fatalf("Expected server to be alive, got %v", status.Error)
+	}
+
+	timing := status.Timing
+	if timing.DNSLookup != 0 {
+		t.Errorf("Expected no DNS lookup for an IP address, got %v", timing.DNSLookup)
+	}
+	if timing.TCPConnect <= 0 || timing.TLSHandshake <= 0 {
+		t.Errorf("Expected connect and handshake durations, got %+v", timing)
+	}
+	if timing.ServerProcessing < delay {
+		t.Errorf("Expected server processing of at least %v, got %v", delay, timing.ServerProcessing)
+	}
+	if timing.TimeToFirstByte < timing.ServerProcessing || timing.Total < timing.TimeToFirstByte {
+		t.Errorf("Expected phases to add up, got %+v", timing)
+	}
+}
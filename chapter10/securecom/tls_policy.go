@@ -0,0 +1,281 @@
+package securecom
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Severity grades a finding
+type Severity string
+
+const (
+	SeverityOK       Severity = "ok"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// rank orders severities so the worst one can be picked
+func (s Severity) rank() int {
+	switch s {
+	case SeverityCritical:
+		return 2
+	case SeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Finding is the named result of one policy check
+type Finding struct {
+	Name     string   `json:"name"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// WorstSeverity returns the most severe level among the findings, ok when there are none
+func WorstSeverity(findings []Finding) Severity {
+	worst := SeverityOK
+	for _, f := range findings {
+		if f.Severity.rank() > worst.rank() {
+			worst = f.Severity
+		}
+	}
+	return worst
+}
+
+// TLSPolicy describes the TLS requirements evaluated against each checked server
+type TLSPolicy struct {
+	ExpiryWarningDays   int
+	ExpiryCriticalDays  int
+	MinVersion          uint16
+	ForbiddenCiphers    []uint16
+	CheckHostname       bool
+	Roots               *x509.CertPool
+	RequireOCSPStapling bool
+}
+
+// NewTLSPolicy creates a policy warning 30 days and alerting 7 days before expiry,
+// requiring TLS 1.2, rejecting insecure cipher suites and checking the hostname
+func NewTLSPolicy() *TLSPolicy {
+	var forbidden []uint16
+	for _, suite := range tls.InsecureCipherSuites() {
+		forbidden = append(forbidden, suite.ID)
+	}
+
+	return &TLSPolicy{
+		ExpiryWarningDays:  30,
+		ExpiryCriticalDays: 7,
+		MinVersion:         tls.VersionTLS12,
+		ForbiddenCiphers:   forbidden,
+		CheckHostname:      true,
+	}
+}
+
+// Method Probe opens its own TLS connection to evaluate the policy, accepting every version
+// and cipher suite and skipping verification, so the servers the policy flags still complete
+// the handshake; the chain is verified by Evaluate instead
+// The connection goes through the proxy and dialer of the transport, which may be nil to
+// connect directly. The returned state is nil when the connection or handshake failed,
+// both reported as critical findings
+func (p *TLSPolicy) Probe(address string, transport *http.Transport, timeout time.Duration, now time.Time) (*tls.ConnectionState, []Finding) {
+	config := &tls.Config{
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS10,
+		CipherSuites:       allCipherSuites(),
+	}
+	if transport != nil && transport.TLSClientConfig != nil {
+		base := transport.TLSClientConfig
+		config.ServerName = base.ServerName
+		config.Certificates = base.Certificates
+		config.GetClientCertificate = base.GetClientCertificate
+	}
+	name := config.ServerName
+	if name == "" {
+		name, _, _ = net.SplitHostPort(address)
+		config.ServerName = name
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	conn, err := dialTransport(ctx, transport, address)
+	if err != nil {
+		return nil, []Finding{{Name: "connection", Severity: SeverityCritical,
+			Message: fmt.Sprintf("TLS policy probe failed to connect: %v", err)}}
+	}
+	defer conn.Close()
+
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, []Finding{{Name: "handshake", Severity: SeverityCritical,
+			Message: fmt.Sprintf("TLS handshake failed: %v", err)}}
+	}
+	state := tlsConn.ConnectionState()
+	return &state, p.Evaluate(name, &state, now)
+}
+
+// allCipherSuites lists every suite Go implements, so weak suites can be negotiated and reported
+func allCipherSuites() []uint16 {
+	var ids []uint16
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		ids = append(ids, suite.ID)
+	}
+	return ids
+}
+
+// Method Evaluate checks the negotiated connection against the policy
+func (p *TLSPolicy) Evaluate(hostname string, state *tls.ConnectionState, now time.Time) []Finding {
+	var findings []Finding
+
+	if len(state.PeerCertificates) == 0 {
+		return []Finding{{Name: "certificate", Severity: SeverityCritical, Message: "server presented no certificate"}}
+	}
+	leaf := state.PeerCertificates[0]
+
+	findings = append(findings, p.checkExpiry(leaf, now))
+	findings = append(findings, p.checkVersion(state.Version))
+	findings = append(findings, p.checkCipher(state.CipherSuite))
+
+	if p.CheckHostname {
+		if err := leaf.VerifyHostname(hostname); err != nil {
+			findings = append(findings, Finding{Name: "hostname", Severity: SeverityCritical, Message: err.Error()})
+		} else {
+			findings = append(findings, Finding{Name: "hostname", Severity: SeverityOK,
+				Message: fmt.Sprintf("certificate is valid for %s", hostname)})
+		}
+	}
+
+	findings = append(findings, p.checkChain(state.PeerCertificates, now))
+
+	if p.RequireOCSPStapling {
+		if len(state.OCSPResponse) == 0 {
+			findings = append(findings, Finding{Name: "ocsp-stapling", Severity: SeverityCritical,
+				Message: "server did not staple an OCSP response"})
+		} else {
+			findings = append(findings, Finding{Name: "ocsp-stapling", Severity: SeverityOK,
+				Message: "OCSP response stapled"})
+		}
+	}
+
+	return findings
+}
+
+func (p *TLSPolicy) checkExpiry(leaf *x509.Certificate, now time.Time) Finding {
+	remaining := leaf.NotAfter.Sub(now)
+	days := int(remaining.Hours() / 24)
+
+	switch {
+	case remaining <= 0:
+		return Finding{Name: "cert-expiry", Severity: SeverityCritical,
+			Message: fmt.Sprintf("certificate expired on %s", leaf.NotAfter.Format("2006-01-02"))}
+	case days < p.ExpiryCriticalDays:
+		return Finding{Name: "cert-expiry", Severity: SeverityCritical,
+			Message: fmt.Sprintf("certificate expires in %d days", days)}
+	case days < p.ExpiryWarningDays:
+		return Finding{Name: "cert-expiry", Severity: SeverityWarning,
+			Message: fmt.Sprintf("certificate expires in %d days", days)}
+	default:
+		return Finding{Name: "cert-expiry", Severity: SeverityOK,
+			Message: fmt.Sprintf("certificate valid for %d more days", days)}
+	}
+}
+
+func (p *TLSPolicy) checkVersion(version uint16) Finding {
+	if p.MinVersion != 0 && version < p.MinVersion {
+		return Finding{Name: "tls-version", Severity: SeverityCritical,
+			Message: fmt.Sprintf("%s is below the minimum %s", getTLSVersionString(version), getTLSVersionString(p.MinVersion))}
+	}
+	return Finding{Name: "tls-version", Severity: SeverityOK, Message: getTLSVersionString(version)}
+}
+
+func (p *TLSPolicy) checkCipher(cipher uint16) Finding {
+	for _, forbidden := range p.ForbiddenCiphers {
+		if cipher == forbidden {
+			return Finding{Name: "cipher-suite", Severity: SeverityCritical,
+				Message: fmt.Sprintf("forbidden cipher suite %s", tls.CipherSuiteName(cipher))}
+		}
+	}
+	return Finding{Name: "cipher-suite", Severity: SeverityOK, Message: tls.CipherSuiteName(cipher)}
+}
+
+// checkChain verifies the presented chain against Roots, or the system pool when Roots is nil
+func (p *TLSPolicy) checkChain(certs []*x509.Certificate, now time.Time) Finding {
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         p.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	if err != nil {
+		return Finding{Name: "chain", Severity: SeverityCritical, Message: err.Error()}
+	}
+	return Finding{Name: "chain", Severity: SeverityOK, Message: "certificate chain is trusted"}
+}
+
+// LoadCABundle reads PEM encoded CA certificates from a file
+func LoadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
+
+// ParseTLSVersion converts "1.0" to "1.3" into a TLS version constant
+func ParseTLSVersion(version string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(version), "tls") {
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unknown TLS version: %s", version)
+	}
+}
+
+// ParseCipherSuites converts a comma-separated list of Go cipher suite names into IDs
+func ParseCipherSuites(names string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		known[suite.Name] = suite.ID
+	}
+
+	var ids []uint16
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cipher suite: %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,557 @@
+package securecom
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testCA is a throwaway certificate authority for issuing test server certificates
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+// issue creates a server or client certificate for 127.0.0.1 and the given DNS name
+func (ca *testCA) issue(t *testing.T, dnsName string, notAfter time.Time) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// findingByName returns the finding with the given name
+func findingByName(findings []Finding, name string) (Finding, bool) {
+	for _, f := range findings {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Finding{}, false
+}
+
+// TestTLSPolicyExpiry tests the warning and critical expiry thresholds
+func TestTLSPolicyExpiry(t *testing.T) {
+	ca := newTestCA(t)
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		notAfter time.Time
+		expected Severity
+	}{
+		{"Valid", now.Add(90 * 24 * time.Hour), SeverityOK},
+		{"Warning", now.Add(20 * 24 * time.Hour), SeverityWarning},
+		{"Critical", now.Add(3 * 24 * time.Hour), SeverityCritical},
+		{"Expired", now.Add(-time.Minute), SeverityCritical},
+	}
+
+	policy := NewTLSPolicy()
+	policy.Roots = ca.pool
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := ca.issue(t, "device.example.com", tt.notAfter)
+			state := &tls.ConnectionState{
+				Version:          tls.VersionTLS13,
+				CipherSuite:      tls.TLS_AES_128_GCM_SHA256,
+				PeerCertificates: []*x509.Certificate{cert.Leaf},
+			}
+
+			finding, ok := findingByName(policy.Evaluate("device.example.com", state, now), "cert-expiry")
+			if !ok {
+				t.Fatal("Expected cert-expiry finding")
+			}
+			if finding.Severity != tt.expected {
+				t.Errorf("Expected %s, got %s (%s)", tt.expected, finding.Severity, finding.Message)
+			}
+		})
+	}
+}
+
+// TestTLSPolicyEvaluate tests version, cipher, hostname, chain and OCSP findings
+func TestTLSPolicyEvaluate(t *testing.T) {
+	ca := newTestCA(t)
+	other := newTestCA(t)
+	cert := ca.issue(t, "device.example.com", time.Now().Add(90*24*time.Hour))
+
+	good := func() *tls.ConnectionState {
+		return &tls.ConnectionState{
+			Version:          tls.VersionTLS13,
+			CipherSuite:      tls.TLS_AES_128_GCM_SHA256,
+			PeerCertificates: []*x509.Certificate{cert.Leaf},
+			OCSPResponse:     []byte{0x30},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		hostname string
+		modify   func(*tls.ConnectionState, *TLSPolicy)
+		finding  string
+		expected Severity
+	}{
+		{"Version OK", "device.example.com", nil, "tls-version", SeverityOK},
+		{"Old version", "device.example.com", func(s *tls.ConnectionState, p *TLSPolicy) {
+			s.Version = tls.VersionTLS11
+		}, "tls-version", SeverityCritical},
+		{"Insecure cipher", "device.example.com", func(s *tls.ConnectionState, p *TLSPolicy) {
+			s.CipherSuite = tls.TLS_RSA_WITH_RC4_128_SHA
+		}, "cipher-suite", SeverityCritical},
+		{"Custom forbidden cipher", "device.example.com", func(s *tls.ConnectionState, p *TLSPolicy) {
+			s.Version = tls.VersionTLS12
+			s.CipherSuite = tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA
+			p.ForbiddenCiphers = append(p.ForbiddenCiphers, tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA)
+		}, "cipher-suite", SeverityCritical},
+		{"Hostname match", "device.example.com", nil, "hostname", SeverityOK},
+		{"Hostname mismatch", "other.example.com", nil, "hostname", SeverityCritical},
+		{"Trusted chain", "device.example.com", nil, "chain", SeverityOK},
+		{"Untrusted chain", "device.example.com", func(s *tls.ConnectionState, p *TLSPolicy) {
+			p.Roots = other.pool
+		}, "chain", SeverityCritical},
+		{"OCSP stapled", "device.example.com", nil, "ocsp-stapling", SeverityOK},
+		{"OCSP missing", "device.example.com", func(s *tls.ConnectionState, p *TLSPolicy) {
+			s.OCSPResponse = nil
+		}, "ocsp-stapling", SeverityCritical},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewTLSPolicy()
+			policy.Roots = ca.pool
+			policy.RequireOCSPStapling = true
+
+			state := good()
+			if tt.modify != nil {
+				tt.modify(state, policy)
+			}
+
+			finding, ok := findingByName(policy.Evaluate(tt.hostname, state, time.Now()), tt.finding)
+			if !ok {
+				t.Fatalf("Expected %s finding", tt.finding)
+			}
+			if finding.Severity != tt.expected {
+				t.Errorf("Expected %s, got %s (%s)", tt.expected, finding.Severity, finding.Message)
+			}
+		})
+	}
+}
+
+// TestTLSPolicyNoCertificate tests a connection without peer certificates
+func TestTLSPolicyNoCertificate(t *testing.T) {
+	findings := NewTLSPolicy().Evaluate("example.com", &tls.ConnectionState{}, time.Now())
+
+	if len(findings) != 1 || findings[0].Severity != SeverityCritical {
+		t.Errorf("Expected a single critical finding, got %+v", findings)
+	}
+}
+
+// TestWorstSeverity tests picking the most severe finding
+func TestWorstSeverity(t *testing.T) {
+	if got := WorstSeverity(nil); got != SeverityOK {
+		t.Errorf("Expected ok for no findings, got %s", got)
+	}
+
+	findings := []Finding{
+		{Name: "a", Severity: SeverityOK},
+		{Name: "b", Severity: SeverityWarning},
+	}
+	if got := WorstSeverity(findings); got != SeverityWarning {
+		t.Errorf("Expected warning, got %s", got)
+	}
+
+	findings = append(findings, Finding{Name: "c", Severity: SeverityCritical})
+	if got := WorstSeverity(findings); got != SeverityCritical {
+		t.Errorf("Expected critical, got %s", got)
+	}
+}
+
+// TestParseTLSVersion tests TLS version parsing
+func TestParseTLSVersion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected uint16
+		wantErr  bool
+	}{
+		{"1.2", tls.VersionTLS12, false},
+		{"TLS1.3", tls.VersionTLS13, false},
+		{"1.0", tls.VersionTLS10, false},
+		{"2.0", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTLSVersion(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.expected {
+			t.Errorf("ParseTLSVersion(%q): expected %d (err %v), got %d (%v)", tt.input, tt.expected, tt.wantErr, got, err)
+		}
+	}
+}
+
+// TestParseCipherSuites tests cipher suite name parsing
+func TestParseCipherSuites(t *testing.T) {
+	ids, err := ParseCipherSuites("TLS_RSA_WITH_RC4_128_SHA, TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != tls.TLS_RSA_WITH_RC4_128_SHA {
+		t.Errorf("Unexpected cipher suites: %v", ids)
+	}
+
+	if ids, err := ParseCipherSuites(""); err != nil || len(ids) != 0 {
+		t.Errorf("Expected empty list, got %v, %v", ids, err)
+	}
+
+	if _, err := ParseCipherSuites("TLS_BOGUS"); err == nil {
+		t.Error("Expected error for unknown cipher suite, got nil")
+	}
+}
+
+// TestLoadCABundle tests reading a PEM CA bundle
+func TestLoadCABundle(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+
+	bundle := filepath.Join(dir, "ca.pem")
+	os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0644)
+
+	if _, err := LoadCABundle(bundle); err != nil {
+		t.Errorf("LoadCABundle failed: %v", err)
+	}
+
+	empty := filepath.Join(dir, "empty.pem")
+	os.WriteFile(empty, []byte("not a certificate"), 0644)
+	if _, err := LoadCABundle(empty); err == nil {
+		t.Error("Expected error for bundle without certificates, got nil")
+	}
+
+	if _, err := LoadCABundle(filepath.Join(dir, "missing.pem")); err == nil {
+		t.Error("Expected error for missing bundle, got nil")
+	}
+}
+
+// TestCheckServerWithTLSPolicy tests that policy findings are recorded in the status
+func TestCheckServerWithTLSPolicy(t *testing.T) {
+	ca := newTestCA(t)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{ca.issue(t, "device.example.com", time.Now().Add(3*24*time.Hour))},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	policy := NewTLSPolicy()
+	policy.Roots = ca.pool
+
+	checker := NewHTTPSChecker("127.0.0.1", server.Listener.Addr().(*net.TCPAddr).Port, 5*time.Second, true)
+	checker.Policy = policy
+
+	status := checker.CheckServer()
+	if !status.IsAlive {
+		t.Fatalf("Expected server to be alive with the custom CA, got %v", status.Error)
+	}
+
+	if status.CipherSuite == "" {
+		t.Error("Expected cipher suite to be recorded")
+	}
+
+	expiry, _ := findingByName(status.Findings, "cert-expiry")
+	if expiry.Severity != SeverityCritical {
+		t.Errorf("Expected critical expiry finding, got %+v", expiry)
+	}
+
+	chain, _ := findingByName(status.Findings, "chain")
+	if chain.Severity != SeverityOK {
+		t.Errorf("Expected trusted chain, got %+v", chain)
+	}
+
+	if status.Healthy() {
+		t.Error("Expected status with critical findings to be unhealthy")
+	}
+}
+
+// TestCheckServerTLSPolicyOldVersion tests that a TLS 1.1 server, which the HTTP client
+// refuses, is still reported by the policy
+func TestCheckServerTLSPolicyOldVersion(t *testing.T) {
+	ca := newTestCA(t)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{ca.issue(t, "device.example.com", time.Now().Add(365*24*time.Hour))},
+		MinVersion:   tls.VersionTLS10,
+		MaxVersion:   tls.VersionTLS11,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	policy := NewTLSPolicy()
+	policy.Roots = ca.pool
+	policy.CheckHostname = false
+
+	checker := NewHTTPSChecker("127.0.0.1", server.Listener.Addr().(*net.TCPAddr).Port, 5*time.Second, true)
+	checker.Policy = policy
+
+	status := checker.CheckServer()
+	if status.IsAlive {
+		t.Fatal("Expected the HTTP client to refuse TLS 1.1")
+	}
+
+	version, ok := findingByName(status.Findings, "tls-version")
+	if !ok || version.Severity != SeverityCritical {
+		t.Errorf("Expected critical tls-version finding, got %+v", status.Findings)
+	}
+	if status.TLSVersion != "TLS 1.1" {
+		t.Errorf("Expected TLS 1.1 to be recorded, got %q", status.TLSVersion)
+	}
+
+	chain, _ := findingByName(status.Findings, "chain")
+	if chain.Severity != SeverityOK {
+		t.Errorf("Expected trusted chain, got %+v", chain)
+	}
+}
+
+// TestCheckServerTLSPolicyUntrustedCA tests that an untrusted chain is a policy finding
+// and not only a transport error
+func TestCheckServerTLSPolicyUntrustedCA(t *testing.T) {
+	trusted := newTestCA(t)
+	unknown := newTestCA(t)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{unknown.issue(t, "device.example.com", time.Now().Add(365*24*time.Hour))},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	policy := NewTLSPolicy()
+	policy.Roots = trusted.pool
+
+	checker := NewHTTPSChecker("127.0.0.1", server.Listener.Addr().(*net.TCPAddr).Port, 5*time.Second, true)
+	checker.Policy = policy
+
+	status := checker.CheckServer()
+	if status.IsAlive {
+		t.Fatal("Expected the HTTP client to refuse the untrusted certificate")
+	}
+
+	chain, ok := findingByName(status.Findings, "chain")
+	if !ok || chain.Severity != SeverityCritical {
+		t.Errorf("Expected critical chain finding, got %+v", status.Findings)
+	}
+	if status.TLSVersion == "" {
+		t.Error("Expected the negotiated TLS version to be recorded")
+	}
+}
+
+// TestTLSPolicyProbeHandshakeFailure tests that a failed handshake is a finding
+func TestTLSPolicyProbeHandshakeFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+			conn.Close()
+		}
+	}()
+
+	state, findings := NewTLSPolicy().Probe(listener.Addr().String(), nil, 5*time.Second, time.Now())
+	if state != nil {
+		t.Errorf("Expected no connection state, got %+v", state)
+	}
+	handshake, ok := findingByName(findings, "handshake")
+	if !ok || handshake.Severity != SeverityCritical {
+		t.Errorf("Expected critical handshake finding, got %+v", findings)
+	}
+
+	listener.Close()
+	_, findings = NewTLSPolicy().Probe(listener.Addr().String(), nil, time.Second, time.Now())
+	connection, ok := findingByName(findings, "connection")
+	if !ok || connection.Severity != SeverityCritical {
+		t.Errorf("Expected critical connection finding for an unreachable server, got %+v", findings)
+	}
+}
+
+// TestCheckServerTLSPolicyTiming tests that the policy probe connection is not counted in the timing
+func TestCheckServerTLSPolicyTiming(t *testing.T) {
+	ca := newTestCA(t)
+	_, port := startTLSServer(t, ca, "api.internal", nil)
+
+	var dials int32
+	transport := NewTransport(false)
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		// Only the probe dials a second time
+		if atomic.AddInt32(&dials, 1) > 1 {
+			time.Sleep(300 * time.Millisecond)
+		}
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	policy := NewTLSPolicy()
+	policy.Roots = ca.pool
+	policy.CheckHostname = false
+
+	checker := NewHTTPSChecker("127.0.0.1", port, 5*time.Second, false)
+	checker.Transport = transport
+	checker.Policy = policy
+
+	status := checker.CheckServer()
+	if !status.IsAlive {
+		t.Fatalf("Expected server to be alive, got %v", status.Error)
+	}
+	if atomic.LoadInt32(&dials) != 2 {
+		t.Fatalf("Expected the probe to use the transport dialer, got %d dials", atomic.LoadInt32(&dials))
+	}
+	if _, ok := findingByName(status.Findings, "tls-version"); !ok {
+		t.Errorf("Expected policy findings, got %+v", status.Findings)
+	}
+	if status.Timing.ContentTransfer >= 300*time.Millisecond || status.Timing.Total >= 300*time.Millisecond {
+		t.Errorf("Expected the probe to be left out of the timing, got %+v", status.Timing)
+	}
+}
+
+// TestCheckServerTLSPolicyProxy tests that the policy probe goes through the check proxy
+// and that a proxy it cannot use is a finding rather than a skipped policy
+func TestCheckServerTLSPolicyProxy(t *testing.T) {
+	ca := newTestCA(t)
+	_, port := startTLSServer(t, ca, "api.internal", nil)
+	caFile := writeCAFile(t, ca)
+
+	httpProxy, httpTunnels := startConnectProxy(t)
+	socksAddr, socksTunnels := startSOCKS5Proxy(t)
+
+	tests := []struct {
+		name    string
+		proxy   string
+		tunnels *int32
+	}{
+		{"HTTP proxy", httpProxy.URL, httpTunnels},
+		{"SOCKS5 proxy", "socks5://" + socksAddr, socksTunnels},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := TransportConfig{VerifyTLS: true, CAFile: caFile, ServerName: "api.internal", Proxy: tt.proxy}.Transport()
+			if err != nil {
+				t.Fatalf("Transport failed: %v", err)
+			}
+			policy := NewTLSPolicy()
+			policy.Roots = ca.pool
+
+			checker := NewHTTPSChecker("127.0.0.1", port, 5*time.Second, true)
+			checker.Transport = transport
+			checker.Policy = policy
+
+			status := checker.CheckServer()
+			if !status.IsAlive {
+				t.Fatalf("Expected check through proxy to succeed, got %v", status.Error)
+			}
+			if atomic.LoadInt32(tt.tunnels) != 2 {
+				t.Errorf("Expected the check and the probe to tunnel through the proxy, got %d tunnels",
+					atomic.LoadInt32(tt.tunnels))
+			}
+			if _, ok := findingByName(status.Findings, "connection"); ok {
+				t.Errorf("Expected the probe to connect, got %+v", status.Findings)
+			}
+		})
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadProxy := "http://" + listener.Addr().String()
+	listener.Close()
+
+	transport, err := TransportConfig{VerifyTLS: true, CAFile: caFile, Proxy: deadProxy}.Transport()
+	if err != nil {
+		t.Fatalf("Transport failed: %v", err)
+	}
+	checker := NewHTTPSChecker("127.0.0.1", port, 5*time.Second, true)
+	checker.Transport = transport
+	checker.Policy = NewTLSPolicy()
+
+	status := checker.CheckServer()
+	connection, ok := findingByName(status.Findings, "connection")
+	if !ok || connection.Severity != SeverityCritical || status.Healthy() {
+		t.Errorf("Expected a critical connection finding through a dead proxy, got %+v", status.Findings)
+	}
+}
@@ -0,0 +1,566 @@
+package securecom
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// ProgressFunc is called as data is transferred with the bytes done so far and the file size
+type ProgressFunc func(transferred, total int64)
+
+// TransferOptions configures Upload and Download
+type TransferOptions struct {
+	// Progress receives transfer progress, including bytes skipped by a resume
+	Progress ProgressFunc
+	// Resume continues a partial transfer instead of starting over (SFTP only)
+	Resume bool
+	// Verify compares SHA-256 checksums of both copies after the transfer
+	Verify bool
+	// ChecksumCommand hashes the remote file for Verify, with %s replaced by the remote path
+	// Defaults to sha256sum, see ChecksumCommands for network operating systems without a shell
+	ChecksumCommand string
+	// ForceSCP skips SFTP and uses the SCP protocol directly
+	ForceSCP bool
+	// Mode is the file mode for SCP uploads, 0644 when zero
+	Mode os.FileMode
+}
+
+// ChecksumCommands are the SHA-256 commands of common network operating systems
+var ChecksumCommands = map[string]string{
+	"nxos":  "show file %s sha256sum",
+	"ios":   "verify /sha256 %s",
+	"junos": "file checksum sha-256 %s",
+}
+
+// sha256Pattern finds the hex digest in the output of a checksum command
+var sha256Pattern = regexp.MustCompile(`\b[0-9a-fA-F]{64}\b`)
+
+// TransferResult describes a completed file transfer
+type TransferResult struct {
+	Protocol    string
+	Size        int64
+	Transferred int64
+	ResumedAt   int64
+	Checksum    string
+	Duration    time.Duration
+}
+
+// Method Upload copies a local file to the device over SFTP, falling back to SCP
+// when the device does not offer the SFTP subsystem
+func (c *SSHClient) Upload(client *ssh.Client, localPath, remotePath string, opts *TransferOptions) (*TransferResult, error) {
+	if opts == nil {
+		opts = &TransferOptions{}
+	}
+
+	fmt.Printf("Uploading %s to %s:%s\n", localPath, c.Hostname, remotePath)
+
+	if !opts.ForceSCP {
+		sftpClient, err := sftp.NewClient(client)
+		if err == nil {
+			defer sftpClient.Close()
+			result, err := uploadSFTP(sftpClient, localPath, remotePath, opts)
+			if err != nil {
+				return nil, err
+			}
+			return verifyTransfer(client, localPath, remotePath, result, opts)
+		}
+		fmt.Printf("SFTP unavailable (%v), falling back to SCP\n", err)
+	}
+
+	result, err := uploadSCP(client, localPath, remotePath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return verifyTransfer(client, localPath, remotePath, result, opts)
+}
+
+// Method Download copies a file from the device over SFTP, falling back to SCP
+// when the device does not offer the SFTP subsystem
+func (c *SSHClient) Download(client *ssh.Client, remotePath, localPath string, opts *TransferOptions) (*TransferResult, error) {
+	if opts == nil {
+		opts = &TransferOptions{}
+	}
+
+	fmt.Printf("Downloading %s:%s to %s\n", c.Hostname, remotePath, localPath)
+
+	if !opts.ForceSCP {
+		sftpClient, err := sftp.NewClient(client)
+		if err == nil {
+			defer sftpClient.Close()
+			result, err := downloadSFTP(sftpClient, remotePath, localPath, opts)
+			if err != nil {
+				return nil, err
+			}
+			return verifyTransfer(client, localPath, remotePath, result, opts)
+		}
+		fmt.Printf("SFTP unavailable (%v), falling back to SCP\n", err)
+	}
+
+	result, err := downloadSCP(client, remotePath, localPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return verifyTransfer(client, localPath, remotePath, result, opts)
+}
+
+// verifyTransfer compares the checksum of the local copy with one computed on the device when asked
+// SFTP results are hashed from the local file, which also covers bytes skipped by a resume
+func verifyTransfer(client *ssh.Client, localPath, remotePath string, result *TransferResult, opts *TransferOptions) (*TransferResult, error) {
+	if !opts.Verify {
+		return result, nil
+	}
+
+	if result.Checksum == "" {
+		sum, err := fileChecksum(localPath)
+		if err != nil {
+			return result, err
+		}
+		result.Checksum = sum
+	}
+
+	if err := verifyRemoteChecksum(client, remotePath, result.Checksum, opts.ChecksumCommand); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// uploadSFTP copies a local file to the remote path, resuming from the remote size if asked
+func uploadSFTP(client *sftp.Client, localPath, remotePath string, opts *TransferOptions) (*TransferResult, error) {
+	startTime := time.Now()
+
+	local, err := os.Open(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local file: %v", err)
+	}
+	defer local.Close()
+
+	info, err := local.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat local file: %v", err)
+	}
+	size := info.Size()
+
+	var offset int64
+	if opts.Resume {
+		if remoteInfo, err := client.Stat(remotePath); err == nil && remoteInfo.Size() <= size {
+			offset = remoteInfo.Size()
+		}
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+
+	remote, err := client.OpenFile(remotePath, flags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open remote file: %v", err)
+	}
+	defer remote.Close()
+
+	if _, err := remote.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek remote file: %v", err)
+	}
+	if _, err := local.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek local file: %v", err)
+	}
+
+	written, err := io.Copy(remote, newProgressReader(local, offset, size, opts.Progress))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file: %v", err)
+	}
+
+	result := &TransferResult{
+		Protocol:    "sftp",
+		Size:        size,
+		Transferred: written,
+		ResumedAt:   offset,
+	}
+
+	result.Duration = time.Since(startTime)
+	return result, nil
+}
+
+// downloadSFTP copies a remote file to the local path, resuming from the local size if asked
+// A fresh download replaces the local file only once it is complete
+func downloadSFTP(client *sftp.Client, remotePath, localPath string, opts *TransferOptions) (*TransferResult, error) {
+	startTime := time.Now()
+
+	remote, err := client.Open(remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open remote file: %v", err)
+	}
+	defer remote.Close()
+
+	info, err := remote.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat remote file: %v", err)
+	}
+	size := info.Size()
+
+	var offset int64
+	if opts.Resume {
+		if localInfo, err := os.Stat(localPath); err == nil && localInfo.Size() <= size {
+			offset = localInfo.Size()
+		}
+	}
+
+	// A resumed download appends in place, a fresh one goes to a temporary file so a failed
+	// download leaves any existing copy untouched
+	var local *os.File
+	if offset > 0 {
+		local, err = os.OpenFile(localPath, os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open local file: %v", err)
+		}
+		defer local.Close()
+
+		if _, err := local.Seek(offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to seek local file: %v", err)
+		}
+		if _, err := remote.Seek(offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to seek remote file: %v", err)
+		}
+	} else {
+		local, err = os.CreateTemp(filepath.Dir(localPath), "."+filepath.Base(localPath)+".part-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create local file: %v", err)
+		}
+		defer func() {
+			local.Close()
+			os.Remove(local.Name())
+		}()
+	}
+
+	written, err := io.Copy(local, newProgressReader(remote, offset, size, opts.Progress))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %v", err)
+	}
+
+	if offset == 0 {
+		if err := local.Close(); err != nil {
+			return nil, fmt.Errorf("failed to write local file: %v", err)
+		}
+		if err := os.Chmod(local.Name(), 0644); err != nil {
+			return nil, fmt.Errorf("failed to set local file mode: %v", err)
+		}
+		if err := os.Rename(local.Name(), localPath); err != nil {
+			return nil, fmt.Errorf("failed to create local file: %v", err)
+		}
+	}
+
+	result := &TransferResult{
+		Protocol:    "sftp",
+		Size:        size,
+		Transferred: written,
+		ResumedAt:   offset,
+	}
+
+	result.Duration = time.Since(startTime)
+	return result, nil
+}
+
+// uploadSCP sends a local file with the SCP sink protocol ("scp -t")
+func uploadSCP(client *ssh.Client, localPath, remotePath string, opts *TransferOptions) (*TransferResult, error) {
+	startTime := time.Now()
+
+	local, err := os.Open(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local file: %v", err)
+	}
+	defer local.Close()
+
+	info, err := local.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat local file: %v", err)
+	}
+
+	mode := opts.Mode
+	if mode == 0 {
+		mode = 0644
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdin pipe: %v", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdout pipe: %v", err)
+	}
+
+	if err := session.Start("scp -t " + shellQuote(remotePath)); err != nil {
+		return nil, fmt.Errorf("failed to start scp: %v", err)
+	}
+
+	hash := sha256.New()
+	src := io.TeeReader(local, hash)
+	err = scpSend(stdin, bufio.NewReader(stdout), path.Base(remotePath), mode, info.Size(), src, opts.Progress)
+	stdin.Close()
+	if err != nil {
+		return nil, fmt.Errorf("scp upload failed: %v", err)
+	}
+
+	if err := session.Wait(); err != nil {
+		return nil, fmt.Errorf("scp upload failed: %v", err)
+	}
+
+	return &TransferResult{
+		Protocol:    "scp",
+		Size:        info.Size(),
+		Transferred: info.Size(),
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		Duration:    time.Since(startTime),
+	}, nil
+}
+
+// downloadSCP receives a remote file with the SCP source protocol ("scp -f")
+func downloadSCP(client *ssh.Client, remotePath, localPath string, opts *TransferOptions) (*TransferResult, error) {
+	startTime := time.Now()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdin pipe: %v", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdout pipe: %v", err)
+	}
+
+	if err := session.Start("scp -f " + shellQuote(remotePath)); err != nil {
+		return nil, fmt.Errorf("failed to start scp: %v", err)
+	}
+
+	// Receive into a temporary file so a failed download leaves any existing copy untouched
+	local, err := os.CreateTemp(filepath.Dir(localPath), "."+filepath.Base(localPath)+".part-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create local file: %v", err)
+	}
+	defer func() {
+		local.Close()
+		os.Remove(local.Name())
+	}()
+
+	hash := sha256.New()
+	size, err := scpReceive(stdin, bufio.NewReader(stdout), io.MultiWriter(local, hash), opts.Progress)
+	stdin.Close()
+	if err != nil {
+		return nil, fmt.Errorf("scp download failed: %v", err)
+	}
+
+	if err := session.Wait(); err != nil {
+		return nil, fmt.Errorf("scp download failed: %v", err)
+	}
+
+	if err := local.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write local file: %v", err)
+	}
+	if err := os.Rename(local.Name(), localPath); err != nil {
+		return nil, fmt.Errorf("failed to create local file: %v", err)
+	}
+
+	return &TransferResult{
+		Protocol:    "scp",
+		Size:        size,
+		Transferred: size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		Duration:    time.Since(startTime),
+	}, nil
+}
+
+// scpSend writes a single file to an SCP sink, waiting for an acknowledgement after each step
+func scpSend(w io.Writer, r *bufio.Reader, name string, mode os.FileMode, size int64, src io.Reader, progress ProgressFunc) error {
+	if err := scpReadAck(r); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "C%04o %d %s\n", mode.Perm(), size, name); err != nil {
+		return err
+	}
+	if err := scpReadAck(r); err != nil {
+		return err
+	}
+
+	if _, err := io.CopyN(w, newProgressReader(src, 0, size, progress), size); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte{0}); err != nil {
+		return err
+	}
+
+	return scpReadAck(r)
+}
+
+// scpReceive reads a single file from an SCP source and returns its size
+func scpReceive(w io.Writer, r *bufio.Reader, dst io.Writer, progress ProgressFunc) (int64, error) {
+	if _, err := w.Write([]byte{0}); err != nil {
+		return 0, err
+	}
+
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return 0, fmt.Errorf("failed to read scp header: %v", err)
+	}
+	if len(header) > 0 && (header[0] == 1 || header[0] == 2) {
+		return 0, fmt.Errorf("remote error: %s", strings.TrimSpace(header[1:]))
+	}
+
+	fields := strings.SplitN(strings.TrimSpace(header), " ", 3)
+	if len(fields) != 3 || !strings.HasPrefix(fields[0], "C") {
+		return 0, fmt.Errorf("unexpected scp header: %q", header)
+	}
+
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size in scp header: %q", header)
+	}
+
+	if _, err := w.Write([]byte{0}); err != nil {
+		return 0, err
+	}
+
+	if _, err := io.CopyN(dst, newProgressReader(r, 0, size, progress), size); err != nil {
+		return 0, err
+	}
+
+	if err := scpReadAck(r); err != nil {
+		return 0, err
+	}
+
+	if _, err := w.Write([]byte{0}); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// scpReadAck reads an SCP status byte, returning the message of warnings and errors
+func scpReadAck(r *bufio.Reader) error {
+	code, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("failed to read scp acknowledgement: %v", err)
+	}
+	if code == 0 {
+		return nil
+	}
+
+	message, _ := r.ReadString('\n')
+	return fmt.Errorf("remote error: %s", strings.TrimSpace(message))
+}
+
+// verifyRemoteChecksum hashes the file on the device and compares it with the expected value
+// An empty command runs sha256sum through the remote shell
+func verifyRemoteChecksum(client *ssh.Client, remotePath, expected, command string) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	output, err := session.Output(checksumCommand(command, remotePath))
+	if err != nil {
+		return fmt.Errorf("failed to compute remote checksum: %v", err)
+	}
+
+	remoteSum, err := parseChecksum(string(output))
+	if err != nil {
+		return fmt.Errorf("%v for %s", err, remotePath)
+	}
+
+	if !strings.EqualFold(remoteSum, expected) {
+		return fmt.Errorf("checksum mismatch: local %s, remote %s", expected, remoteSum)
+	}
+	return nil
+}
+
+// checksumCommand fills the remote path into the command
+// Device CLIs take the path as is, only the sha256sum default goes through a shell
+func checksumCommand(command, remotePath string) string {
+	if command == "" {
+		return "sha256sum " + shellQuote(remotePath)
+	}
+	return strings.ReplaceAll(command, "%s", remotePath)
+}
+
+// parseChecksum returns the SHA-256 digest from the output of sha256sum, "show file ... sha256sum",
+// "verify /sha256" or "file checksum sha-256"
+func parseChecksum(output string) (string, error) {
+	sum := sha256Pattern.FindString(output)
+	if sum == "" {
+		return "", fmt.Errorf("no SHA-256 checksum in output %q", strings.TrimSpace(output))
+	}
+	return strings.ToLower(sum), nil
+}
+
+// fileChecksum returns the hex encoded SHA-256 of a local file
+func fileChecksum(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file for checksum: %v", err)
+	}
+	defer f.Close()
+
+	return readerChecksum(f)
+}
+
+// readerChecksum returns the hex encoded SHA-256 of everything read from r
+func readerChecksum(r io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// shellQuote quotes a path for the remote shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// progressReader reports the running byte count of the wrapped reader
+type progressReader struct {
+	r        io.Reader
+	done     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func newProgressReader(r io.Reader, done, total int64, progress ProgressFunc) io.Reader {
+	if progress == nil {
+		return r
+	}
+	return &progressReader{r: r, done: done, total: total, progress: progress}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.done += int64(n)
+		p.progress(p.done, p.total)
+	}
+	return n, err
+}
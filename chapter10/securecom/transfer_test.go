@@ -0,0 +1,450 @@
+package securecom
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"securecom/sshtest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// newSFTPPipe starts an in-process SFTP server and returns a client connected to it
+func newSFTPPipe(t *testing.T) *sftp.Client {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+
+	server, err := sftp.NewServer(serverConn)
+	if err != nil {
+		t.Fatalf("Failed to create SFTP server: %v", err)
+	}
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	if err != nil {
+		t.Fatalf("Failed to create SFTP client: %v", err)
+	}
+
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client
+}
+
+// firmwareImage returns deterministic test content of the given size
+func firmwareImage(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+// TestUploadSFTP tests a full SFTP upload with progress
+func TestUploadSFTP(t *testing.T) {
+	client := newSFTPPipe(t)
+	dir := t.TempDir()
+
+	content := firmwareImage(100000)
+	localPath := filepath.Join(dir, "image.bin")
+	remotePath := filepath.Join(dir, "remote.bin")
+	if err := os.WriteFile(localPath, content, 0644); err != nil {
+		t.Fatalf("Failed to write local file: %v", err)
+	}
+
+	var last, total int64
+	opts := &TransferOptions{
+		Progress: func(transferred, size int64) {
+			last, total = transferred, size
+		},
+	}
+
+	result, err := uploadSFTP(client, localPath, remotePath, opts)
+	if err != nil {
+		t.Fatalf("uploadSFTP failed: %v", err)
+	}
+
+	if result.Protocol != "sftp" || result.Transferred != int64(len(content)) {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	if last != int64(len(content)) || total != int64(len(content)) {
+		t.Errorf("Expected final progress %d/%d, got %d/%d", len(content), len(content), last, total)
+	}
+
+	data, err := os.ReadFile(remotePath)
+	if err != nil {
+		t.Fatalf("Failed to read uploaded file: %v", err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Error("Uploaded file content does not match")
+	}
+}
+
+// TestUploadSFTP_Resume tests resuming an interrupted upload
+func TestUploadSFTP_Resume(t *testing.T) {
+	client := newSFTPPipe(t)
+	dir := t.TempDir()
+
+	content := firmwareImage(50000)
+	localPath := filepath.Join(dir, "image.bin")
+	remotePath := filepath.Join(dir, "remote.bin")
+	os.WriteFile(localPath, content, 0644)
+	os.WriteFile(remotePath, content[:20000], 0644)
+
+	result, err := uploadSFTP(client, localPath, remotePath, &TransferOptions{Resume: true})
+	if err != nil {
+		t.Fatalf("uploadSFTP failed: %v", err)
+	}
+
+	if result.ResumedAt != 20000 {
+		t.Errorf("Expected resume at 20000, got %d", result.ResumedAt)
+	}
+
+	if result.Transferred != 30000 {
+		t.Errorf("Expected 30000 bytes transferred, got %d", result.Transferred)
+	}
+
+	data, _ := os.ReadFile(remotePath)
+	if !bytes.Equal(data, content) {
+		t.Error("Resumed file content does not match")
+	}
+}
+
+// TestUpload_ResumeChecksumMismatch tests that a corrupt partial file is detected by the device checksum
+func TestUpload_ResumeChecksumMismatch(t *testing.T) {
+	root := t.TempDir()
+	_, client, conn := connectTestServer(t, sshtest.Config{FileRoot: root})
+
+	content := firmwareImage(10000)
+	localPath := filepath.Join(t.TempDir(), "image.bin")
+	os.WriteFile(localPath, content, 0644)
+	os.WriteFile(filepath.Join(root, "image.bin"), bytes.Repeat([]byte{0xff}, 4000), 0644)
+
+	opts := &TransferOptions{Resume: true, Verify: true, ChecksumCommand: "sha256sum %s"}
+	_, err := client.Upload(conn, localPath, "image.bin", opts)
+	if err == nil {
+		t.Fatal("Expected checksum mismatch error, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected checksum mismatch error, got %v", err)
+	}
+}
+
+// TestUploadSFTP_NoResumeTruncates tests that a fresh upload replaces a longer remote file
+func TestUploadSFTP_NoResumeTruncates(t *testing.T) {
+	client := newSFTPPipe(t)
+	dir := t.TempDir()
+
+	localPath := filepath.Join(dir, "config.txt")
+	remotePath := filepath.Join(dir, "remote.txt")
+	os.WriteFile(localPath, []byte("short"), 0644)
+	os.WriteFile(remotePath, []byte("a much longer previous file"), 0644)
+
+	if _, err := uploadSFTP(client, localPath, remotePath, &TransferOptions{}); err != nil {
+		t.Fatalf("uploadSFTP failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(remotePath)
+	if string(data) != "short" {
+		t.Errorf("Expected remote file to be replaced, got %q", string(data))
+	}
+}
+
+// TestDownloadSFTP tests downloading and resuming a config backup
+func TestDownloadSFTP(t *testing.T) {
+	client := newSFTPPipe(t)
+	dir := t.TempDir()
+
+	content := []byte(strings.Repeat("interface Ethernet1/1\n description uplink\n", 500))
+	remotePath := filepath.Join(dir, "running-config")
+	localPath := filepath.Join(dir, "backup.cfg")
+	os.WriteFile(remotePath, content, 0644)
+	os.WriteFile(localPath, content[:1000], 0644)
+
+	result, err := downloadSFTP(client, remotePath, localPath, &TransferOptions{Resume: true})
+	if err != nil {
+		t.Fatalf("downloadSFTP failed: %v", err)
+	}
+
+	if result.ResumedAt != 1000 || result.Size != int64(len(content)) {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	data, _ := os.ReadFile(localPath)
+	if !bytes.Equal(data, content) {
+		t.Error("Downloaded file content does not match")
+	}
+}
+
+// TestDownloadSFTP_MissingFile tests downloading a file that does not exist
+func TestDownloadSFTP_MissingFile(t *testing.T) {
+	client := newSFTPPipe(t)
+	dir := t.TempDir()
+
+	_, err := downloadSFTP(client, filepath.Join(dir, "missing"), filepath.Join(dir, "local"), &TransferOptions{})
+	if err == nil {
+		t.Error("Expected error for missing remote file, got nil")
+	}
+}
+
+// TestDownloadSFTP_KeepsExistingFile tests that a download failing midway does not truncate the local copy
+func TestDownloadSFTP_KeepsExistingFile(t *testing.T) {
+	client := newSFTPPipe(t)
+	dir := t.TempDir()
+
+	remotePath := filepath.Join(dir, "running-config")
+	localPath := filepath.Join(dir, "backup.cfg")
+	os.WriteFile(remotePath, firmwareImage(512*1024), 0644)
+	os.WriteFile(localPath, []byte("hostname R1\n"), 0644)
+
+	// Drop the connection after the first chunk arrives
+	opts := &TransferOptions{Progress: func(transferred, total int64) { client.Close() }}
+	if _, err := downloadSFTP(client, remotePath, localPath, opts); err == nil {
+		t.Fatal("Expected error for interrupted download, got nil")
+	}
+
+	data, _ := os.ReadFile(localPath)
+	if string(data) != "hostname R1\n" {
+		t.Errorf("Expected local file to be untouched, got %d bytes", len(data))
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("Expected no leftover partial files, got %d entries", len(entries))
+	}
+}
+
+// TestSCPSend tests the SCP sink protocol exchange for an upload
+func TestSCPSend(t *testing.T) {
+	sinkIn, clientOut := io.Pipe()
+	clientIn, sinkOut := io.Pipe()
+
+	received := make(chan string, 2)
+	go func() {
+		reader := bufio.NewReader(sinkIn)
+		sinkOut.Write([]byte{0})
+		header, _ := reader.ReadString('\n')
+		received <- header
+		sinkOut.Write([]byte{0})
+		data := make([]byte, 11)
+		io.ReadFull(reader, data)
+		reader.ReadByte()
+		received <- string(data)
+		sinkOut.Write([]byte{0})
+	}()
+
+	var progress int64
+	err := scpSend(clientOut, bufio.NewReader(clientIn), "router.cfg", 0600, 11, strings.NewReader("hostname R1"),
+		func(transferred, total int64) { progress = transferred })
+	if err != nil {
+		t.Fatalf("scpSend failed: %v", err)
+	}
+
+	if header := <-received; header != "C0600 11 router.cfg\n" {
+		t.Errorf("Unexpected header: %q", header)
+	}
+	if data := <-received; data != "hostname R1" {
+		t.Errorf("Unexpected data: %q", data)
+	}
+	if progress != 11 {
+		t.Errorf("Expected progress 11, got %d", progress)
+	}
+}
+
+// TestSCPSend_RemoteError tests that an SCP error message is returned
+func TestSCPSend_RemoteError(t *testing.T) {
+	reply := bufio.NewReader(strings.NewReader("\x01scp: /flash/image.bin: Permission denied\n"))
+
+	err := scpSend(io.Discard, reply, "image.bin", 0644, 4, strings.NewReader("data"), nil)
+	if err == nil || !strings.Contains(err.Error(), "Permission denied") {
+		t.Errorf("Expected permission denied error, got %v", err)
+	}
+}
+
+// TestSCPReceive tests the SCP source protocol exchange for a download
+func TestSCPReceive(t *testing.T) {
+	source := bufio.NewReader(strings.NewReader("C0644 11 running-config\nhostname R1\x00"))
+
+	var acks bytes.Buffer
+	var dst bytes.Buffer
+	size, err := scpReceive(&acks, source, &dst, nil)
+	if err != nil {
+		t.Fatalf("scpReceive failed: %v", err)
+	}
+
+	if size != 11 || dst.String() != "hostname R1" {
+		t.Errorf("Unexpected download: size=%d content=%q", size, dst.String())
+	}
+
+	if acks.Len() != 3 {
+		t.Errorf("Expected 3 acknowledgements, got %d", acks.Len())
+	}
+}
+
+// TestSCPReceive_RemoteError tests a source that reports a missing file
+func TestSCPReceive_RemoteError(t *testing.T) {
+	source := bufio.NewReader(strings.NewReader("\x01scp: running-config: No such file or directory\n"))
+
+	_, err := scpReceive(io.Discard, source, io.Discard, nil)
+	if err == nil || !strings.Contains(err.Error(), "No such file") {
+		t.Errorf("Expected missing file error, got %v", err)
+	}
+}
+
+// TestParseChecksum tests reading the digest from the checksum commands of different platforms
+func TestParseChecksum(t *testing.T) {
+	sum := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	tests := []struct {
+		name    string
+		output  string
+		wantErr bool
+	}{
+		{name: "sha256sum", output: sum + "  /tmp/image.bin\n"},
+		{name: "NX-OS", output: "\n" + sum + "\n"},
+		{name: "IOS", output: "verify /sha256 (bootflash:image.bin) = " + strings.ToUpper(sum) + "\n"},
+		{name: "Junos", output: "SHA256 (/var/tmp/image.tgz) = " + sum + "\n"},
+		{name: "Error", output: "% Invalid input detected at '^' marker.\n", wantErr: true},
+		{name: "Empty", output: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksum(tt.output)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != sum {
+				t.Errorf("Expected %s, got %s", sum, got)
+			}
+		})
+	}
+}
+
+// TestChecksumCommand tests filling the remote path into checksum commands
+func TestChecksumCommand(t *testing.T) {
+	tests := []struct {
+		command  string
+		path     string
+		expected string
+	}{
+		{"", "it's.bin", `sha256sum 'it'\''s.bin'`},
+		{ChecksumCommands["nxos"], "bootflash:nxos.bin", "show file bootflash:nxos.bin sha256sum"},
+		{ChecksumCommands["ios"], "flash:ios.bin", "verify /sha256 flash:ios.bin"},
+		{ChecksumCommands["junos"], "/var/tmp/junos.tgz", "file checksum sha-256 /var/tmp/junos.tgz"},
+	}
+
+	for _, tt := range tests {
+		if got := checksumCommand(tt.command, tt.path); got != tt.expected {
+			t.Errorf("checksumCommand(%q, %q): expected %q, got %q", tt.command, tt.path, tt.expected, got)
+		}
+	}
+}
+
+// TestDownloadSCP_KeepsExistingFile tests that a failed download does not truncate the local copy
+func TestDownloadSCP_KeepsExistingFile(t *testing.T) {
+	_, client, conn := connectTestServer(t, sshtest.Config{FileRoot: t.TempDir(), DisableSFTP: true})
+
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "backup.cfg")
+	os.WriteFile(localPath, []byte("hostname R1\n"), 0644)
+
+	if _, err := client.Download(conn, "missing.cfg", localPath, nil); err == nil {
+		t.Fatal("Expected error for missing remote file, got nil")
+	}
+
+	data, _ := os.ReadFile(localPath)
+	if string(data) != "hostname R1\n" {
+		t.Errorf("Expected local file to be untouched, got %q", string(data))
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected no leftover partial files, got %d entries", len(entries))
+	}
+}
+
+// TestShellQuote tests quoting of remote paths
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"/flash/image.bin", "'/flash/image.bin'"},
+		{"my file", "'my file'"},
+		{"it's", `'it'\''s'`},
+	}
+
+	for _, tt := range tests {
+		if got := shellQuote(tt.input); got != tt.expected {
+			t.Errorf("shellQuote(%q): expected %s, got %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+// TestUploadDownload_TestServer tests transfers end to end, with and without the SFTP subsystem
+func TestUploadDownload_TestServer(t *testing.T) {
+	tests := []struct {
+		name        string
+		disableSFTP bool
+		protocol    string
+	}{
+		{name: "SFTP", protocol: "sftp"},
+		{name: "SCP fallback", disableSFTP: true, protocol: "scp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			_, client, conn := connectTestServer(t, sshtest.Config{
+				FileRoot:    root,
+				DisableSFTP: tt.disableSFTP,
+			})
+
+			content := firmwareImage(64000)
+			localPath := filepath.Join(t.TempDir(), "image.bin")
+			os.WriteFile(localPath, content, 0644)
+
+			result, err := client.Upload(conn, localPath, "image.bin", &TransferOptions{Verify: true})
+			if err != nil {
+				t.Fatalf("Upload failed: %v", err)
+			}
+			if result.Protocol != tt.protocol {
+				t.Errorf("Expected protocol %s, got %s", tt.protocol, result.Protocol)
+			}
+			if result.Checksum == "" {
+				t.Error("Expected checksum to be set after verification")
+			}
+
+			data, _ := os.ReadFile(filepath.Join(root, "image.bin"))
+			if !bytes.Equal(data, content) {
+				t.Fatal("Uploaded file content does not match")
+			}
+
+			backupPath := filepath.Join(t.TempDir(), "backup.bin")
+			if _, err := client.Download(conn, "image.bin", backupPath, &TransferOptions{Verify: true}); err != nil {
+				t.Fatalf("Download failed: %v", err)
+			}
+
+			data, _ = os.ReadFile(backupPath)
+			if !bytes.Equal(data, content) {
+				t.Error("Downloaded file content does not match")
+			}
+		})
+	}
+}
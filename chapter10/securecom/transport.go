@@ -0,0 +1,209 @@
+package securecom
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// ProxyFromEnv selects the proxy from HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+const ProxyFromEnv = "env"
+
+// TransportConfig describes the TLS identity, trust and proxy of a check transport
+type TransportConfig struct {
+	VerifyTLS bool
+	// CertFile and KeyFile hold the PEM client certificate presented for mutual TLS
+	CertFile string
+	KeyFile  string
+	// CAFile replaces the system roots used to verify the server
+	CAFile string
+	// ServerName overrides the SNI name and the name the certificate is verified against
+	ServerName string
+	// Proxy is an http, https, socks5 or socks5h URL, ProxyFromEnv, or empty to connect directly
+	Proxy string
+}
+
+// Method Transport builds the HTTP transport, loading the certificate files
+func (c TransportConfig) Transport() (*http.Transport, error) {
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := NewTransport(c.VerifyTLS)
+	transport.TLSClientConfig = tlsConfig
+
+	proxy, err := ParseProxy(c.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	transport.Proxy = proxy
+
+	return transport, nil
+}
+
+// Method TLSConfig builds the TLS settings alone, for probes that do not use HTTP
+func (c TransportConfig) TLSConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: !c.VerifyTLS,
+		ServerName:         c.ServerName,
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, fmt.Errorf("client certificate and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.CAFile != "" {
+		roots, err := LoadCABundle(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = roots
+	}
+
+	return config, nil
+}
+
+// ParseProxy returns the proxy function for a proxy setting, nil for a direct connection
+func ParseProxy(spec string) (func(*http.Request) (*url.URL, error), error) {
+	switch strings.ToLower(spec) {
+	case "", "direct", "none":
+		return nil, nil
+	case ProxyFromEnv:
+		return http.ProxyFromEnvironment, nil
+	}
+
+	proxyURL, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %s: %v", spec, err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, expected http, https, socks5 or socks5h", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %s: missing host", spec)
+	}
+
+	return http.ProxyURL(proxyURL), nil
+}
+
+// dialFunc adapts a DialContext function to the dialer interfaces of golang.org/x/net/proxy
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+func (f dialFunc) Dial(network, address string) (net.Conn, error) {
+	return f(context.Background(), network, address)
+}
+
+func (f dialFunc) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return f(ctx, network, address)
+}
+
+// dialTransport opens a TCP connection to an HTTPS address the way the transport would,
+// through its proxy and with its dialer, for probes that run the TLS handshake themselves
+func dialTransport(ctx context.Context, transport *http.Transport, address string) (net.Conn, error) {
+	var dialer net.Dialer
+	dial := dialFunc(dialer.DialContext)
+	if transport == nil {
+		return dial(ctx, "tcp", address)
+	}
+	if transport.DialContext != nil {
+		dial = transport.DialContext
+	}
+
+	var proxyURL *url.URL
+	if transport.Proxy != nil {
+		req := &http.Request{Method: http.MethodGet, URL: &url.URL{Scheme: "https", Host: address}, Header: http.Header{}}
+		var err error
+		if proxyURL, err = transport.Proxy(req); err != nil {
+			return nil, fmt.Errorf("failed to select proxy: %v", err)
+		}
+	}
+	if proxyURL == nil {
+		return dial(ctx, "tcp", address)
+	}
+
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		socks, err := proxy.FromURL(proxyURL, dial)
+		if err != nil {
+			return nil, err
+		}
+		return socks.(proxy.ContextDialer).DialContext(ctx, "tcp", address)
+	case "http", "https":
+		return dialConnect(ctx, dial, proxyURL, address)
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+	}
+}
+
+// dialConnect opens a tunnel to the address with an HTTP CONNECT request to the proxy
+func dialConnect(ctx context.Context, dial dialFunc, proxyURL *url.URL, address string) (net.Conn, error) {
+	port := proxyURL.Port()
+	if port == "" {
+		port = "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	conn, err := dial(ctx, "tcp", net.JoinHostPort(proxyURL.Hostname(), port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS handshake with proxy failed: %v", err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{Method: http.MethodConnect, URL: &url.URL{Opaque: address}, Host: address, Header: http.Header{}}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT to proxy: %v", err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read CONNECT response: %v", err)
+	}
+	// A successful CONNECT has no body, the connection now belongs to the tunnel
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused CONNECT: %s", resp.Status)
+	}
+
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
@@ -0,0 +1,300 @@
+package securecom
+
+import (
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writePEM writes a certificate and its key as PEM files and returns their paths
+func writePEM(t *testing.T, cert tls.Certificate) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	keyDER, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0644)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile
+}
+
+func writeCAFile(t *testing.T, ca *testCA) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0644)
+	return filename
+}
+
+// startTLSServer starts a server with a certificate from the CA, configured by the callback
+func startTLSServer(t *testing.T, ca *testCA, dnsName string, configure func(*tls.Config)) (*httptest.Server, int) {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{ca.issue(t, dnsName, time.Now().Add(90*24*time.Hour))}}
+	if configure != nil {
+		configure(server.TLS)
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server, server.Listener.Addr().(*net.TCPAddr).Port
+}
+
+// TestMutualTLS tests presenting a client certificate to a server that requires one
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	_, port := startTLSServer(t, ca, "api.internal", func(cfg *tls.Config) {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = ca.pool
+	})
+
+	caFile := writeCAFile(t, ca)
+	certFile, keyFile := writePEM(t, ca.issue(t, "client.internal", time.Now().Add(24*time.Hour)))
+
+	tests := []struct {
+		name   string
+		config TransportConfig
+		alive  bool
+	}{
+		{"Without client certificate", TransportConfig{VerifyTLS: true, CAFile: caFile}, false},
+		{"With client certificate", TransportConfig{VerifyTLS: true, CAFile: caFile, CertFile: certFile, KeyFile: keyFile}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := tt.config.Transport()
+			if err != nil {
+				t.Fatalf("Transport failed: %v", err)
+			}
+
+			checker := NewHTTPSChecker("127.0.0.1", port, 5*time.Second, true)
+			checker.Transport = transport
+			status := checker.CheckServer()
+
+			if status.IsAlive != tt.alive {
+				t.Errorf("Expected alive %v, got %v (%v)", tt.alive, status.IsAlive, status.Error)
+			}
+		})
+	}
+}
+
+// TestServerNameOverride tests that the SNI override is sent and used for verification
+func TestServerNameOverride(t *testing.T) {
+	ca := newTestCA(t)
+	var sni atomic.Value
+	_, port := startTLSServer(t, ca, "api.internal", func(cfg *tls.Config) {
+		cfg.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			sni.Store(hello.ServerName)
+			return nil, nil
+		}
+	})
+
+	transport, err := TransportConfig{VerifyTLS: true, CAFile: writeCAFile(t, ca), ServerName: "api.internal"}.Transport()
+	if err != nil {
+		t.Fatalf("Transport failed: %v", err)
+	}
+
+	checker := NewHTTPSChecker("127.0.0.1", port, 5*time.Second, true)
+	checker.Transport = transport
+	checker.Policy = NewTLSPolicy()
+	checker.Policy.Roots = transport.TLSClientConfig.RootCAs
+
+	status := checker.CheckServer()
+	if !status.Healthy() {
+		t.Fatalf("Expected healthy check, got %v %+v", status.Error, status.Findings)
+	}
+	if sni.Load() != "api.internal" {
+		t.Errorf("Expected SNI api.internal, got %v", sni.Load())
+	}
+}
+
+// startConnectProxy starts an HTTP proxy handling CONNECT and counts tunnels
+func startConnectProxy(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var tunnels int32
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		atomic.AddInt32(&tunnels, 1)
+
+		w.WriteHeader(http.StatusOK)
+		client, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			target.Close()
+			return
+		}
+		go pipe(client, target)
+	}))
+	t.Cleanup(proxy.Close)
+
+	return proxy, &tunnels
+}
+
+// startSOCKS5Proxy starts a SOCKS5 proxy without authentication and counts tunnels
+func startSOCKS5Proxy(t *testing.T) (string, *int32) {
+	t.Helper()
+	var tunnels int32
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				// Greeting: version, method count, methods
+				header := make([]byte, 2)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					conn.Close()
+					return
+				}
+				io.ReadFull(conn, make([]byte, header[1]))
+				conn.Write([]byte{5, 0})
+
+				// Request: version, CONNECT, reserved, address type
+				request := make([]byte, 4)
+				if _, err := io.ReadFull(conn, request); err != nil {
+					conn.Close()
+					return
+				}
+				var host string
+				switch request[3] {
+				case 1:
+					ip := make([]byte, 4)
+					io.ReadFull(conn, ip)
+					host = net.IP(ip).String()
+				case 3:
+					length := make([]byte, 1)
+					io.ReadFull(conn, length)
+					name := make([]byte, length[0])
+					io.ReadFull(conn, name)
+					host = string(name)
+				}
+				portBytes := make([]byte, 2)
+				io.ReadFull(conn, portBytes)
+				address := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBytes))))
+
+				target, err := net.Dial("tcp", address)
+				if err != nil {
+					conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+					conn.Close()
+					return
+				}
+				atomic.AddInt32(&tunnels, 1)
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				pipe(conn, target)
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), &tunnels
+}
+
+func pipe(a, b net.Conn) {
+	go func() {
+		io.Copy(a, b)
+		a.Close()
+	}()
+	io.Copy(b, a)
+	b.Close()
+}
+
+// TestCheckThroughProxy tests checks tunnelled through HTTP and SOCKS5 proxies
+func TestCheckThroughProxy(t *testing.T) {
+	ca := newTestCA(t)
+	_, port := startTLSServer(t, ca, "api.internal", nil)
+	caFile := writeCAFile(t, ca)
+
+	httpProxy, httpTunnels := startConnectProxy(t)
+	socksAddr, socksTunnels := startSOCKS5Proxy(t)
+
+	tests := []struct {
+		name    string
+		proxy   string
+		tunnels *int32
+	}{
+		{"HTTP proxy", httpProxy.URL, httpTunnels},
+		{"SOCKS5 proxy", "socks5://" + socksAddr, socksTunnels},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := TransportConfig{VerifyTLS: true, CAFile: caFile, Proxy: tt.proxy}.Transport()
+			if err != nil {
+				t.Fatalf("Transport failed: %v", err)
+			}
+
+			checker := NewHTTPSChecker("127.0.0.1", port, 5*time.Second, true)
+			checker.Transport = transport
+			status := checker.CheckServer()
+
+			if !status.IsAlive {
+				t.Fatalf("Expected check through proxy to succeed, got %v", status.Error)
+			}
+			if atomic.LoadInt32(tt.tunnels) != 1 {
+				t.Errorf("Expected 1 tunnel through the proxy, got %d", atomic.LoadInt32(tt.tunnels))
+			}
+		})
+	}
+}
+
+// TestTransportConfigErrors tests invalid proxy and certificate settings
+func TestTransportConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config TransportConfig
+	}{
+		{"Unsupported proxy scheme", TransportConfig{Proxy: "ftp://proxy:21"}},
+		{"Proxy without host", TransportConfig{Proxy: "http://"}},
+		{"Certificate without key", TransportConfig{CertFile: "cert.pem"}},
+		{"Missing certificate files", TransportConfig{CertFile: "missing.pem", KeyFile: "missing.key"}},
+		{"Missing CA file", TransportConfig{CAFile: "missing.pem"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.config.Transport(); err == nil {
+				t.Errorf("Expected error")
+			}
+		})
+	}
+
+	for _, spec := range []string{"", "direct", "env", "http://proxy:3128", "https://proxy:3129", "socks5h://proxy:1080"} {
+		if _, err := ParseProxy(spec); err != nil {
+			t.Errorf("Unexpected error for %q: %v", spec, err)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Command genschema writes the JSON Schema of the infrastructure model
+package main
+
+import (
+	"fmt"
+	"model"
+	"os"
+)
+
+func main() {
+	filename := "model.schema.json"
+	if len(os.Args) > 1 {
+		filename = os.Args[1]
+	}
+
+	data, err := model.GenerateSchema()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write schema: %v\n", err)
+		os.Exit(1)
+	}
+}
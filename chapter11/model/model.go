@@ -22,17 +22,17 @@ type Location struct {
 }
 
 type Interface struct {
-	Name           string `json:"name"`
+	Name           string `json:"name" jsonschema:"required"`
 	Description    string `json:"description"`
 	IPAddress      string `json:"ip_address,omitempty"`
 	SubnetMask     string `json:"subnet_mask,omitempty"`
 	Enabled        bool   `json:"enabled"`
-	MTU            int    `json:"mtu,omitempty"`
+	MTU            int    `json:"mtu,omitempty" jsonschema:"minimum=64,maximum=65535"`
 	Speed          string `json:"speed"`
 	Duplex         string `json:"duplex"`
-	SwitchportMode string `json:"switchport_mode,omitempty"`
-	VLAN           int    `json:"vlan,omitempty"`
-	AllowedVLANs   []int  `json:"allowed_vlans,omitempty"`
+	SwitchportMode string `json:"switchport_mode,omitempty" jsonschema:"enum=access|trunk"`
+	VLAN           int    `json:"vlan,omitempty" jsonschema:"minimum=1,maximum=4094"`
+	AllowedVLANs   []int  `json:"allowed_vlans,omitempty" jsonschema:"minimum=1,maximum=4094"`
 }
 
 type OSPFArea struct {
@@ -48,8 +48,8 @@ type OSPFProtocol struct {
 }
 
 type BGPNeighbor struct {
-	IP          string `json:"ip"`
-	RemoteAS    string `json:"remote_as"`
+	IP          string `json:"ip" jsonschema:"required"`
+	RemoteAS    string `json:"remote_as" jsonschema:"required"`
 	Description string `json:"description"`
 }
 
@@ -61,7 +61,7 @@ type BGPProtocol struct {
 }
 
 type RoutingProtocol struct {
-	Protocol  string        `json:"protocol"`
+	Protocol  string        `json:"protocol" jsonschema:"required,enum=ospf|isis|bgp"`
 	ProcessID string        `json:"process_id,omitempty"`
 	RouterID  string        `json:"router_id,omitempty"`
 	ASNumber  string        `json:"as_number,omitempty"`
@@ -70,9 +70,9 @@ type RoutingProtocol struct {
 }
 
 type StaticRoute struct {
-	Destination            string `json:"destination"`
-	NextHop                string `json:"next_hop"`
-	AdministrativeDistance int    `json:"administrative_distance"`
+	Destination            string `json:"destination" jsonschema:"required"`
+	NextHop                string `json:"next_hop" jsonschema:"required"`
+	AdministrativeDistance int    `json:"administrative_distance" jsonschema:"minimum=0,maximum=255"`
 }
 
 type Routing struct {
@@ -93,8 +93,8 @@ type SNMPService struct {
 }
 
 type SyslogServer struct {
-	Host     string `json:"host"`
-	Port     int    `json:"port"`
+	Host     string `json:"host" jsonschema:"required"`
+	Port     int    `json:"port" jsonschema:"minimum=0,maximum=65535"`
 	Severity string `json:"severity"`
 }
 
@@ -110,14 +110,14 @@ type Services struct {
 }
 
 type VLAN struct {
-	ID          int    `json:"id"`
+	ID          int    `json:"id" jsonschema:"required,minimum=1,maximum=4094"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
 }
 
 type Device struct {
-	ID           string      `json:"id"`
-	Hostname     string      `json:"hostname"`
+	ID           string      `json:"id" jsonschema:"required"`
+	Hostname     string      `json:"hostname" jsonschema:"required"`
 	DeviceType   string      `json:"device_type"`
 	Vendor       string      `json:"vendor"`
 	Model        string      `json:"model"`
@@ -130,16 +130,16 @@ type Device struct {
 }
 
 type ACLEntry struct {
-	Sequence        int    `json:"sequence"`
-	Action          string `json:"action"`
+	Sequence        int    `json:"sequence" jsonschema:"required,minimum=1"`
+	Action          string `json:"action" jsonschema:"required,enum=permit|deny"`
 	Protocol        string `json:"protocol"`
 	Source          string `json:"source"`
 	Destination     string `json:"destination"`
-	DestinationPort int    `json:"destination_port,omitempty"`
+	DestinationPort int    `json:"destination_port,omitempty" jsonschema:"minimum=1,maximum=65535"`
 }
 
 type AccessList struct {
-	Name    string     `json:"name"`
+	Name    string     `json:"name" jsonschema:"required"`
 	Entries []ACLEntry `json:"entries"`
 }
 
@@ -159,6 +159,10 @@ func LoadModel(filename string) (*InfrastructureModel, error) {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
+	if errs := ValidateSchema(data); errs != nil {
+		return nil, fmt.Errorf("invalid model %s: %w", filename, errs)
+	}
+
 	var model InfrastructureModel
 	if err := json.Unmarshal(data, &model); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
@@ -0,0 +1,367 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:generate go run ./cmd/genschema model.schema.json
+
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema generated for the model types
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 schemaType         `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+}
+
+// schemaType is a single type name, or a list when null is also allowed
+type schemaType []string
+
+func (t schemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t schemaType) allows(name string) bool {
+	for _, allowed := range t {
+		if allowed == name || (allowed == "number" && name == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	schemaOnce  sync.Once
+	modelSchema *Schema
+)
+
+// ModelSchema returns the schema of InfrastructureModel, generated from the
+// json and jsonschema struct tags
+func ModelSchema() *Schema {
+	schemaOnce.Do(func() {
+		modelSchema = schemaFor(reflect.TypeOf(InfrastructureModel{}))
+		modelSchema.Schema = SchemaDraft
+		// Lets model files point editors at the schema
+		modelSchema.Properties["$schema"] = &Schema{Type: schemaType{"string"}}
+	})
+	return modelSchema
+}
+
+// GenerateSchema returns the schema as indented JSON, as shipped in model.schema.json
+func GenerateSchema() ([]byte, error) {
+	data, err := json.MarshalIndent(ModelSchema(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func schemaFor(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: schemaType{"string"}, Format: "date-time"}
+	case t.Kind() == reflect.Ptr:
+		s := schemaFor(t.Elem())
+		s.Type = append(s.Type, "null")
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		s := &Schema{
+			Title:                t.Name(),
+			Type:                 schemaType{"object"},
+			Properties:           map[string]*Schema{},
+			AdditionalProperties: new(bool),
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			property := schemaFor(field.Type)
+			if applySchemaTag(property, field.Tag.Get("jsonschema")) {
+				s.Required = append(s.Required, name)
+			}
+			s.Properties[name] = property
+		}
+		return s
+	case reflect.Slice, reflect.Array:
+		// encoding/json writes nil slices as null
+		return &Schema{Type: schemaType{"array", "null"}, Items: schemaFor(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: schemaType{"string"}}
+	case reflect.Bool:
+		return &Schema{Type: schemaType{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: schemaType{"integer"}}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: schemaType{"integer"}, Minimum: new(float64)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: schemaType{"number"}}
+	default:
+		return &Schema{}
+	}
+}
+
+// applySchemaTag applies the options of a jsonschema tag such as
+// "required,minimum=1,maximum=4094" or "enum=permit|deny", to the items of
+// a slice, and reports whether the field is required
+func applySchemaTag(s *Schema, tag string) bool {
+	required := false
+	target := s
+	if s.Items != nil {
+		target = s.Items
+	}
+	for _, option := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(option, "=")
+		switch key {
+		case "required":
+			required = true
+		case "enum":
+			target.Enum = strings.Split(value, "|")
+		case "format":
+			target.Format = value
+		case "minimum", "maximum":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				panic(fmt.Sprintf("invalid jsonschema %s %q", key, value))
+			}
+			if key == "minimum" {
+				target.Minimum = &n
+			} else {
+				target.Maximum = &n
+			}
+		}
+	}
+	return required
+}
+
+// jsonNode is a decoded JSON value with the offset it starts at
+type jsonNode struct {
+	kind   string
+	offset int64
+	value  interface{}
+	keys   []jsonKey
+	items  []*jsonNode
+}
+
+type jsonKey struct {
+	name   string
+	offset int64
+	value  *jsonNode
+}
+
+type jsonParser struct {
+	data []byte
+	dec  *json.Decoder
+}
+
+// start returns the offset of the next token, skipping white space and separators
+func (p *jsonParser) start() int64 {
+	offset := p.dec.InputOffset()
+	for offset < int64(len(p.data)) && strings.IndexByte(" \t\r\n,:", p.data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+func (p *jsonParser) parse() (*jsonNode, error) {
+	offset := p.start()
+	token, err := p.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	node := &jsonNode{offset: offset, value: token}
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '{' {
+			node.kind = "object"
+			for p.dec.More() {
+				keyOffset := p.start()
+				key, err := p.dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := p.parse()
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, jsonKey{name: key.(string), offset: keyOffset, value: value})
+			}
+		} else {
+			node.kind = "array"
+			for p.dec.More() {
+				item, err := p.parse()
+				if err != nil {
+					return nil, err
+				}
+				node.items = append(node.items, item)
+			}
+		}
+		if _, err := p.dec.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		node.kind = "string"
+	case json.Number:
+		node.kind = "number"
+		if f, err := token.Float64(); err == nil && f == math.Trunc(f) {
+			node.kind = "integer"
+		}
+	case bool:
+		node.kind = "boolean"
+	case nil:
+		node.kind = "null"
+	}
+	return node, nil
+}
+
+type schemaValidator struct {
+	data   []byte
+	errors ValidationErrors
+}
+
+func (v *schemaValidator) add(path string, offset int64, format string, args ...interface{}) {
+	line, column := position(v.data, offset)
+	v.errors = append(v.errors, ValidationError{Path: path, Line: line, Column: column, Message: fmt.Sprintf(format, args...)})
+}
+
+// position converts a byte offset to a 1-based line and column
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - (bytes.LastIndexByte(before, '\n') + 1) + 1
+	return line, column
+}
+
+// ValidateSchema checks a model document against ModelSchema and returns
+// every problem with its JSON path and position, nil when it conforms
+func ValidateSchema(data []byte) ValidationErrors {
+	v := &schemaValidator{data: data}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	p := &jsonParser{data: data, dec: dec}
+	root, err := p.parse()
+	if err == nil {
+		if _, err = dec.Token(); err == io.EOF {
+			err = nil
+		} else if err == nil {
+			err = errors.New("unexpected data after the top-level value")
+		}
+	}
+	if err != nil {
+		offset := dec.InputOffset()
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			offset = syntaxErr.Offset
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = errors.New("unexpected end of JSON input")
+		}
+		v.add("$", offset, "invalid JSON: %v", err)
+		return v.errors
+	}
+
+	v.validate(ModelSchema(), root, "$")
+	return v.errors
+}
+
+func (v *schemaValidator) validate(s *Schema, node *jsonNode, path string) {
+	if len(s.Type) > 0 && !s.Type.allows(node.kind) {
+		v.add(path, node.offset, "expected %s, got %s", strings.Join(s.Type, " or "), node.kind)
+		return
+	}
+
+	switch node.kind {
+	case "object":
+		seen := map[string]bool{}
+		for _, key := range node.keys {
+			keyPath := path + "." + key.name
+			if seen[key.name] {
+				v.add(keyPath, key.offset, "duplicate property %q", key.name)
+			}
+			seen[key.name] = true
+
+			property, ok := s.Properties[key.name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					v.add(keyPath, key.offset, "unknown property %q", key.name)
+				}
+				continue
+			}
+			v.validate(property, key.value, keyPath)
+		}
+		for _, name := range s.Required {
+			if !seen[name] {
+				v.add(path, node.offset, "missing required property %q", name)
+			}
+		}
+	case "array":
+		if s.Items != nil {
+			for i, item := range node.items {
+				v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case "string":
+		value := node.value.(string)
+		if len(s.Enum) > 0 && !contains(s.Enum, value) {
+			v.add(path, node.offset, "%q is not one of %s", value, strings.Join(s.Enum, ", "))
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				v.add(path, node.offset, "%q is not an RFC 3339 date-time", value)
+			}
+		}
+	case "integer", "number":
+		value, _ := node.value.(json.Number).Float64()
+		if s.Minimum != nil && value < *s.Minimum {
+			v.add(path, node.offset, "%s is less than the minimum %g", node.value, *s.Minimum)
+		}
+		if s.Maximum != nil && value > *s.Maximum {
+			v.add(path, node.offset, "%s is greater than the maximum %g", node.value, *s.Maximum)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSchemaFileUpToDate(t *testing.T) {
+	want, err := GenerateSchema()
+	if err != nil {
+		t.Fatalf("Failed to generate schema: %v", err)
+	}
+	got, err := os.ReadFile("model.schema.json")
+	if err != nil {
+		t.Fatalf("Failed to read model.schema.json: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("model.schema.json is out of date, run go generate")
+	}
+}
+
+func TestValidateSchemaValidDocuments(t *testing.T) {
+	data, err := os.ReadFile("model.json")
+	if err != nil {
+		t.Fatalf("Failed to read model.json: %v", err)
+	}
+	if errs := ValidateSchema(data); errs != nil {
+		t.Errorf("Expected model.json to conform, got %v", errs)
+	}
+
+	filename := "test_schema_roundtrip.json"
+	defer os.Remove(filename)
+	if err := SaveModel(filename, createTestModel()); err != nil {
+		t.Fatalf("Failed to save test model: %v", err)
+	}
+	data, err = os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read saved model: %v", err)
+	}
+	if errs := ValidateSchema(data); errs != nil {
+		t.Errorf("Expected saved model to conform, got %v", errs)
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []ValidationError
+	}{
+		{
+			name: "wrong type",
+			doc: `{
+  "devices": [
+    {"id": "r1", "hostname": "r1", "interfaces": [{"name": "ge-0/0/0", "mtu": "9000"}]}
+  ]
+}`,
+			want: []ValidationError{
+				{Path: "$.devices[0].interfaces[0].mtu", Line: 3, Column: 79},
+			},
+		},
+		{
+			name: "unknown and missing properties",
+			doc: `{
+  "devices": [
+    {
+      "id": "r1",
+      "hostnme": "r1"
+    }
+  ]
+}`,
+			want: []ValidationError{
+				{Path: "$.devices[0].hostnme", Line: 5, Column: 7},
+				{Path: "$.devices[0]", Line: 3, Column: 5},
+			},
+		},
+		{
+			name: "out of range and enum",
+			doc: `{"devices": [{"id": "s1", "hostname": "s1", "vlans": [{"id": 5000}]}],
+"security": {"access_lists": [{"name": "A", "entries": [{"sequence": 10, "action": "allow"}]}]}}`,
+			want: []ValidationError{
+				{Path: "$.devices[0].vlans[0].id", Line: 1, Column: 62},
+				{Path: "$.security.access_lists[0].entries[0].action", Line: 2, Column: 84},
+			},
+		},
+		{
+			name: "date-time and duplicate key",
+			doc:  "{\"metadata\": {\"created_at\": \"yesterday\", \"version\": \"1\", \"version\": \"2\"}}",
+			want: []ValidationError{
+				{Path: "$.metadata.created_at", Line: 1, Column: 29},
+				{Path: "$.metadata.version", Line: 1, Column: 58},
+			},
+		},
+		{
+			name: "syntax error",
+			doc:  "{\n  \"devices\": [\n    {\"id\": \"r1\",}\n  ]\n}",
+			want: []ValidationError{
+				{Path: "$", Line: 3, Column: 17},
+			},
+		},
+		{
+			name: "truncated",
+			doc:  `{"devices": [`,
+			want: []ValidationError{
+				{Path: "$", Line: 1, Column: 14},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateSchema([]byte(tt.doc))
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tt.want), len(errs), errs)
+			}
+			for i, want := range tt.want {
+				got := errs[i]
+				if got.Path != want.Path || got.Line != want.Line || got.Column != want.Column {
+					t.Errorf("Expected error %d at %s line %d column %d, got %s", i, want.Path, want.Line, want.Column, got)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadModelRejectsInvalidDocument(t *testing.T) {
+	filename := "test_invalid_model.json"
+	defer os.Remove(filename)
+	if err := os.WriteFile(filename, []byte(`{"devices": [{"id": "r1"}]}`), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	_, err := LoadModel(filename)
+	if err == nil {
+		t.Fatal("Expected error when loading an invalid model, got nil")
+	}
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `$.devices[0] (line 1, column 14): missing required property "hostname"`) {
+		t.Errorf("Expected missing hostname error, got %v", err)
+	}
+}
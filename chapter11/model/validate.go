@@ -0,0 +1,286 @@
+package model
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	MinVLANID = 1
+	MaxVLANID = 4094
+)
+
+// ValidationError is one problem in the model, Path is the JSON path of the
+// offending field such as $.devices[0].interfaces[1].ip_address
+// Line and Column locate it in the document when it was found by ValidateSchema
+type ValidationError struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s (line %d, column %d): %s", e.Path, e.Line, e.Column, e.Message)
+	}
+	return e.Path + ": " + e.Message
+}
+
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return fmt.Sprintf("%d validation errors:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+type validator struct {
+	errors ValidationErrors
+}
+
+func (v *validator) add(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks the consistency of the model and returns every problem found, nil when it is valid
+func Validate(model *InfrastructureModel) ValidationErrors {
+	v := &validator{}
+
+	ids := map[string]string{}
+	hostnames := map[string]string{}
+	for i, device := range model.Devices {
+		path := fmt.Sprintf("$.devices[%d]", i)
+
+		if device.ID == "" {
+			v.add(path+".id", "device ID is required")
+		} else if first, ok := ids[device.ID]; ok {
+			v.add(path+".id", "duplicate device ID %q, also used by %s", device.ID, first)
+		} else {
+			ids[device.ID] = path
+		}
+
+		if hostname := strings.ToLower(device.Hostname); hostname != "" {
+			if first, ok := hostnames[hostname]; ok {
+				v.add(path+".hostname", "duplicate hostname %q, also used by %s", device.Hostname, first)
+			} else {
+				hostnames[hostname] = path
+			}
+		}
+
+		v.validateDevice(path, device)
+	}
+
+	for i, acl := range model.Security.AccessLists {
+		v.validateAccessList(fmt.Sprintf("$.security.access_lists[%d]", i), acl)
+	}
+
+	return v.errors
+}
+
+type interfaceNetwork struct {
+	name    string
+	ip      net.IP
+	network *net.IPNet
+}
+
+func (v *validator) validateDevice(path string, device Device) {
+	if device.ManagementIP != "" && net.ParseIP(device.ManagementIP) == nil {
+		v.add(path+".management_ip", "invalid IP address %q", device.ManagementIP)
+	}
+
+	vlans := map[int]bool{}
+	for i, vlan := range device.VLANs {
+		vlanPath := fmt.Sprintf("%s.vlans[%d].id", path, i)
+		if !validVLAN(vlan.ID) {
+			v.add(vlanPath, "VLAN ID %d out of range %d-%d", vlan.ID, MinVLANID, MaxVLANID)
+		} else if vlans[vlan.ID] {
+			v.add(vlanPath, "duplicate VLAN ID %d", vlan.ID)
+		}
+		vlans[vlan.ID] = true
+	}
+
+	var networks []interfaceNetwork
+	for i, intf := range device.Interfaces {
+		intfPath := fmt.Sprintf("%s.interfaces[%d]", path, i)
+
+		if intf.VLAN != 0 {
+			v.validateVLANReference(intfPath+".vlan", intf.VLAN, vlans)
+		}
+		for j, id := range intf.AllowedVLANs {
+			v.validateVLANReference(fmt.Sprintf("%s.allowed_vlans[%d]", intfPath, j), id, vlans)
+		}
+
+		network, ok := v.validateAddress(intfPath, intf)
+		if !ok {
+			continue
+		}
+		for _, other := range networks {
+			if other.network.Contains(network.IP) || network.Contains(other.network.IP) {
+				v.add(intfPath+".ip_address", "subnet %s overlaps %s on %s", network, other.network, other.name)
+			}
+		}
+		networks = append(networks, interfaceNetwork{name: intf.Name, ip: net.ParseIP(intf.IPAddress), network: network})
+	}
+
+	if device.Routing == nil {
+		return
+	}
+	for i, protocol := range device.Routing.Protocols {
+		protocolPath := fmt.Sprintf("%s.routing.protocols[%d]", path, i)
+
+		if protocol.RouterID != "" && net.ParseIP(protocol.RouterID).To4() == nil {
+			v.add(protocolPath+".router_id", "invalid router ID %q", protocol.RouterID)
+		}
+
+		for j, area := range protocol.Areas {
+			for k, network := range area.Networks {
+				v.validateOSPFNetwork(fmt.Sprintf("%s.areas[%d].networks[%d]", protocolPath, j, k), network, networks)
+			}
+		}
+
+		for j, neighbor := range protocol.Neighbors {
+			v.validateBGPNeighbor(fmt.Sprintf("%s.neighbors[%d].ip", protocolPath, j), neighbor, networks)
+		}
+	}
+
+	for i, route := range device.Routing.StaticRoutes {
+		routePath := fmt.Sprintf("%s.routing.static_routes[%d]", path, i)
+		if _, _, err := net.ParseCIDR(route.Destination); err != nil {
+			v.add(routePath+".destination", "invalid prefix %q", route.Destination)
+		}
+		if net.ParseIP(route.NextHop) == nil {
+			v.add(routePath+".next_hop", "invalid IP address %q", route.NextHop)
+		}
+	}
+}
+
+// validateAddress returns the subnet of a routed interface, false when it has
+// no address or the address is invalid
+func (v *validator) validateAddress(path string, intf Interface) (*net.IPNet, bool) {
+	if intf.IPAddress == "" && intf.SubnetMask == "" {
+		return nil, false
+	}
+	if intf.IPAddress == "" {
+		v.add(path+".ip_address", "ip_address is required with subnet_mask")
+		return nil, false
+	}
+
+	ip := net.ParseIP(intf.IPAddress)
+	if ip == nil {
+		v.add(path+".ip_address", "invalid IP address %q", intf.IPAddress)
+		return nil, false
+	}
+	if intf.SubnetMask == "" {
+		v.add(path+".subnet_mask", "subnet_mask is required with ip_address")
+		return nil, false
+	}
+	mask, err := parseMask(intf.SubnetMask, ip)
+	if err != nil {
+		v.add(path+".subnet_mask", "%v", err)
+		return nil, false
+	}
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}, true
+}
+
+// parseMask accepts a dotted IPv4 mask or a prefix length
+func parseMask(s string, ip net.IP) (net.IPMask, error) {
+	bits := 128
+	if ip.To4() != nil {
+		bits = 32
+	}
+
+	if length, err := strconv.Atoi(strings.TrimPrefix(s, "/")); err == nil {
+		if length < 0 || length > bits {
+			return nil, fmt.Errorf("invalid prefix length %q", s)
+		}
+		return net.CIDRMask(length, bits), nil
+	}
+
+	maskIP := net.ParseIP(s).To4()
+	if maskIP == nil || bits != 32 {
+		return nil, fmt.Errorf("invalid subnet mask %q", s)
+	}
+	mask := net.IPMask(maskIP)
+	if _, size := mask.Size(); size == 0 {
+		return nil, fmt.Errorf("invalid subnet mask %q, bits are not contiguous", s)
+	}
+	return mask, nil
+}
+
+func validVLAN(id int) bool {
+	return id >= MinVLANID && id <= MaxVLANID
+}
+
+func (v *validator) validateVLANReference(path string, id int, defined map[int]bool) {
+	switch {
+	case !validVLAN(id):
+		v.add(path, "VLAN ID %d out of range %d-%d", id, MinVLANID, MaxVLANID)
+	case !defined[id]:
+		v.add(path, "VLAN %d is not defined on the device", id)
+	}
+}
+
+// validateOSPFNetwork requires an interface address inside every OSPF network,
+// otherwise the network statement enables nothing
+func (v *validator) validateOSPFNetwork(path, network string, networks []interfaceNetwork) {
+	_, ospfNet, err := net.ParseCIDR(network)
+	if err != nil {
+		v.add(path, "invalid prefix %q", network)
+		return
+	}
+	for _, intf := range networks {
+		if ospfNet.Contains(intf.ip) {
+			return
+		}
+	}
+	v.add(path, "network %s is not covered by any interface", network)
+}
+
+// validateBGPNeighbor requires the neighbor to be in a connected interface subnet
+func (v *validator) validateBGPNeighbor(path string, neighbor BGPNeighbor, networks []interfaceNetwork) {
+	ip := net.ParseIP(neighbor.IP)
+	if ip == nil {
+		v.add(path, "invalid IP address %q", neighbor.IP)
+		return
+	}
+	for _, intf := range networks {
+		if intf.network.Contains(ip) {
+			if ip.Equal(intf.ip) {
+				v.add(path, "neighbor %s is the address of %s", neighbor.IP, intf.name)
+			}
+			return
+		}
+	}
+	v.add(path, "neighbor %s is not reachable through any interface subnet", neighbor.IP)
+}
+
+func (v *validator) validateAccessList(path string, acl AccessList) {
+	sequences := map[int]int{}
+	for i, entry := range acl.Entries {
+		entryPath := fmt.Sprintf("%s.entries[%d]", path, i)
+		if first, ok := sequences[entry.Sequence]; ok {
+			v.add(entryPath+".sequence", "sequence %d of %s collides with entries[%d]", entry.Sequence, acl.Name, first)
+		} else {
+			sequences[entry.Sequence] = i
+		}
+
+		for _, field := range []struct{ name, value string }{{"source", entry.Source}, {"destination", entry.Destination}} {
+			if !validACLAddress(field.value) {
+				v.add(entryPath+"."+field.name, "invalid address %q, expected any, an IP address or a prefix", field.value)
+			}
+		}
+	}
+}
+
+func validACLAddress(s string) bool {
+	if s == "any" || net.ParseIP(s) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(s)
+	return err == nil
+}
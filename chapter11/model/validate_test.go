@@ -0,0 +1,161 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateValidModel(t *testing.T) {
+	if errs := Validate(createTestModel()); errs != nil {
+		t.Errorf("Expected no validation errors, got %v", errs)
+	}
+
+	model, err := LoadModel("model.json")
+	if err != nil {
+		t.Fatalf("Failed to load model.json: %v", err)
+	}
+	if errs := Validate(model); errs != nil {
+		t.Errorf("Expected model.json to be valid, got %v", errs)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(model *InfrastructureModel)
+		want   []string
+	}{
+		{
+			name: "duplicate device ID and hostname",
+			modify: func(model *InfrastructureModel) {
+				model.Devices[1].ID = model.Devices[0].ID
+				model.Devices[1].Hostname = strings.ToUpper(model.Devices[0].Hostname)
+			},
+			want: []string{"$.devices[1].id", "$.devices[1].hostname"},
+		},
+		{
+			name: "invalid addresses",
+			modify: func(model *InfrastructureModel) {
+				model.Devices[0].ManagementIP = "10.0.1"
+				model.Devices[0].Interfaces[0].IPAddress = "192.168.1.300"
+				model.Devices[0].Interfaces[1].SubnetMask = "255.0.255.0"
+				model.Devices[0].Routing.StaticRoutes[0].NextHop = "gateway"
+			},
+			want: []string{
+				"$.devices[0].management_ip",
+				"$.devices[0].interfaces[0].ip_address",
+				"$.devices[0].interfaces[1].subnet_mask",
+				"$.devices[0].routing.static_routes[0].next_hop",
+			},
+		},
+		{
+			name: "missing mask",
+			modify: func(model *InfrastructureModel) {
+				model.Devices[0].Interfaces[1].SubnetMask = ""
+			},
+			want: []string{"$.devices[0].interfaces[1].subnet_mask"},
+		},
+		{
+			name: "overlapping subnets",
+			modify: func(model *InfrastructureModel) {
+				model.Devices[0].Interfaces[1].SubnetMask = "255.255.255.0"
+			},
+			want: []string{"$.devices[0].interfaces[1].ip_address"},
+		},
+		{
+			name: "prefix length mask",
+			modify: func(model *InfrastructureModel) {
+				model.Devices[0].Interfaces[1].SubnetMask = "/30"
+			},
+		},
+		{
+			name: "vlans",
+			modify: func(model *InfrastructureModel) {
+				sw := &model.Devices[1]
+				sw.VLANs = append(sw.VLANs, VLAN{ID: 4095}, VLAN{ID: 100})
+				sw.Interfaces[0].VLAN = 200
+				sw.Interfaces = append(sw.Interfaces, Interface{Name: "ge-0/0/47", SwitchportMode: "trunk", AllowedVLANs: []int{100, 0}})
+			},
+			want: []string{
+				"$.devices[1].vlans[1].id",
+				"$.devices[1].vlans[2].id",
+				"$.devices[1].interfaces[0].vlan",
+				"$.devices[1].interfaces[1].allowed_vlans[1]",
+			},
+		},
+		{
+			name: "ospf networks",
+			modify: func(model *InfrastructureModel) {
+				model.Devices[0].Routing.Protocols = []RoutingProtocol{{
+					Protocol: "ospf",
+					RouterID: "1.1.1.1",
+					Areas:    []OSPFArea{{AreaID: "0", Networks: []string{"192.168.1.0/30", "192.168.2.0/30", "192.168.3.0"}}},
+				}}
+			},
+			want: []string{
+				"$.devices[0].routing.protocols[0].areas[0].networks[1]",
+				"$.devices[0].routing.protocols[0].areas[0].networks[2]",
+			},
+		},
+		{
+			name: "bgp neighbors",
+			modify: func(model *InfrastructureModel) {
+				model.Devices[0].Routing.Protocols = []RoutingProtocol{{
+					Protocol: "bgp",
+					ASNumber: "65001",
+					Neighbors: []BGPNeighbor{
+						{IP: "192.168.1.2", RemoteAS: "65002"},
+						{IP: "10.9.9.9", RemoteAS: "65003"},
+						{IP: "192.168.1.5", RemoteAS: "65004"},
+					},
+				}}
+			},
+			want: []string{
+				"$.devices[0].routing.protocols[0].neighbors[1].ip",
+				"$.devices[0].routing.protocols[0].neighbors[2].ip",
+			},
+		},
+		{
+			name: "acl sequence collision",
+			modify: func(model *InfrastructureModel) {
+				acl := &model.Security.AccessLists[0]
+				acl.Entries = append(acl.Entries,
+					ACLEntry{Sequence: 20, Action: "deny", Protocol: "ip", Source: "any", Destination: "any"},
+					ACLEntry{Sequence: 10, Action: "deny", Protocol: "ip", Source: "10.0.0.0/33", Destination: "any"},
+				)
+			},
+			want: []string{
+				"$.security.access_lists[0].entries[2].sequence",
+				"$.security.access_lists[0].entries[2].source",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := createTestModel()
+			tt.modify(model)
+
+			errs := Validate(model)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Expected %d validation errors, got %d: %v", len(tt.want), len(errs), errs)
+			}
+			for i, path := range tt.want {
+				if errs[i].Path != path {
+					t.Errorf("Expected error %d at %s, got %s", i, path, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidationErrorsMessage(t *testing.T) {
+	model := createTestModel()
+	model.Devices[1].ID = model.Devices[0].ID
+
+	err := Validate(model).Error()
+	want := `$.devices[1].id: duplicate device ID "test-rtr-01", also used by $.devices[0]`
+	if !strings.Contains(err, want) {
+		t.Errorf("Expected error to contain %q, got %q", want, err)
+	}
+}
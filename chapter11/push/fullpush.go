@@ -5,6 +5,7 @@ import (
 	"io"
 	"log"
 	"model"
+	"securecom"
 	"strings"
 	"time"
 
@@ -25,6 +26,8 @@ type ConfigBackup struct {
 type FullReplaceStrategy struct {
 	sshConfig *ssh.ClientConfig
 	timeout   time.Duration
+	port      int
+	jumpHosts []securecom.JumpHost
 }
 
 func NewFullReplaceStrategy(username, password string, timeout time.Duration) *FullReplaceStrategy {
@@ -40,12 +43,21 @@ func NewFullReplaceStrategy(username, password string, timeout time.Duration) *F
 	return &FullReplaceStrategy{
 		sshConfig: config,
 		timeout:   timeout,
+		port:      22,
 	}
 }
 
+func (s *FullReplaceStrategy) SetJumpHosts(hosts []securecom.JumpHost) {
+	s.jumpHosts = hosts
+}
+
+func (s *FullReplaceStrategy) SetPort(port int) {
+	s.port = port
+}
+
 func (s *FullReplaceStrategy) connectSSH(device *model.Device) (*ssh.Client, error) {
-	addr := fmt.Sprintf("%s:22", device.ManagementIP)
-	client, err := ssh.Dial("tcp", addr, s.sshConfig)
+	addr := fmt.Sprintf("%s:%d", device.ManagementIP, s.port)
+	client, err := securecom.DialThroughJumpHosts(s.jumpHosts, addr, s.sshConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to %s: %w", device.ManagementIP, err)
 	}
@@ -3,6 +3,8 @@ package push
 import (
 	"errors"
 	"model"
+	"securecom"
+	"securecom/sshtest"
 	"strings"
 	"testing"
 	"time"
@@ -490,3 +492,160 @@ func TestDeploymentResultWithError(t *testing.T) {
 		t.Errorf("Expected error %v, got %v", deployError, result.Error)
 	}
 }
+
+func TestFullReplaceStrategySetJumpHosts(t *testing.T) {
+	strategy := NewFullReplaceStrategy("admin", "password", 2*time.Second)
+
+	jumps := []securecom.JumpHost{
+		{Hostname: "bastion1.example.com", Username: "ops", Password: "secret"},
+		{Hostname: "bastion2.example.com", Port: 2222, Username: "ops", Password: "secret"},
+	}
+	strategy.SetJumpHosts(jumps)
+
+	if len(strategy.jumpHosts) != 2 {
+		t.Fatalf("Expected 2 jump hosts, got %d", len(strategy.jumpHosts))
+	}
+
+	if strategy.jumpHosts[1].Port != 2222 {
+		t.Errorf("Expected second jump host port 2222, got %d", strategy.jumpHosts[1].Port)
+	}
+}
+
+func TestFullReplaceConnectThroughUnreachableJumpHost(t *testing.T) {
+	strategy := NewFullReplaceStrategy("admin", "password", 1*time.Second)
+	strategy.SetJumpHosts([]securecom.JumpHost{
+		{Hostname: "127.0.0.1", Port: 1, Username: "ops", Password: "secret"},
+	})
+
+	_, err := strategy.connectSSH(createTestDeviceForDeployment("cisco"))
+	if err == nil {
+		t.Fatal("Expected error when jump host is unreachable")
+	}
+
+	if !strings.Contains(err.Error(), "jump host") {
+		t.Errorf("Expected error to mention the jump host, got: %v", err)
+	}
+}
+
+func startTestDevice(t *testing.T, cfg sshtest.Config) (*sshtest.Server, *model.Device) {
+	t.Helper()
+
+	cfg.Username, cfg.Password = "admin", "password"
+	server, err := sshtest.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Failed to start test device: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	device := createTestDeviceForDeployment(cfg.Vendor)
+	device.ManagementIP = server.Host()
+	return server, device
+}
+
+func TestFullReplaceStrategySetPort(t *testing.T) {
+	strategy := NewFullReplaceStrategy("admin", "password", 2*time.Second)
+
+	if strategy.port != 22 {
+		t.Errorf("Expected default port 22, got %d", strategy.port)
+	}
+
+	strategy.SetPort(2222)
+	if strategy.port != 2222 {
+		t.Errorf("Expected port 2222, got %d", strategy.port)
+	}
+}
+
+func TestBackupCurrentConfigFromTestDevice(t *testing.T) {
+	tests := []struct {
+		vendor  string
+		command string
+	}{
+		{vendor: "cisco", command: "show running-config"},
+		{vendor: "juniper", command: "show configuration"},
+	}
+
+	for _, tt := range tests {
+		server, device := startTestDevice(t, sshtest.Config{
+			Vendor:        tt.vendor,
+			RunningConfig: "hostname " + tt.vendor + "-router\n",
+		})
+
+		strategy := NewFullReplaceStrategy("admin", "password", 5*time.Second)
+		strategy.SetPort(server.Port())
+
+		backup, err := strategy.BackupCurrentConfig(device)
+		if err != nil {
+			t.Fatalf("%s: backup failed: %v", tt.vendor, err)
+		}
+
+		if backup.Config != "hostname "+tt.vendor+"-router\n" {
+			t.Errorf("%s: unexpected backup %q", tt.vendor, backup.Config)
+		}
+
+		if commands := server.Commands(); len(commands) != 1 || commands[0] != tt.command {
+			t.Errorf("%s: expected command %q, got %v", tt.vendor, tt.command, commands)
+		}
+	}
+}
+
+func TestBackupCurrentConfigWrongCredentials(t *testing.T) {
+	server, device := startTestDevice(t, sshtest.Config{Vendor: "cisco"})
+
+	strategy := NewFullReplaceStrategy("admin", "wrong", 5*time.Second)
+	strategy.SetPort(server.Port())
+
+	if _, err := strategy.BackupCurrentConfig(device); err == nil {
+		t.Fatal("Expected authentication error, got nil")
+	}
+}
+
+func TestFullReplaceDeployToTestDevice(t *testing.T) {
+	server, device := startTestDevice(t, sshtest.Config{
+		Vendor:        "cisco",
+		RunningConfig: "hostname old-router\n",
+	})
+
+	strategy := NewFullReplaceStrategy("admin", "password", 5*time.Second)
+	strategy.SetPort(server.Port())
+
+	config := "hostname new-router\ninterface Loopback0\n ip address 10.0.0.1 255.255.255.255\n"
+	if err := strategy.Deploy(device, config); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	if server.RunningConfig() != config {
+		t.Errorf("Expected running config %q, got %q", config, server.RunningConfig())
+	}
+
+	commands := strings.Join(server.Commands(), "\n")
+	for _, expected := range []string{"configure replace terminal", "write memory"} {
+		if !strings.Contains(commands, expected) {
+			t.Errorf("Expected device to receive %q, got %v", expected, server.Commands())
+		}
+	}
+}
+
+func TestFullReplaceDeployRollsBackOnDisconnect(t *testing.T) {
+	// The backup is the first command, the connection drops on the second
+	server, device := startTestDevice(t, sshtest.Config{
+		Vendor:              "cisco",
+		RunningConfig:       "hostname old-router\n",
+		DisconnectOnCommand: 2,
+	})
+
+	strategy := NewFullReplaceStrategy("admin", "password", 5*time.Second)
+	strategy.SetPort(server.Port())
+
+	err := strategy.Deploy(device, "hostname new-router\n")
+	if err == nil {
+		t.Fatal("Expected deployment to fail on disconnect")
+	}
+
+	if !strings.Contains(err.Error(), "successfully rolled back") {
+		t.Errorf("Expected rollback to succeed, got: %v", err)
+	}
+
+	if server.RunningConfig() != "hostname old-router\n" {
+		t.Errorf("Expected backup config to be restored, got %q", server.RunningConfig())
+	}
+}
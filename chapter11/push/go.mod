@@ -1,10 +1,14 @@
 module push
 
-replace model => /home/vzhovtan/gosrc/gofordevops-book/chapter11/model
+replace model => ../model
 
-replace render => /home/vzhovtan/gosrc/gofordevops-book/chapter11/render
+replace render => ../render
 
-go 1.24.0
+replace securecom => ../../chapter10/securecom
+
+replace discovery => ../../chapter10/discovery
+
+go 1.24.9
 
 toolchain go1.24.11
 
@@ -12,6 +16,18 @@ require (
 	golang.org/x/crypto v0.47.0
 	model v0.0.0-00010101000000-000000000000
 	render v0.0.0-00010101000000-000000000000
+	securecom v0.0.0-00010101000000-000000000000
 )
 
-require golang.org/x/sys v0.40.0 // indirect
+require (
+	discovery v0.0.0-00010101000000-000000000000 // indirect
+	github.com/kr/fs v0.1.0 // indirect
+	github.com/pkg/sftp v1.13.9 // indirect
+	golang.org/x/net v0.48.0 // indirect
+	golang.org/x/sys v0.40.0 // indirect
+	golang.org/x/text v0.33.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250804133106-a7a43d27e69b // indirect
+	google.golang.org/grpc v1.76.0 // indirect
+	google.golang.org/protobuf v1.36.6 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -5,6 +5,7 @@ import (
 	"log"
 	"model"
 	"render"
+	"securecom"
 	"strings"
 	"time"
 
@@ -22,6 +23,8 @@ type ConfigElement struct {
 type PerElementStrategy struct {
 	sshConfig *ssh.ClientConfig
 	timeout   time.Duration
+	port      int
+	jumpHosts []securecom.JumpHost
 }
 
 func NewPerElementStrategy(username, password string, timeout time.Duration) *PerElementStrategy {
@@ -37,12 +40,21 @@ func NewPerElementStrategy(username, password string, timeout time.Duration) *Pe
 	return &PerElementStrategy{
 		sshConfig: config,
 		timeout:   timeout,
+		port:      22,
 	}
 }
 
+func (s *PerElementStrategy) SetJumpHosts(hosts []securecom.JumpHost) {
+	s.jumpHosts = hosts
+}
+
+func (s *PerElementStrategy) SetPort(port int) {
+	s.port = port
+}
+
 func (s *PerElementStrategy) connectSSH(device *model.Device) (*ssh.Client, error) {
-	addr := fmt.Sprintf("%s:22", device.ManagementIP)
-	client, err := ssh.Dial("tcp", addr, s.sshConfig)
+	addr := fmt.Sprintf("%s:%d", device.ManagementIP, s.port)
+	client, err := securecom.DialThroughJumpHosts(s.jumpHosts, addr, s.sshConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to %s: %w", device.ManagementIP, err)
 	}
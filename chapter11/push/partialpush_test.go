@@ -3,6 +3,8 @@ package push
 import (
 	"errors"
 	"model"
+	"securecom"
+	"securecom/sshtest"
 	"strings"
 	"testing"
 	"time"
@@ -653,3 +655,96 @@ func TestIPAddressCIDRConversion(t *testing.T) {
 		t.Error("Expected to find IP address with CIDR notation (/30)")
 	}
 }
+
+func TestPerElementConnectThroughUnreachableJumpHost(t *testing.T) {
+	strategy := NewPerElementStrategy("admin", "password", 1*time.Second)
+	strategy.SetJumpHosts([]securecom.JumpHost{
+		{Hostname: "127.0.0.1", Port: 1, Username: "ops", Password: "secret"},
+	})
+
+	_, err := strategy.connectSSH(createJuniperTestDevice())
+	if err == nil {
+		t.Fatal("Expected error when jump host is unreachable")
+	}
+
+	if !strings.Contains(err.Error(), "jump host") {
+		t.Errorf("Expected error to mention the jump host, got: %v", err)
+	}
+}
+
+func startJuniperTestDevice(t *testing.T, responses map[string]string) (*sshtest.Server, *model.Device) {
+	t.Helper()
+
+	server, err := sshtest.NewServer(sshtest.Config{
+		Username:      "admin",
+		Password:      "password",
+		Vendor:        "juniper",
+		RunningConfig: "set system host-name test-juniper-switch\n",
+		Responses:     responses,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start test device: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	device := createJuniperTestDevice()
+	device.ManagementIP = server.Host()
+	return server, device
+}
+
+func TestPerElementDeployToTestDevice(t *testing.T) {
+	server, device := startJuniperTestDevice(t, map[string]string{
+		"request system snapshot slice alternate media internal": "Snapshot completed\n",
+	})
+
+	strategy := NewPerElementStrategy("admin", "password", 5*time.Second)
+	strategy.SetPort(server.Port())
+
+	if err := strategy.Deploy(device, ""); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	running := server.RunningConfig()
+	for _, expected := range []string{
+		"set system host-name test-juniper-switch",
+		"set system ntp server 10.0.0.1",
+		"set system ntp server 10.0.0.2",
+	} {
+		if !strings.Contains(running, expected) {
+			t.Errorf("Expected committed config to contain %q, got:\n%s", expected, running)
+		}
+	}
+
+	commands := server.Commands()
+	if commands[len(commands)-1] != "configure; commit and-quit" {
+		t.Errorf("Expected final commit, got %q", commands[len(commands)-1])
+	}
+}
+
+func TestPerElementDeployRollsBackOnCommitCheckError(t *testing.T) {
+	server, device := startJuniperTestDevice(t, map[string]string{
+		"request system snapshot slice alternate media internal": "Snapshot completed\n",
+		"commit check": "error: configuration check-out failed\n",
+	})
+
+	strategy := NewPerElementStrategy("admin", "password", 5*time.Second)
+	strategy.SetPort(server.Port())
+
+	err := strategy.Deploy(device, "")
+	if err == nil {
+		t.Fatal("Expected deploy to fail on commit check error")
+	}
+
+	if !strings.Contains(err.Error(), "failed to apply element") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if server.RunningConfig() != "set system host-name test-juniper-switch\n" {
+		t.Errorf("Expected configuration to be unchanged, got %q", server.RunningConfig())
+	}
+
+	commands := server.Commands()
+	if commands[len(commands)-1] != "configure; rollback; commit and-quit" {
+		t.Errorf("Expected rollback, got %q", commands[len(commands)-1])
+	}
+}
@@ -0,0 +1,12 @@
+module validate
+
+replace model => ../model
+
+replace extraction => ../../chapter10/extraction
+
+go 1.24.9
+
+require (
+	extraction v0.0.0-00010101000000-000000000000
+	model v0.0.0-00010101000000-000000000000
+)
@@ -0,0 +1,289 @@
+package validate
+
+import (
+	"extraction"
+	"fmt"
+	"io"
+	"model"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+type Drift struct {
+	Severity Severity `json:"severity"`
+	Check    string   `json:"check"`
+	Object   string   `json:"object"`
+	Expected string   `json:"expected,omitempty"`
+	Actual   string   `json:"actual,omitempty"`
+	Message  string   `json:"message"`
+}
+
+type ComplianceReport struct {
+	DeviceID string  `json:"device_id"`
+	Hostname string  `json:"hostname"`
+	Checks   int     `json:"checks"`
+	Drift    []Drift `json:"drift"`
+}
+
+func (r *ComplianceReport) Compliant() bool {
+	for _, d := range r.Drift {
+		if d.Severity == SeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *ComplianceReport) Count(severity Severity) int {
+	count := 0
+	for _, d := range r.Drift {
+		if d.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *ComplianceReport) Print(w io.Writer) {
+	status := "COMPLIANT"
+	if !r.Compliant() {
+		status = "NOT COMPLIANT"
+	}
+	fmt.Fprintf(w, "%s (%s): %s, %d checks, %d errors, %d warnings\n", r.DeviceID, r.Hostname, status,
+		r.Checks, r.Count(SeverityError), r.Count(SeverityWarning))
+	for _, d := range r.Drift {
+		fmt.Fprintf(w, "  [%s] %s %s: %s\n", strings.ToUpper(string(d.Severity)), d.Check, d.Object, d.Message)
+	}
+}
+
+func (r *ComplianceReport) add(severity Severity, check, object, expected, actual, message string) {
+	r.Drift = append(r.Drift, Drift{
+		Severity: severity,
+		Check:    check,
+		Object:   object,
+		Expected: expected,
+		Actual:   actual,
+		Message:  message,
+	})
+}
+
+func Validate(m *model.InfrastructureModel, states map[string]*extraction.OperationalState) []*ComplianceReport {
+	reports := make([]*ComplianceReport, 0, len(m.Devices))
+	for _, device := range m.Devices {
+		state, ok := states[device.ID]
+		if !ok {
+			report := &ComplianceReport{DeviceID: device.ID, Hostname: device.Hostname, Checks: 1}
+			report.add(SeverityError, "state", "device", "", "", "no operational state collected")
+			reports = append(reports, report)
+			continue
+		}
+		reports = append(reports, ValidateDevice(device, state))
+	}
+	return reports
+}
+
+func ValidateDevice(device model.Device, state *extraction.OperationalState) *ComplianceReport {
+	report := &ComplianceReport{DeviceID: device.ID, Hostname: device.Hostname}
+
+	interfaces := map[string]extraction.InterfaceState{}
+	for _, intf := range state.Interfaces {
+		interfaces[strings.ToLower(intf.Name)] = intf
+	}
+	for _, intf := range device.Interfaces {
+		actual, ok := interfaces[strings.ToLower(intf.Name)]
+		validateInterface(report, intf, actual, ok)
+	}
+
+	if device.Routing != nil {
+		for _, protocol := range device.Routing.Protocols {
+			switch strings.ToLower(protocol.Protocol) {
+			case "ospf", "isis":
+				validateIGP(report, device, protocol, state)
+			case "bgp":
+				validateBGP(report, protocol, state)
+			}
+		}
+	}
+	return report
+}
+
+func validateInterface(report *ComplianceReport, intf model.Interface, actual extraction.InterfaceState, found bool) {
+	object := "interface " + intf.Name
+
+	report.Checks++
+	if !found {
+		report.add(SeverityError, "interface", object, "present", "missing", "interface not found on the device")
+		return
+	}
+
+	report.Checks++
+	switch {
+	case intf.Enabled && actual.AdminStatus == extraction.LinkDown:
+		report.add(SeverityError, "admin-status", object, "up", "down", "interface is admin-down but should be up")
+	case !intf.Enabled && actual.AdminStatus == extraction.LinkUp:
+		report.add(SeverityWarning, "admin-status", object, "down", "up", "interface is up but should be shut down")
+	case intf.Enabled && actual.OperStatus == extraction.LinkDown:
+		report.add(SeverityError, "oper-status", object, "up", "down", "interface is enabled but the link is down")
+	}
+
+	if intf.MTU > 0 && actual.MTU > 0 {
+		report.Checks++
+		if intf.MTU != actual.MTU {
+			report.add(SeverityError, "mtu", object, strconv.Itoa(intf.MTU), strconv.Itoa(actual.MTU),
+				fmt.Sprintf("MTU is %d but should be %d", actual.MTU, intf.MTU))
+		}
+	}
+
+	report.Checks++
+	if strings.TrimSpace(intf.Description) != strings.TrimSpace(actual.Description) {
+		report.add(SeverityWarning, "description", object, intf.Description, actual.Description,
+			fmt.Sprintf("description is %q but should be %q", actual.Description, intf.Description))
+	}
+}
+
+// validateIGP expects an established adjacency on every enabled interface
+// whose address falls in one of the OSPF networks, the model has no ISIS
+// networks so ISIS expects one on every enabled routed interface except
+// loopback and management interfaces
+func validateIGP(report *ComplianceReport, device model.Device, protocol model.RoutingProtocol, state *extraction.OperationalState) {
+	name := strings.ToLower(protocol.Protocol)
+
+	var networks []*net.IPNet
+	for _, area := range protocol.Areas {
+		for _, network := range area.Networks {
+			if _, ipNet, err := net.ParseCIDR(network); err == nil {
+				networks = append(networks, ipNet)
+			}
+		}
+	}
+
+	adjacencies := map[string][]extraction.RoutingAdjacency{}
+	for _, adj := range state.Adjacencies {
+		if adj.Protocol == name {
+			key := strings.ToLower(adj.Interface)
+			adjacencies[key] = append(adjacencies[key], adj)
+		}
+	}
+
+	for _, intf := range device.Interfaces {
+		ip := net.ParseIP(intf.IPAddress)
+		if !intf.Enabled || ip == nil {
+			continue
+		}
+		if name == "isis" && !adjacencyCapable(intf.Name) {
+			continue
+		}
+		if name != "isis" && !containedIn(ip, networks) {
+			continue
+		}
+
+		object := fmt.Sprintf("%s neighbor on %s", name, intf.Name)
+		report.Checks++
+		found := adjacencies[strings.ToLower(intf.Name)]
+		if len(found) == 0 {
+			report.add(SeverityError, name+"-neighbor", object, "up", "missing",
+				fmt.Sprintf("no %s adjacency on %s", strings.ToUpper(name), intf.Name))
+			continue
+		}
+		up := false
+		var states []string
+		for _, adj := range found {
+			if adj.State == "up" || adj.State == "full" {
+				up = true
+			}
+			states = append(states, adj.State)
+		}
+		if !up {
+			report.add(SeverityError, name+"-neighbor", object, "up", strings.Join(states, ","),
+				fmt.Sprintf("%s adjacency on %s is %s", strings.ToUpper(name), intf.Name, strings.Join(states, ",")))
+		}
+	}
+}
+
+// adjacencyCapable excludes the interfaces that never form an IGP adjacency
+func adjacencyCapable(name string) bool {
+	name = strings.ToLower(name)
+	for _, prefix := range []string{"loopback", "lo", "mgmt", "management", "fxp", "em", "me"} {
+		if strings.HasPrefix(name, prefix) && (len(name) == len(prefix) || name[len(prefix)] >= '0' && name[len(prefix)] <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func containedIn(ip net.IP, networks []*net.IPNet) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultVRF is the VRF of the routing protocols in the model, which has no VRFs
+const defaultVRF = "default"
+
+type bgpKey struct {
+	vrf      string
+	neighbor string
+}
+
+// validateBGP compares the model neighbors with the sessions of the default VRF,
+// sessions of other VRFs are outside the model and not reported
+func validateBGP(report *ComplianceReport, protocol model.RoutingProtocol, state *extraction.OperationalState) {
+	sessions := map[bgpKey]extraction.BGPSession{}
+	for _, session := range state.BGPSessions {
+		vrf := session.VRF
+		if vrf == "" {
+			vrf = defaultVRF
+		}
+		sessions[bgpKey{vrf, session.Neighbor}] = session
+	}
+
+	expected := map[bgpKey]bool{}
+	for _, neighbor := range protocol.Neighbors {
+		key := bgpKey{defaultVRF, neighbor.IP}
+		expected[key] = true
+		object := "bgp neighbor " + neighbor.IP
+
+		report.Checks++
+		session, ok := sessions[key]
+		if !ok {
+			report.add(SeverityError, "bgp-neighbor", object, "established", "missing", "BGP session not configured on the device")
+			continue
+		}
+		if !session.Established() {
+			report.add(SeverityError, "bgp-neighbor", object, "established", session.State,
+				fmt.Sprintf("BGP session is %s", session.State))
+		}
+
+		if neighbor.RemoteAS != "" {
+			report.Checks++
+			if remoteAS := strconv.FormatUint(uint64(session.RemoteAS), 10); remoteAS != neighbor.RemoteAS {
+				report.add(SeverityError, "bgp-remote-as", object, neighbor.RemoteAS, remoteAS,
+					fmt.Sprintf("remote AS is %s but should be %s", remoteAS, neighbor.RemoteAS))
+			}
+		}
+	}
+
+	var unexpected []string
+	for key := range sessions {
+		if key.vrf == defaultVRF && !expected[key] {
+			unexpected = append(unexpected, key.neighbor)
+		}
+	}
+	sort.Strings(unexpected)
+	for _, neighbor := range unexpected {
+		report.add(SeverityWarning, "bgp-neighbor", "bgp neighbor "+neighbor, "absent",
+			sessions[bgpKey{defaultVRF, neighbor}].State, "BGP session is not in the model")
+	}
+}
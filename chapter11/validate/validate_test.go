@@ -0,0 +1,283 @@
+package validate
+
+import (
+	"bytes"
+	"extraction"
+	"model"
+	"strings"
+	"testing"
+)
+
+func testDevice() model.Device {
+	return model.Device{
+		ID:       "core-01",
+		Hostname: "core-router-01",
+		Vendor:   "cisco",
+		Interfaces: []model.Interface{
+			{Name: "Ethernet1/1", Description: "to dist-01", IPAddress: "10.0.0.1", SubnetMask: "255.255.255.252", Enabled: true, MTU: 9216},
+			{Name: "Ethernet1/2", Description: "to dist-02", IPAddress: "10.0.0.5", SubnetMask: "255.255.255.252", Enabled: true, MTU: 9216},
+			{Name: "Ethernet1/3", Description: "unused", Enabled: false},
+		},
+		Routing: &model.Routing{
+			Protocols: []model.RoutingProtocol{
+				{
+					Protocol: "ospf",
+					Areas:    []model.OSPFArea{{AreaID: "0", Networks: []string{"10.0.0.0/24"}}},
+				},
+				{
+					Protocol:  "bgp",
+					ASNumber:  "65000",
+					Neighbors: []model.BGPNeighbor{{IP: "192.0.2.1", RemoteAS: "65001"}},
+				},
+			},
+		},
+	}
+}
+
+func compliantState() *extraction.OperationalState {
+	return &extraction.OperationalState{
+		Interfaces: []extraction.InterfaceState{
+			{Name: "Ethernet1/1", Description: "to dist-01", AdminStatus: extraction.LinkUp, OperStatus: extraction.LinkUp, MTU: 9216},
+			{Name: "Ethernet1/2", Description: "to dist-02", AdminStatus: extraction.LinkUp, OperStatus: extraction.LinkUp, MTU: 9216},
+			{Name: "Ethernet1/3", Description: "unused", AdminStatus: extraction.LinkDown, OperStatus: extraction.LinkDown},
+		},
+		Adjacencies: []extraction.RoutingAdjacency{
+			{Protocol: "ospf", NeighborID: "10.255.0.2", Interface: "Ethernet1/1", State: "full"},
+			{Protocol: "ospf", NeighborID: "10.255.0.3", Interface: "Ethernet1/2", State: "full"},
+		},
+		BGPSessions: []extraction.BGPSession{
+			{Neighbor: "192.0.2.1", RemoteAS: 65001, State: "established"},
+		},
+	}
+}
+
+type drift struct {
+	severity Severity
+	check    string
+	object   string
+}
+
+func TestValidateDevice(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(state *extraction.OperationalState)
+		want   []drift
+	}{
+		{
+			name:   "compliant",
+			modify: func(state *extraction.OperationalState) {},
+		},
+		{
+			name: "admin down",
+			modify: func(state *extraction.OperationalState) {
+				state.Interfaces[0].AdminStatus = extraction.LinkDown
+				state.Interfaces[0].OperStatus = extraction.LinkDown
+				state.Adjacencies = state.Adjacencies[1:]
+			},
+			want: []drift{
+				{SeverityError, "admin-status", "interface Ethernet1/1"},
+				{SeverityError, "ospf-neighbor", "ospf neighbor on Ethernet1/1"},
+			},
+		},
+		{
+			name: "link down",
+			modify: func(state *extraction.OperationalState) {
+				state.Interfaces[1].OperStatus = extraction.LinkDown
+				state.Adjacencies[1].State = "init"
+			},
+			want: []drift{
+				{SeverityError, "oper-status", "interface Ethernet1/2"},
+				{SeverityError, "ospf-neighbor", "ospf neighbor on Ethernet1/2"},
+			},
+		},
+		{
+			name: "disabled interface up",
+			modify: func(state *extraction.OperationalState) {
+				state.Interfaces[2].AdminStatus = extraction.LinkUp
+			},
+			want: []drift{
+				{SeverityWarning, "admin-status", "interface Ethernet1/3"},
+			},
+		},
+		{
+			name: "mtu and description",
+			modify: func(state *extraction.OperationalState) {
+				state.Interfaces[0].MTU = 1500
+				state.Interfaces[1].Description = "to dist-03"
+			},
+			want: []drift{
+				{SeverityError, "mtu", "interface Ethernet1/1"},
+				{SeverityWarning, "description", "interface Ethernet1/2"},
+			},
+		},
+		{
+			name: "missing interface",
+			modify: func(state *extraction.OperationalState) {
+				state.Interfaces = state.Interfaces[:2]
+			},
+			want: []drift{
+				{SeverityError, "interface", "interface Ethernet1/3"},
+			},
+		},
+		{
+			name: "bgp drift",
+			modify: func(state *extraction.OperationalState) {
+				state.BGPSessions = []extraction.BGPSession{
+					{Neighbor: "192.0.2.1", RemoteAS: 65002, State: "active"},
+					{Neighbor: "192.0.2.9", RemoteAS: 65009, State: "established"},
+				}
+			},
+			want: []drift{
+				{SeverityError, "bgp-neighbor", "bgp neighbor 192.0.2.1"},
+				{SeverityError, "bgp-remote-as", "bgp neighbor 192.0.2.1"},
+				{SeverityWarning, "bgp-neighbor", "bgp neighbor 192.0.2.9"},
+			},
+		},
+		{
+			name: "bgp sessions in other vrfs",
+			modify: func(state *extraction.OperationalState) {
+				state.BGPSessions = []extraction.BGPSession{
+					{Neighbor: "192.0.2.1", RemoteAS: 65001, State: "established", VRF: "default"},
+					{Neighbor: "192.0.2.1", RemoteAS: 64512, State: "idle", VRF: "customer-a"},
+					{Neighbor: "198.51.100.1", RemoteAS: 64513, State: "established", VRF: "customer-b"},
+				}
+			},
+		},
+		{
+			name: "bgp neighbor only in another vrf",
+			modify: func(state *extraction.OperationalState) {
+				state.BGPSessions = []extraction.BGPSession{
+					{Neighbor: "192.0.2.1", RemoteAS: 65001, State: "established", VRF: "customer-a"},
+				}
+			},
+			want: []drift{
+				{SeverityError, "bgp-neighbor", "bgp neighbor 192.0.2.1"},
+			},
+		},
+		{
+			name: "missing bgp session",
+			modify: func(state *extraction.OperationalState) {
+				state.BGPSessions = nil
+			},
+			want: []drift{
+				{SeverityError, "bgp-neighbor", "bgp neighbor 192.0.2.1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := compliantState()
+			tt.modify(state)
+
+			report := ValidateDevice(testDevice(), state)
+
+			var got []drift
+			for _, d := range report.Drift {
+				got = append(got, drift{d.Severity, d.Check, d.Object})
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d drift entries, got %d: %+v", len(tt.want), len(got), report.Drift)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Drift %d: expected %+v, got %+v", i, tt.want[i], got[i])
+				}
+			}
+
+			wantCompliant := true
+			for _, d := range tt.want {
+				if d.severity == SeverityError {
+					wantCompliant = false
+				}
+			}
+			if report.Compliant() != wantCompliant {
+				t.Errorf("Expected compliant %v, got %v", wantCompliant, report.Compliant())
+			}
+		})
+	}
+}
+
+func TestValidateISIS(t *testing.T) {
+	device := model.Device{
+		ID:       "spine-01",
+		Hostname: "spine-01",
+		Interfaces: []model.Interface{
+			{Name: "Ethernet1/1", IPAddress: "10.1.0.1", SubnetMask: "255.255.255.254", Enabled: true},
+			{Name: "Ethernet1/2", IPAddress: "10.1.0.3", SubnetMask: "255.255.255.254", Enabled: true},
+			{Name: "Ethernet1/3", IPAddress: "10.1.0.5", SubnetMask: "255.255.255.254", Enabled: true},
+			{Name: "Ethernet1/4", IPAddress: "10.1.0.7", SubnetMask: "255.255.255.254", Enabled: false},
+			{Name: "Ethernet1/5", Enabled: true, SwitchportMode: "access", VLAN: 10},
+			{Name: "Loopback0", IPAddress: "10.255.0.1", SubnetMask: "255.255.255.255", Enabled: true},
+			{Name: "mgmt0", IPAddress: "192.0.2.10", SubnetMask: "255.255.255.0", Enabled: true},
+		},
+		Routing: &model.Routing{Protocols: []model.RoutingProtocol{{Protocol: "isis", ProcessID: "underlay"}}},
+	}
+
+	state := &extraction.OperationalState{
+		Adjacencies: []extraction.RoutingAdjacency{
+			{Protocol: "isis", Hostname: "leaf-01", Interface: "Ethernet1/1", State: "up"},
+			{Protocol: "isis", Hostname: "leaf-02", Interface: "Ethernet1/2", State: "init"},
+		},
+	}
+	for _, intf := range device.Interfaces {
+		adminStatus := extraction.LinkUp
+		if !intf.Enabled {
+			adminStatus = extraction.LinkDown
+		}
+		state.Interfaces = append(state.Interfaces, extraction.InterfaceState{Name: intf.Name, AdminStatus: adminStatus, OperStatus: adminStatus})
+	}
+
+	report := ValidateDevice(device, state)
+
+	want := []drift{
+		{SeverityError, "isis-neighbor", "isis neighbor on Ethernet1/2"},
+		{SeverityError, "isis-neighbor", "isis neighbor on Ethernet1/3"},
+	}
+	var got []drift
+	for _, d := range report.Drift {
+		got = append(got, drift{d.Severity, d.Check, d.Object})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d drift entries, got %d: %+v", len(want), len(got), report.Drift)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("Drift %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+	if report.Drift[1].Actual != "missing" {
+		t.Errorf("Expected a missing adjacency on Ethernet1/3, got %+v", report.Drift[1])
+	}
+}
+
+func TestValidateMissingState(t *testing.T) {
+	m := &model.InfrastructureModel{Devices: []model.Device{testDevice(), {ID: "edge-01", Hostname: "edge-router-01"}}}
+	states := map[string]*extraction.OperationalState{"core-01": compliantState()}
+
+	reports := Validate(m, states)
+	if len(reports) != 2 {
+		t.Fatalf("Expected 2 reports, got %d", len(reports))
+	}
+	if !reports[0].Compliant() {
+		t.Errorf("Expected core-01 to be compliant, got %+v", reports[0].Drift)
+	}
+	if reports[1].Compliant() || reports[1].Drift[0].Check != "state" {
+		t.Errorf("Expected edge-01 to report missing state, got %+v", reports[1].Drift)
+	}
+}
+
+func TestReportPrint(t *testing.T) {
+	state := compliantState()
+	state.Interfaces[0].MTU = 1500
+
+	var buf bytes.Buffer
+	ValidateDevice(testDevice(), state).Print(&buf)
+
+	output := buf.String()
+	for _, want := range []string{"core-01 (core-router-01): NOT COMPLIANT", "1 errors", "[ERROR] mtu interface Ethernet1/1: MTU is 1500 but should be 9216"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
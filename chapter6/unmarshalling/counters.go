@@ -0,0 +1,171 @@
+package unmarshalling
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// InterfaceCounters holds the counters of an interface since they were last cleared
+type InterfaceCounters struct {
+	InPackets       uint64
+	InBytes         uint64
+	InUnicast       uint64
+	InMulticast     uint64
+	InBroadcast     uint64
+	InJumbo         uint64
+	InErrors        uint64
+	InDiscards      uint64
+	InIfDownDrops   uint64
+	InPause         uint64
+	CRC             uint64
+	StompedCRC      uint64
+	Runts           uint64
+	Giants          uint64
+	Frame           uint64
+	Overrun         uint64
+	Underrun        uint64
+	Ignored         uint64
+	NoBuffer        uint64
+	StormSuppressed uint64
+
+	OutPackets     uint64
+	OutBytes       uint64
+	OutUnicast     uint64
+	OutMulticast   uint64
+	OutBroadcast   uint64
+	OutJumbo       uint64
+	OutErrors      uint64
+	OutDiscards    uint64
+	OutPause       uint64
+	Collisions     uint64
+	LateCollisions uint64
+	Deferred       uint64
+	LostCarrier    uint64
+	NoCarrier      uint64
+	Babbles        uint64
+
+	// Resets is the number of times the interface was reset
+	Resets uint64
+	// Rates are the averages the device computes over its load intervals
+	Rates []InterfaceRate
+}
+
+// InterfaceRate is the average traffic over one load interval, in bits and packets per second
+type InterfaceRate struct {
+	Interval   time.Duration
+	InBits     uint64
+	InPackets  uint64
+	OutBits    uint64
+	OutPackets uint64
+}
+
+// errorCounters are the counters whose increase points at a link problem
+func (c *InterfaceCounters) errorCounters() map[string]uint64 {
+	return map[string]uint64{
+		"InErrors":       c.InErrors,
+		"InDiscards":     c.InDiscards,
+		"CRC":            c.CRC,
+		"StompedCRC":     c.StompedCRC,
+		"Runts":          c.Runts,
+		"Giants":         c.Giants,
+		"Frame":          c.Frame,
+		"Overrun":        c.Overrun,
+		"Underrun":       c.Underrun,
+		"Ignored":        c.Ignored,
+		"NoBuffer":       c.NoBuffer,
+		"OutErrors":      c.OutErrors,
+		"OutDiscards":    c.OutDiscards,
+		"Collisions":     c.Collisions,
+		"LateCollisions": c.LateCollisions,
+		"LostCarrier":    c.LostCarrier,
+		"NoCarrier":      c.NoCarrier,
+		"Babbles":        c.Babbles,
+	}
+}
+
+// CounterRates is the change between two counter snapshots
+type CounterRates struct {
+	Interval             time.Duration
+	InBitsPerSecond      float64
+	OutBitsPerSecond     float64
+	InPacketsPerSecond   float64
+	OutPacketsPerSecond  float64
+	InErrorsPerSecond    float64
+	OutErrorsPerSecond   float64
+	InDiscardsPerSecond  float64
+	OutDiscardsPerSecond float64
+	// ErrorIncreases holds each error or discard counter that went up, with its increase
+	ErrorIncreases map[string]uint64
+	// Cleared reports that a counter went down because the counters were cleared
+	// between the snapshots, the increase of such a counter is counted from zero
+	Cleared bool
+}
+
+// Method ErrorsIncreased reports whether any error or discard counter went up
+func (r CounterRates) ErrorsIncreased() bool {
+	return len(r.ErrorIncreases) > 0
+}
+
+// Method IncreasedCounters returns the names of the counters that went up, sorted
+func (r CounterRates) IncreasedCounters() []string {
+	names := make([]string, 0, len(r.ErrorIncreases))
+	for name := range r.ErrorIncreases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DiffCounters computes per second rates from two snapshots taken interval apart
+func DiffCounters(before, after InterfaceCounters, interval time.Duration) (CounterRates, error) {
+	if interval <= 0 {
+		return CounterRates{}, fmt.Errorf("interval between snapshots must be positive, got %v", interval)
+	}
+
+	rates := CounterRates{Interval: interval, ErrorIncreases: map[string]uint64{}}
+	delta := func(b, a uint64) uint64 {
+		if a < b {
+			rates.Cleared = true
+			return a
+		}
+		return a - b
+	}
+	perSecond := func(b, a uint64) float64 {
+		return float64(delta(b, a)) / interval.Seconds()
+	}
+
+	rates.InBitsPerSecond = 8 * perSecond(before.InBytes, after.InBytes)
+	rates.OutBitsPerSecond = 8 * perSecond(before.OutBytes, after.OutBytes)
+	rates.InPacketsPerSecond = perSecond(before.InPackets, after.InPackets)
+	rates.OutPacketsPerSecond = perSecond(before.OutPackets, after.OutPackets)
+	rates.InErrorsPerSecond = perSecond(before.InErrors, after.InErrors)
+	rates.OutErrorsPerSecond = perSecond(before.OutErrors, after.OutErrors)
+	rates.InDiscardsPerSecond = perSecond(before.InDiscards, after.InDiscards)
+	rates.OutDiscardsPerSecond = perSecond(before.OutDiscards, after.OutDiscards)
+
+	previous := before.errorCounters()
+	for name, value := range after.errorCounters() {
+		if d := delta(previous[name], value); d > 0 {
+			rates.ErrorIncreases[name] = d
+		}
+	}
+	return rates, nil
+}
+
+// DiffInterfaces computes the rates of every interface present in both snapshots
+func DiffInterfaces(before, after *InfoInterfaces, interval time.Duration) (map[string]CounterRates, error) {
+	rates := map[string]CounterRates{}
+	for name, intf := range after.Interfaces {
+		previous, ok := before.Interfaces[name]
+		if !ok {
+			continue
+		}
+		r, err := DiffCounters(previous.Counters, intf.Counters, interval)
+		if err != nil {
+			return nil, err
+		}
+		rates[name] = r
+	}
+	return rates, nil
+}
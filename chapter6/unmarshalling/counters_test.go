@@ -0,0 +1,105 @@
+package unmarshalling_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+	"time"
+	"unmarshalling"
+)
+
+func TestDiffCounters(t *testing.T) {
+	before := unmarshalling.InterfaceCounters{
+		InPackets: 1000, InBytes: 1000000, OutPackets: 2000, OutBytes: 3000000,
+		InErrors: 5, CRC: 5, OutDiscards: 1,
+	}
+	tests := []struct {
+		name  string
+		after unmarshalling.InterfaceCounters
+		want  unmarshalling.CounterRates
+	}{
+		{
+			name: "Traffic without errors",
+			after: unmarshalling.InterfaceCounters{
+				InPackets: 4000, InBytes: 1750000, OutPackets: 2600, OutBytes: 3150000,
+				InErrors: 5, CRC: 5, OutDiscards: 1,
+			},
+			want: unmarshalling.CounterRates{
+				Interval:            30 * time.Second,
+				InBitsPerSecond:     200000,
+				OutBitsPerSecond:    40000,
+				InPacketsPerSecond:  100,
+				OutPacketsPerSecond: 20,
+				ErrorIncreases:      map[string]uint64{},
+			},
+		},
+		{
+			name: "CRC errors and discards increase",
+			after: unmarshalling.InterfaceCounters{
+				InPackets: 1000, InBytes: 1000000, OutPackets: 2000, OutBytes: 3000000,
+				InErrors: 35, CRC: 35, OutDiscards: 7,
+			},
+			want: unmarshalling.CounterRates{
+				Interval:             30 * time.Second,
+				InErrorsPerSecond:    1,
+				OutDiscardsPerSecond: 0.2,
+				ErrorIncreases:       map[string]uint64{"InErrors": 30, "CRC": 30, "OutDiscards": 6},
+			},
+		},
+		{
+			name: "Counters cleared between snapshots",
+			after: unmarshalling.InterfaceCounters{
+				InPackets: 300, InBytes: 30000, OutPackets: 600, OutBytes: 60000,
+			},
+			want: unmarshalling.CounterRates{
+				Interval:            30 * time.Second,
+				InBitsPerSecond:     8000,
+				OutBitsPerSecond:    16000,
+				InPacketsPerSecond:  10,
+				OutPacketsPerSecond: 20,
+				ErrorIncreases:      map[string]uint64{},
+				Cleared:             true,
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := unmarshalling.DiffCounters(before, tc.after, 30*time.Second)
+			if err != nil {
+				t.Fatalf("DiffCounters got error: %v, want nil", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("DiffCounters returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := unmarshalling.DiffCounters(before, before, 0); err == nil {
+		t.Errorf("DiffCounters with a zero interval got nil error")
+	}
+}
+
+func TestDiffInterfaces(t *testing.T) {
+	before := &unmarshalling.InfoInterfaces{Interfaces: map[string]unmarshalling.Interface{
+		"Ethernet1/1": {Counters: unmarshalling.InterfaceCounters{InBytes: 1000, Runts: 2}},
+		"Ethernet1/2": {},
+	}}
+	after := &unmarshalling.InfoInterfaces{Interfaces: map[string]unmarshalling.Interface{
+		"Ethernet1/1": {Counters: unmarshalling.InterfaceCounters{InBytes: 11000, Runts: 4}},
+		"Ethernet1/3": {},
+	}}
+
+	got, err := unmarshalling.DiffInterfaces(before, after, 10*time.Second)
+	if err != nil {
+		t.Fatalf("DiffInterfaces got error: %v, want nil", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("DiffInterfaces returned %d interfaces, want 1", len(got))
+	}
+	rates := got["Ethernet1/1"]
+	if rates.InBitsPerSecond != 8000 || !rates.ErrorsIncreased() {
+		t.Errorf("DiffInterfaces returned %+v", rates)
+	}
+	if diff := cmp.Diff([]string{"Runts"}, rates.IncreasedCounters()); diff != "" {
+		t.Errorf("IncreasedCounters returned diff (-want +got):\n%s", diff)
+	}
+}
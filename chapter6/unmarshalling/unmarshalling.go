@@ -2,6 +2,8 @@ package unmarshalling
 
 import (
 	"encoding/json"
+	"fmt"
+	"time"
 )
 
 type InfoInterfaces struct {
@@ -9,11 +11,16 @@ type InfoInterfaces struct {
 }
 type Interface struct {
 	Description string
-	Speed       string
-	MTU         string
-	OperStatus  string
-	AdminStatus string
+	// Bandwidth is in bits per second
+	Bandwidth   uint64
+	MTU         int
+	OperStatus  LinkState
+	AdminStatus LinkState
+	// LinkFlapped is how long ago the link last changed state
+	LinkFlapped time.Duration
+	Counters    InterfaceCounters
 }
+
 type deviceInterface struct {
 	TABLEInterface struct {
 		ROWInterface struct {
@@ -123,12 +130,105 @@ func ParseInterfaces(js []byte) (*InfoInterfaces, error) {
 	if err != nil {
 		return nil, err
 	}
-	intfs[devInt.TABLEInterface.ROWInterface.Interface] = Interface{
-		Description: devInt.TABLEInterface.ROWInterface.Desc,
-		Speed:       devInt.TABLEInterface.ROWInterface.EthBw,
-		MTU:         devInt.TABLEInterface.ROWInterface.EthMtu,
-		OperStatus:  devInt.TABLEInterface.ROWInterface.State,
-		AdminStatus: devInt.TABLEInterface.ROWInterface.AdminState,
+	row := devInt.TABLEInterface.ROWInterface
+	intf := Interface{Description: row.Desc}
+	if intf.Bandwidth, err = ParseBandwidth(row.EthBw); err != nil {
+		return nil, fmt.Errorf("interface %s: %w", row.Interface, err)
+	}
+	if intf.MTU, err = ParseMTU(row.EthMtu); err != nil {
+		return nil, fmt.Errorf("interface %s: %w", row.Interface, err)
+	}
+	if intf.OperStatus, err = ParseLinkState(row.State); err != nil {
+		return nil, fmt.Errorf("interface %s: %w", row.Interface, err)
+	}
+	if intf.AdminStatus, err = ParseLinkState(row.AdminState); err != nil {
+		return nil, fmt.Errorf("interface %s: %w", row.Interface, err)
+	}
+	if intf.LinkFlapped, err = ParseTimer(row.EthLinkFlapped); err != nil {
+		return nil, fmt.Errorf("interface %s: invalid link flapped: %w", row.Interface, err)
+	}
+
+	c := &intf.Counters
+	counters := []struct {
+		name  string
+		value string
+		field *uint64
+	}{
+		{"eth_inpkts", row.EthInpkts, &c.InPackets},
+		{"eth_inbytes", row.EthInbytes, &c.InBytes},
+		{"eth_inucast", row.EthInucast, &c.InUnicast},
+		{"eth_inmcast", row.EthInmcast, &c.InMulticast},
+		{"eth_inbcast", row.EthInbcast, &c.InBroadcast},
+		{"eth_jumbo_inpkts", row.EthJumboInpkts, &c.InJumbo},
+		{"eth_inerr", row.EthInerr, &c.InErrors},
+		{"eth_indiscard", row.EthIndiscard, &c.InDiscards},
+		{"eth_in_ifdown_drops", row.EthInIfdownDrops, &c.InIfDownDrops},
+		{"eth_inpause", row.EthInpause, &c.InPause},
+		{"eth_crc", row.EthCrc, &c.CRC},
+		{"eth_stomped_crc", row.EthStompedCrc, &c.StompedCRC},
+		{"eth_runts", row.EthRunts, &c.Runts},
+		{"eth_giants", row.EthGiants, &c.Giants},
+		{"eth_frame", row.EthFrame, &c.Frame},
+		{"eth_overrun", row.EthOverrun, &c.Overrun},
+		{"eth_underrun", row.EthUnderrun, &c.Underrun},
+		{"eth_ignored", row.EthIgnored, &c.Ignored},
+		{"eth_nobuf", row.EthNobuf, &c.NoBuffer},
+		{"eth_storm_supp", row.EthStormSupp, &c.StormSuppressed},
+		{"eth_outpkts", row.EthOutpkts, &c.OutPackets},
+		{"eth_outbytes", row.EthOutbytes, &c.OutBytes},
+		{"eth_outucast", row.EthOutucast, &c.OutUnicast},
+		{"eth_outmcast", row.EthOutmcast, &c.OutMulticast},
+		{"eth_outbcast", row.EthOutbcast, &c.OutBroadcast},
+		{"eth_jumbo_outpkts", row.EthJumboOutpkts, &c.OutJumbo},
+		{"eth_outerr", row.EthOuterr, &c.OutErrors},
+		{"eth_outdiscard", row.EthOutdiscard, &c.OutDiscards},
+		{"eth_outpause", row.EthOutpause, &c.OutPause},
+		{"eth_coll", row.EthColl, &c.Collisions},
+		{"eth_latecoll", row.EthLatecoll, &c.LateCollisions},
+		{"eth_deferred", row.EthDeferred, &c.Deferred},
+		{"eth_lostcarrier", row.EthLostcarrier, &c.LostCarrier},
+		{"eth_nocarrier", row.EthNocarrier, &c.NoCarrier},
+		{"eth_babbles", row.EthBabbles, &c.Babbles},
+		{"eth_reset_cntr", row.EthResetCntr, &c.Resets},
+	}
+	for _, counter := range counters {
+		if *counter.field, err = ParseCounter(counter.value); err != nil {
+			return nil, fmt.Errorf("interface %s: %s: %w", row.Interface, counter.name, err)
+		}
+	}
+
+	rates := []struct {
+		interval, inBits, inPkts, outBits, outPkts string
+	}{
+		{row.EthLoadInterval1Rx, row.EthInrate1Bits, row.EthInrate1Pkts, row.EthOutrate1Bits, row.EthOutrate1Pkts},
+		{row.EthLoadInterval2Rx, row.EthInrate2Bits, row.EthInrate2Pkts, row.EthOutrate2Bits, row.EthOutrate2Pkts},
+	}
+	for _, r := range rates {
+		if r.interval == "" {
+			continue
+		}
+		var rate InterfaceRate
+		seconds, err := ParseCounter(r.interval)
+		if err != nil {
+			return nil, fmt.Errorf("interface %s: load interval: %w", row.Interface, err)
+		}
+		rate.Interval = time.Duration(seconds) * time.Second
+		for _, v := range []struct {
+			value string
+			field *uint64
+		}{
+			{r.inBits, &rate.InBits},
+			{r.inPkts, &rate.InPackets},
+			{r.outBits, &rate.OutBits},
+			{r.outPkts, &rate.OutPackets},
+		} {
+			if *v.field, err = ParseCounter(v.value); err != nil {
+				return nil, fmt.Errorf("interface %s: rate: %w", row.Interface, err)
+			}
+		}
+		c.Rates = append(c.Rates, rate)
 	}
+
+	intfs[row.Interface] = intf
 	return &InfoInterfaces{Interfaces: intfs}, nil
 }
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 	"unmarshalling"
 )
 
@@ -19,10 +20,30 @@ func TestParseInterfaces(t *testing.T) {
 				Interfaces: map[string]unmarshalling.Interface{
 					"Ethernet1/1": {
 						Description: "another.device:peering-interface",
-						Speed:       "100000000",
-						MTU:         "9216",
-						OperStatus:  "up",
-						AdminStatus: "up",
+						Bandwidth:   100000000000,
+						MTU:         9216,
+						OperStatus:  unmarshalling.LinkUp,
+						AdminStatus: unmarshalling.LinkUp,
+						LinkFlapped: 24 * 24 * time.Hour,
+						Counters: unmarshalling.InterfaceCounters{
+							InPackets:    66859362450,
+							InBytes:      79167802986755,
+							InUnicast:    66859156438,
+							InMulticast:  190797,
+							InBroadcast:  15215,
+							OutPackets:   20683567370,
+							OutBytes:     14412739958342,
+							OutUnicast:   20653978075,
+							OutMulticast: 29364335,
+							OutBroadcast: 224960,
+							InJumbo:      1589825405,
+							OutJumbo:     1927803646,
+							Resets:       1,
+							Rates: []unmarshalling.InterfaceRate{
+								{Interval: 30 * time.Second, InBits: 210966568, InPackets: 22665, OutBits: 47161280, OutPackets: 12977},
+								{Interval: 300 * time.Second, InBits: 405778064, InPackets: 39696, OutBits: 57008112, OutPackets: 12639},
+							},
+						},
 					},
 				},
 			},
@@ -44,3 +65,40 @@ func TestParseInterfaces(t *testing.T) {
 		})
 	}
 }
+
+func TestParseInterfacesMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		js   string
+		want string
+	}{
+		{
+			name: "MTU",
+			js:   `{"TABLE_interface": {"ROW_interface": {"interface": "Ethernet1/1", "eth_mtu": "jumbo", "eth_bw": "1000000"}}}`,
+			want: `interface Ethernet1/1: invalid MTU "jumbo"`,
+		},
+		{
+			name: "Link flapped",
+			js:   `{"TABLE_interface": {"ROW_interface": {"interface": "Ethernet1/1", "eth_mtu": "1500", "eth_bw": "1000000", "eth_link_flapped": "a while"}}}`,
+			want: `interface Ethernet1/1: invalid link flapped: invalid timer "a while"`,
+		},
+		{
+			name: "Link flapped overflow",
+			js:   `{"TABLE_interface": {"ROW_interface": {"interface": "Ethernet1/1", "eth_mtu": "1500", "eth_bw": "1000000", "eth_link_flapped": "99999999999999999999d"}}}`,
+			want: `interface Ethernet1/1: invalid link flapped: invalid timer "99999999999999999999d": strconv.ParseInt: parsing "99999999999999999999": value out of range`,
+		},
+		{
+			name: "Counter",
+			js:   `{"TABLE_interface": {"ROW_interface": {"interface": "Ethernet1/1", "eth_mtu": "1500", "eth_bw": "1000000", "eth_inerr": "-3"}}}`,
+			want: `interface Ethernet1/1: eth_inerr: invalid counter "-3"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := unmarshalling.ParseInterfaces([]byte(tc.js))
+			if err == nil || err.Error() != tc.want {
+				t.Errorf("ParseInterfaces got error: %v, want %s", err, tc.want)
+			}
+		})
+	}
+}